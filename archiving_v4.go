@@ -135,7 +135,7 @@ func (c *GitHubClient) QueryArchivableRepos(username string, maxForks, maxStars,
 				Str("username", username).
 				Str("operation", "queryArchivableRepositories").
 				Msg("error querying for archivable repositories")
-			return nil, NewGitHubAPIError(0, "query archivable repositories", "",
+			return nil, classifyAPIError(0, "query archivable repositories", "",
 				fmt.Sprintf("failed to query repositories for user %s", username), err)
 		}
 
@@ -173,7 +173,7 @@ func (c *GitHubClient) MutateArchiveRepository(repo RepositoryInfo) error {
 			Str("operation", "archiveRepository").
 			Str("repositoryName", string(repo.Name)).
 			Msg("Unable to archive repository")
-		return NewGitHubAPIError(0, "archive repository", string(repo.Name), "failed to archive repository", err)
+		return classifyAPIError(0, "archive repository", string(repo.Name), "failed to archive repository", err)
 	}
 	return nil
 }
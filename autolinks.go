@@ -0,0 +1,200 @@
+package ownershit
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/go-github/v66/github"
+	"github.com/rs/zerolog/log"
+)
+
+// autolinkNumberPlaceholder is the token GitHub substitutes with the matched reference.
+const autolinkNumberPlaceholder = "<num>"
+
+// Autolink defines an autolink reference, e.g. turning JIRA-123 into a link to the issue tracker.
+type Autolink struct {
+	KeyPrefix      string `yaml:"key_prefix"`
+	URLTemplate    string `yaml:"url_template"`
+	IsAlphanumeric *bool  `yaml:"is_alphanumeric,omitempty"`
+}
+
+// alphanumeric returns the effective is_alphanumeric value; GitHub defaults it to true.
+func (a Autolink) alphanumeric() bool {
+	if a.IsAlphanumeric == nil {
+		return true
+	}
+	return *a.IsAlphanumeric
+}
+
+// AutolinkPlan describes the changes needed to reconcile a repository's autolinks.
+type AutolinkPlan struct {
+	Create []Autolink
+	Delete []*github.Autolink
+}
+
+// HasChanges reports whether the plan contains any drift.
+func (p *AutolinkPlan) HasChanges() bool {
+	return len(p.Create) > 0 || len(p.Delete) > 0
+}
+
+// ValidateAutolinks checks autolink entries for required fields and duplicate key prefixes.
+func ValidateAutolinks(field string, autolinks []Autolink) error {
+	seen := make(map[string]bool)
+	for i, a := range autolinks {
+		entry := fmt.Sprintf("%s[%d]", field, i)
+		prefix := strings.TrimSpace(a.KeyPrefix)
+		if prefix == "" {
+			return NewConfigValidationError(entry+".key_prefix", a.KeyPrefix, "key prefix cannot be empty", nil)
+		}
+		if !strings.Contains(a.URLTemplate, autolinkNumberPlaceholder) {
+			return NewConfigValidationError(entry+".url_template", a.URLTemplate,
+				fmt.Sprintf("url template must contain %s", autolinkNumberPlaceholder), nil)
+		}
+		if seen[prefix] {
+			return NewConfigValidationError(entry+".key_prefix", prefix, "duplicate autolink key prefix", nil)
+		}
+		seen[prefix] = true
+	}
+	return nil
+}
+
+// ResolveAutolinks merges global autolinks with repository entries; a repository entry
+// replaces a global one with the same key prefix. The result is sorted by key prefix.
+func ResolveAutolinks(global, repo []Autolink) []Autolink {
+	byPrefix := make(map[string]Autolink, len(global)+len(repo))
+	for _, a := range global {
+		byPrefix[a.KeyPrefix] = a
+	}
+	for _, a := range repo {
+		byPrefix[a.KeyPrefix] = a
+	}
+	resolved := make([]Autolink, 0, len(byPrefix))
+	for _, a := range byPrefix {
+		resolved = append(resolved, a)
+	}
+	sort.Slice(resolved, func(i, j int) bool { return resolved[i].KeyPrefix < resolved[j].KeyPrefix })
+	return resolved
+}
+
+// PlanAutolinks compares the desired autolinks with those on GitHub. Autolinks cannot be
+// edited in place, so a changed entry results in a delete of the old reference and a create
+// of the new one. Existing references not present in desired are deleted.
+func PlanAutolinks(desired []Autolink, existing []*github.Autolink) *AutolinkPlan {
+	plan := &AutolinkPlan{}
+	current := make(map[string]*github.Autolink, len(existing))
+	for _, e := range existing {
+		current[e.GetKeyPrefix()] = e
+	}
+	wanted := make(map[string]bool, len(desired))
+	for _, d := range desired {
+		wanted[d.KeyPrefix] = true
+		e, ok := current[d.KeyPrefix]
+		if !ok {
+			plan.Create = append(plan.Create, d)
+			continue
+		}
+		if e.GetURLTemplate() != d.URLTemplate || e.GetIsAlphanumeric() != d.alphanumeric() {
+			plan.Delete = append(plan.Delete, e)
+			plan.Create = append(plan.Create, d)
+		}
+	}
+	for _, e := range existing {
+		if !wanted[e.GetKeyPrefix()] {
+			plan.Delete = append(plan.Delete, e)
+		}
+	}
+	return plan
+}
+
+// applyAutolinks reconciles the repository's autolinks with the resolved list. Autolinks are only
+// managed when the configuration mentions them, so an explicit empty list deletes them all.
+func applyAutolinks(settings *PermissionsSettings, repo *Repository, client *GitHubClient, dryRun bool) error {
+	if settings.Autolinks == nil && repo.Autolinks == nil {
+		return nil
+	}
+	desired := ResolveAutolinks(settings.Autolinks, repo.Autolinks)
+
+	plan, err := client.PlanAutolinks(*settings.Organization, *repo.Name, desired)
+	if err != nil {
+		log.Err(err).
+			Str("repository", *repo.Name).
+			Str("organization", *settings.Organization).
+			Msg("reading autolinks")
+		return err
+	}
+	if !plan.HasChanges() {
+		log.Debug().Str("repository", *repo.Name).Msg("autolinks up to date")
+		return nil
+	}
+
+	if dryRun {
+		for _, a := range plan.Delete {
+			log.Info().
+				Str("repository", *repo.Name).
+				Str("key_prefix", a.GetKeyPrefix()).
+				Str("url_template", a.GetURLTemplate()).
+				Msg("Would delete autolink")
+		}
+		for _, a := range plan.Create {
+			log.Info().
+				Str("repository", *repo.Name).
+				Str("key_prefix", a.KeyPrefix).
+				Str("url_template", a.URLTemplate).
+				Msg("Would create autolink")
+		}
+		return nil
+	}
+
+	if err := client.ApplyAutolinkPlan(*settings.Organization, *repo.Name, plan); err != nil {
+		log.Err(err).
+			Str("repository", *repo.Name).
+			Str("organization", *settings.Organization).
+			Msg("synchronizing autolinks")
+		return err
+	}
+	return nil
+}
+
+// PlanAutolinks lists the repository's autolinks and computes the changes needed to match desired.
+func (c *GitHubClient) PlanAutolinks(org, repo string, desired []Autolink) (*AutolinkPlan, error) {
+	existing, resp, err := c.Repositories.ListAutolinks(c.Context, org, repo, nil)
+	if err != nil {
+		return nil, classifyAPIError(responseStatus(resp), "list autolinks", fmt.Sprintf("%s/%s", org, repo),
+			"failed to list autolinks", err)
+	}
+	return PlanAutolinks(desired, existing), nil
+}
+
+// ApplyAutolinkPlan deletes stale autolinks and creates missing ones. Deletes run first so that a
+// changed entry can be recreated under the same key prefix.
+func (c *GitHubClient) ApplyAutolinkPlan(org, repo string, plan *AutolinkPlan) error {
+	repoPath := fmt.Sprintf("%s/%s", org, repo)
+	for _, a := range plan.Delete {
+		resp, err := c.Repositories.DeleteAutolink(c.Context, org, repo, a.GetID())
+		if err != nil {
+			return classifyAPIError(responseStatus(resp), "delete autolink", repoPath,
+				fmt.Sprintf("failed to delete autolink %s", a.GetKeyPrefix()), err)
+		}
+		log.Info().
+			Str("repository", repoPath).
+			Str("key_prefix", a.GetKeyPrefix()).
+			Msg("deleted autolink")
+	}
+	for _, a := range plan.Create {
+		_, resp, err := c.Repositories.AddAutolink(c.Context, org, repo, &github.AutolinkOptions{
+			KeyPrefix:      github.String(a.KeyPrefix),
+			URLTemplate:    github.String(a.URLTemplate),
+			IsAlphanumeric: github.Bool(a.alphanumeric()),
+		})
+		if err != nil {
+			return classifyAPIError(responseStatus(resp), "create autolink", repoPath,
+				fmt.Sprintf("failed to create autolink %s", a.KeyPrefix), err)
+		}
+		log.Info().
+			Str("repository", repoPath).
+			Str("key_prefix", a.KeyPrefix).
+			Msg("created autolink")
+	}
+	return nil
+}
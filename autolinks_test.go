@@ -0,0 +1,139 @@
+package ownershit
+
+import (
+	"testing"
+
+	"github.com/google/go-github/v66/github"
+	"go.uber.org/mock/gomock"
+	"gopkg.in/yaml.v3"
+)
+
+func TestValidateAutolinks(t *testing.T) {
+	tests := []struct {
+		name      string
+		autolinks []Autolink
+		wantErr   bool
+	}{
+		{name: "empty", autolinks: nil},
+		{name: "valid", autolinks: []Autolink{{KeyPrefix: "JIRA-", URLTemplate: "https://jira.example.com/browse/JIRA-<num>"}}},
+		{name: "missing prefix", autolinks: []Autolink{{URLTemplate: "https://x/<num>"}}, wantErr: true},
+		{name: "missing placeholder", autolinks: []Autolink{{KeyPrefix: "JIRA-", URLTemplate: "https://x/"}}, wantErr: true},
+		{name: "duplicate prefix", autolinks: []Autolink{
+			{KeyPrefix: "JIRA-", URLTemplate: "https://a/<num>"},
+			{KeyPrefix: "JIRA-", URLTemplate: "https://b/<num>"},
+		}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateAutolinks("autolinks", tt.autolinks); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateAutolinks() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestResolveAutolinks(t *testing.T) {
+	global := []Autolink{
+		{KeyPrefix: "OPS-", URLTemplate: "https://jira/OPS-<num>"},
+		{KeyPrefix: "JIRA-", URLTemplate: "https://jira/JIRA-<num>"},
+	}
+	repo := []Autolink{{KeyPrefix: "JIRA-", URLTemplate: "https://other/JIRA-<num>"}}
+
+	got := ResolveAutolinks(global, repo)
+	if len(got) != 2 {
+		t.Fatalf("expected 2 autolinks, got %d", len(got))
+	}
+	if got[0].KeyPrefix != "JIRA-" || got[0].URLTemplate != "https://other/JIRA-<num>" {
+		t.Errorf("expected repository override for JIRA-, got %+v", got[0])
+	}
+	if got[1].KeyPrefix != "OPS-" {
+		t.Errorf("expected OPS- second, got %+v", got[1])
+	}
+}
+
+func TestPlanAutolinks(t *testing.T) {
+	existing := []*github.Autolink{
+		{ID: github.Int64(1), KeyPrefix: github.String("JIRA-"), URLTemplate: github.String("https://jira/JIRA-<num>"), IsAlphanumeric: github.Bool(true)},
+		{ID: github.Int64(2), KeyPrefix: github.String("OLD-"), URLTemplate: github.String("https://old/<num>"), IsAlphanumeric: github.Bool(true)},
+		{ID: github.Int64(3), KeyPrefix: github.String("OPS-"), URLTemplate: github.String("https://ops/<num>"), IsAlphanumeric: github.Bool(true)},
+	}
+	desired := []Autolink{
+		{KeyPrefix: "JIRA-", URLTemplate: "https://jira/JIRA-<num>"},
+		{KeyPrefix: "OPS-", URLTemplate: "https://ops/<num>", IsAlphanumeric: boolPtr(false)},
+		{KeyPrefix: "NEW-", URLTemplate: "https://new/<num>"},
+	}
+
+	plan := PlanAutolinks(desired, existing)
+	if !plan.HasChanges() {
+		t.Fatal("expected drift")
+	}
+	deleted := map[int64]bool{}
+	for _, d := range plan.Delete {
+		deleted[d.GetID()] = true
+	}
+	if deleted[1] || !deleted[2] || !deleted[3] || len(plan.Delete) != 2 {
+		t.Errorf("unexpected deletes: %v", deleted)
+	}
+	created := map[string]bool{}
+	for _, c := range plan.Create {
+		created[c.KeyPrefix] = true
+	}
+	if !created["OPS-"] || !created["NEW-"] || len(plan.Create) != 2 {
+		t.Errorf("unexpected creates: %v", created)
+	}
+
+	if PlanAutolinks(desired[:1], existing[:1]).HasChanges() {
+		t.Error("expected no drift for matching autolinks")
+	}
+}
+
+func TestApplyAutolinkPlan(t *testing.T) {
+	m := setupMocks(t)
+	plan := &AutolinkPlan{
+		Delete: []*github.Autolink{{ID: github.Int64(7), KeyPrefix: github.String("OLD-")}},
+		Create: []Autolink{{KeyPrefix: "JIRA-", URLTemplate: "https://jira/JIRA-<num>"}},
+	}
+	gomock.InOrder(
+		m.repoMock.EXPECT().DeleteAutolink(gomock.Any(), "org", "repo", int64(7)).Return(defaultGoodResponse, nil),
+		m.repoMock.EXPECT().AddAutolink(gomock.Any(), "org", "repo", &github.AutolinkOptions{
+			KeyPrefix:      github.String("JIRA-"),
+			URLTemplate:    github.String("https://jira/JIRA-<num>"),
+			IsAlphanumeric: github.Bool(true),
+		}).Return(&github.Autolink{}, defaultGoodResponse, nil),
+	)
+	if err := m.client.ApplyAutolinkPlan("org", "repo", plan); err != nil {
+		t.Fatalf("ApplyAutolinkPlan() error = %v", err)
+	}
+}
+
+func TestApplyAutolinksDryRunReportsDrift(t *testing.T) {
+	m := setupMocks(t)
+	settings := &PermissionsSettings{
+		Organization: stringPtr("org"),
+		Autolinks:    []Autolink{{KeyPrefix: "JIRA-", URLTemplate: "https://jira/JIRA-<num>"}},
+	}
+	m.repoMock.EXPECT().ListAutolinks(gomock.Any(), "org", "repo", gomock.Nil()).Return(nil, defaultGoodResponse, nil)
+	// Dry run must not call AddAutolink or DeleteAutolink.
+	applyAutolinks(settings, &Repository{Name: stringPtr("repo")}, m.client, true)
+}
+
+func TestApplyAutolinksEmptyListDeletesAll(t *testing.T) {
+	m := setupMocks(t)
+	repo := &Repository{Name: stringPtr("repo")}
+
+	// Without an autolinks key the repository's autolinks are not managed.
+	if err := applyAutolinks(&PermissionsSettings{Organization: stringPtr("org")}, repo, m.client, false); err != nil {
+		t.Fatalf("applyAutolinks() error = %v", err)
+	}
+
+	var settings PermissionsSettings
+	if err := yaml.Unmarshal([]byte("organization: org\nautolinks: []\n"), &settings); err != nil {
+		t.Fatal(err)
+	}
+	m.repoMock.EXPECT().ListAutolinks(gomock.Any(), "org", "repo", gomock.Nil()).
+		Return([]*github.Autolink{{ID: github.Int64(7), KeyPrefix: github.String("OLD-")}}, defaultGoodResponse, nil)
+	m.repoMock.EXPECT().DeleteAutolink(gomock.Any(), "org", "repo", int64(7)).Return(defaultGoodResponse, nil)
+	if err := applyAutolinks(&settings, repo, m.client, false); err != nil {
+		t.Fatalf("applyAutolinks() error = %v", err)
+	}
+}
@@ -0,0 +1,299 @@
+package ownershit
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// DefaultCacheTTL is how long slow-changing data, such as organization teams, is cached.
+	DefaultCacheTTL = 10 * time.Minute
+	// DefaultCacheMaxAge is how long a cache entry that is neither written nor revalidated is
+	// kept on disk.
+	DefaultCacheMaxAge = 7 * 24 * time.Hour
+	// cacheHeader marks responses served from the cache after a 304 Not Modified.
+	cacheHeader = "X-Ownershit-Cache"
+)
+
+// HTTPCache stores REST responses on disk so repeated GET requests are sent as conditional
+// requests, and keeps other slow-changing data for a TTL. GitHub does not count 304 Not Modified
+// responses against the REST rate limit. Entries are keyed by a scope identifying who the client
+// authenticates as, so identities never see each other's responses, while rotating installation
+// tokens keep using the same entries. A nil HTTPCache caches nothing.
+type HTTPCache struct {
+	dir string
+	// TTL is how long values stored with Put are returned by Get.
+	TTL time.Duration
+	now func() time.Time
+}
+
+// DefaultCacheDir returns the ownershit directory in the user's cache directory.
+func DefaultCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return filepath.Join(os.TempDir(), "ownershit-cache")
+	}
+	return filepath.Join(dir, "ownershit")
+}
+
+// NewHTTPCache creates a cache in dir, creating the directory if needed, and removes entries that
+// were not written or revalidated within DefaultCacheMaxAge.
+func NewHTTPCache(dir string) (*HTTPCache, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("creating cache directory: %w", err)
+	}
+	cache := &HTTPCache{dir: dir, TTL: DefaultCacheTTL, now: time.Now}
+	cache.Prune(DefaultCacheMaxAge)
+	return cache, nil
+}
+
+// Prune removes the entries last written or revalidated more than maxAge ago. Failures only
+// leave entries behind, so they are logged and ignored.
+func (c *HTTPCache) Prune(maxAge time.Duration) {
+	if c == nil {
+		return
+	}
+	cutoff := c.now().Add(-maxAge)
+	removed := 0
+	for _, kind := range []string{"http", "values"} {
+		entries, err := os.ReadDir(filepath.Join(c.dir, kind))
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			info, err := entry.Info()
+			if err != nil || entry.IsDir() || !info.ModTime().Before(cutoff) {
+				continue
+			}
+			path := filepath.Join(c.dir, kind, entry.Name())
+			if err := os.Remove(path); err != nil {
+				log.Debug().Err(err).Str("path", path).Msg("stale cache entry not removed")
+				continue
+			}
+			removed++
+		}
+	}
+	if removed > 0 {
+		log.Debug().Int("entries", removed).Str("dir", c.dir).Msg("pruned stale cache entries")
+	}
+}
+
+var (
+	activeCacheMu sync.RWMutex
+	activeCache   *HTTPCache
+)
+
+// SetHTTPCache sets the cache used by clients created afterwards. Nil disables caching, which is
+// the default.
+func SetHTTPCache(cache *HTTPCache) {
+	activeCacheMu.Lock()
+	defer activeCacheMu.Unlock()
+	activeCache = cache
+}
+
+// ActiveHTTPCache returns the cache set with SetHTTPCache, or nil.
+func ActiveHTTPCache() *HTTPCache {
+	activeCacheMu.RLock()
+	defer activeCacheMu.RUnlock()
+	return activeCache
+}
+
+// CacheScope returns an opaque cache key component identifying parts, such as a token, without
+// revealing them.
+func CacheScope(parts ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
+
+// cachedValue is a value stored with Put.
+type cachedValue struct {
+	Expires time.Time       `json:"expires"`
+	Value   json.RawMessage `json:"value"`
+}
+
+// Get decodes the value stored under key into v and reports whether an unexpired value was found.
+func (c *HTTPCache) Get(key string, v interface{}) bool {
+	if c == nil {
+		return false
+	}
+	var entry cachedValue
+	if !c.read("values", key, &entry) || c.now().After(entry.Expires) {
+		return false
+	}
+	if err := json.Unmarshal(entry.Value, v); err != nil {
+		log.Debug().Err(err).Str("key", key).Msg("discarding unreadable cache entry")
+		return false
+	}
+	log.Debug().Str("key", key).Msg("cache hit")
+	return true
+}
+
+// Put stores v under key for the cache's TTL.
+func (c *HTTPCache) Put(key string, v interface{}) {
+	if c == nil {
+		return
+	}
+	value, err := json.Marshal(v)
+	if err != nil {
+		log.Debug().Err(err).Str("key", key).Msg("value not cached")
+		return
+	}
+	c.write("values", key, cachedValue{Expires: c.now().Add(c.TTL), Value: value})
+}
+
+// cachedResponse is a response stored for conditional requests.
+type cachedResponse struct {
+	URL          string      `json:"url"`
+	ETag         string      `json:"etag,omitempty"`
+	LastModified string      `json:"last_modified,omitempty"`
+	Header       http.Header `json:"header"`
+	Body         []byte      `json:"body"`
+}
+
+// Transport returns a transport that sends GET requests through base as conditional requests
+// when a cached response exists, serving the cached body on 304 Not Modified. scope identifies who
+// the requests authenticate as, such as a CacheScope of the token or of the GitHub App
+// installation; it must not change when a token is refreshed.
+func (c *HTTPCache) Transport(scope string, base http.RoundTripper) http.RoundTripper {
+	if c == nil {
+		return base
+	}
+	return &cachingTransport{cache: c, scope: scope, base: base}
+}
+
+type cachingTransport struct {
+	cache *HTTPCache
+	scope string
+	base  http.RoundTripper
+}
+
+func (t *cachingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet || req.Header.Get("Range") != "" {
+		return t.base.RoundTrip(req)
+	}
+	key := req.URL.String() + "\n" + t.scope + "\n" + req.Header.Get("Accept")
+	var cached cachedResponse
+	found := t.cache.read("http", key, &cached)
+	if found {
+		req = req.Clone(req.Context())
+		if cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+	}
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case found && resp.StatusCode == http.StatusNotModified:
+		_ = resp.Body.Close()
+		log.Debug().Str("url", req.URL.Redacted()).Msg("not modified; using cached response")
+		t.cache.touch("http", key)
+		header := cached.Header.Clone()
+		// Rate limit and scope headers describe this request, not the cached one.
+		for name, values := range resp.Header {
+			header[name] = values
+		}
+		header.Set(cacheHeader, "revalidated")
+		return &http.Response{
+			Status:        "200 OK",
+			StatusCode:    http.StatusOK,
+			Proto:         resp.Proto,
+			ProtoMajor:    resp.ProtoMajor,
+			ProtoMinor:    resp.ProtoMinor,
+			Header:        header,
+			Body:          io.NopCloser(bytes.NewReader(cached.Body)),
+			ContentLength: int64(len(cached.Body)),
+			Request:       req,
+		}, nil
+	case resp.StatusCode == http.StatusOK && (resp.Header.Get("ETag") != "" || resp.Header.Get("Last-Modified") != ""):
+		body, err := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+		t.cache.write("http", key, cachedResponse{
+			URL:          req.URL.Redacted(),
+			ETag:         resp.Header.Get("ETag"),
+			LastModified: resp.Header.Get("Last-Modified"),
+			Header:       resp.Header.Clone(),
+			Body:         body,
+		})
+	}
+	return resp, nil
+}
+
+// path returns the file holding key in the kind subdirectory.
+func (c *HTTPCache) path(kind, key string) string {
+	return filepath.Join(c.dir, kind, CacheScope(key)+".json")
+}
+
+// read decodes the entry for key into v. Keys may hold credentials, so only paths are logged.
+func (c *HTTPCache) read(kind, key string, v interface{}) bool {
+	path := c.path(kind, key)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		log.Debug().Err(err).Str("path", path).Msg("discarding unreadable cache entry")
+		return false
+	}
+	return true
+}
+
+// touch marks the entry for key as used now, so Prune keeps it.
+func (c *HTTPCache) touch(kind, key string) {
+	now := c.now()
+	if err := os.Chtimes(c.path(kind, key), now, now); err != nil {
+		log.Debug().Err(err).Str("path", c.path(kind, key)).Msg("cache entry not touched")
+	}
+}
+
+// write stores v atomically. Failures only cost a cache miss, so they are logged and ignored.
+func (c *HTTPCache) write(kind, key string, v interface{}) {
+	path := c.path(kind, key)
+	if err := writeCacheFile(path, v); err != nil {
+		log.Debug().Err(err).Str("path", path).Msg("cache entry not written")
+	}
+}
+
+func writeCacheFile(path string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".entry-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
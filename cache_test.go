@@ -0,0 +1,118 @@
+package ownershit
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHTTPCacheConditionalRequests(t *testing.T) {
+	var notModified int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Remaining", "4999")
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			atomic.AddInt32(&notModified, 1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"name":"api"}`))
+	}))
+	defer server.Close()
+
+	cache, err := NewHTTPCache(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewHTTPCache() error = %v", err)
+	}
+	get := func(scope, token string) (*http.Response, string) {
+		t.Helper()
+		client := &http.Client{Transport: cache.Transport(scope, http.DefaultTransport)}
+		req, _ := http.NewRequest(http.MethodGet, server.URL+"/repos/acme/api", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("GET error = %v", err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp, string(body)
+	}
+
+	if resp, body := get("app-1", "one"); resp.StatusCode != http.StatusOK || body != `{"name":"api"}` {
+		t.Fatalf("first GET = %d %q", resp.StatusCode, body)
+	}
+	// A rotated installation token keeps the installation's entries.
+	resp, body := get("app-1", "rotated")
+	if resp.StatusCode != http.StatusOK || body != `{"name":"api"}` || resp.Header.Get(cacheHeader) == "" {
+		t.Errorf("revalidated GET = %d %q, headers %v", resp.StatusCode, body, resp.Header)
+	}
+	if resp.Header.Get("Content-Type") != "application/json" || resp.Header.Get("X-RateLimit-Remaining") != "4999" {
+		t.Errorf("cached and fresh headers should be merged: %v", resp.Header)
+	}
+	if atomic.LoadInt32(&notModified) != 1 {
+		t.Errorf("expected one 304, got %d", notModified)
+	}
+
+	// Another identity must not revalidate the first one's entry.
+	get("app-2", "two")
+	if atomic.LoadInt32(&notModified) != 1 {
+		t.Error("a different identity reused a cached response")
+	}
+}
+
+func TestHTTPCacheValues(t *testing.T) {
+	cache, err := NewHTTPCache(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewHTTPCache() error = %v", err)
+	}
+	now := time.Unix(1_700_000_000, 0)
+	cache.now = func() time.Time { return now }
+
+	cache.Put("teams acme", []string{"platform"})
+	var teams []string
+	if !cache.Get("teams acme", &teams) || len(teams) != 1 || teams[0] != "platform" {
+		t.Errorf("Get() = %v", teams)
+	}
+	now = now.Add(DefaultCacheTTL + time.Second)
+	if cache.Get("teams acme", &teams) {
+		t.Error("Get() returned an expired value")
+	}
+
+	var disabled *HTTPCache
+	disabled.Put("teams acme", teams)
+	if disabled.Get("teams acme", &teams) {
+		t.Error("a nil cache should never hit")
+	}
+}
+
+func TestHTTPCachePrune(t *testing.T) {
+	dir := t.TempDir()
+	cache, err := NewHTTPCache(dir)
+	if err != nil {
+		t.Fatalf("NewHTTPCache() error = %v", err)
+	}
+	cache.Put("stale", "old")
+	cache.Put("fresh", "new")
+	old := time.Now().Add(-DefaultCacheMaxAge - time.Hour)
+	if err := os.Chtimes(cache.path("values", "stale"), old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	// Opening the cache again prunes entries older than DefaultCacheMaxAge.
+	cache, err = NewHTTPCache(dir)
+	if err != nil {
+		t.Fatalf("NewHTTPCache() error = %v", err)
+	}
+	var value string
+	if cache.Get("stale", &value) {
+		t.Error("a stale entry survived pruning")
+	}
+	if !cache.Get("fresh", &value) || value != "new" {
+		t.Errorf("Get(fresh) = %q", value)
+	}
+}
@@ -0,0 +1,312 @@
+package ownershit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Khan/genqlient/graphql"
+	"github.com/google/go-github/v66/github"
+	"github.com/shurcooL/githubv4"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// GraphQL error types reported by GitHub.
+const (
+	GraphQLNotFound           = "NOT_FOUND"
+	GraphQLForbidden          = "FORBIDDEN"
+	GraphQLInsufficientScopes = "INSUFFICIENT_SCOPES"
+	GraphQLRateLimited        = "RATE_LIMITED"
+)
+
+// GraphQLError is an error from the GraphQL API, with the type GitHub assigned it. Errors in the
+// response body have a StatusCode of 200; requests GitHub rejects outright carry its HTTP status.
+type GraphQLError struct {
+	StatusCode int
+	Type       string
+	Path       []string
+	Message    string
+	// Header holds the response headers, including the rate limit headers.
+	Header http.Header
+	Err    error
+}
+
+func (e *GraphQLError) Error() string {
+	if e.Type != "" {
+		return fmt.Sprintf("%s (%s)", e.Message, e.Type)
+	}
+	return e.Message
+}
+
+func (e *GraphQLError) Unwrap() error {
+	return e.Err
+}
+
+// graphQLResponseKey is the context key under which graphQLClient collects the response details
+// of a request.
+type graphQLResponseKey struct{}
+
+// graphQLResponse holds the details of a GraphQL response that the GraphQL client discards.
+type graphQLResponse struct {
+	StatusCode int
+	Header     http.Header
+	Message    string `json:"message"`
+	Errors     []struct {
+		Type    string        `json:"type"`
+		Path    []interface{} `json:"path"`
+		Message string        `json:"message"`
+	} `json:"errors"`
+}
+
+// graphQLErrorTransport records the status, headers and errors of GraphQL responses for requests
+// sent by graphQLClient. Other requests pass through untouched.
+type graphQLErrorTransport struct {
+	base http.RoundTripper
+}
+
+func (t *graphQLErrorTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	info, ok := req.Context().Value(graphQLResponseKey{}).(*graphQLResponse)
+	if err != nil || !ok {
+		return resp, err
+	}
+	info.StatusCode = resp.StatusCode
+	info.Header = resp.Header.Clone()
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if resp.StatusCode != http.StatusOK || bytes.Contains(body, []byte(`"errors"`)) {
+		// A body that is not JSON only costs the error details.
+		_ = json.Unmarshal(body, info)
+	}
+	return resp, nil
+}
+
+// graphQLClient returns GraphQLErrors carrying the type and status GitHub reported, which the
+// wrapped client drops.
+type graphQLClient struct {
+	client GraphQLClient
+}
+
+func (g *graphQLClient) Query(ctx context.Context, q interface{}, variables map[string]interface{}) error {
+	info := &graphQLResponse{}
+	return info.error(g.client.Query(context.WithValue(ctx, graphQLResponseKey{}, info), q, variables))
+}
+
+func (g *graphQLClient) Mutate(ctx context.Context, m interface{}, input githubv4.Input, variables map[string]interface{}) error {
+	info := &graphQLResponse{}
+	return info.error(g.client.Mutate(context.WithValue(ctx, graphQLResponseKey{}, info), m, input, variables))
+}
+
+// error returns err with the recorded response details. Only the first GraphQL error is kept, as
+// the GraphQL client does.
+func (r *graphQLResponse) error(err error) error {
+	switch {
+	case err == nil || r.StatusCode == 0:
+		return err
+	case len(r.Errors) > 0:
+		first := r.Errors[0]
+		path := make([]string, 0, len(first.Path))
+		for _, elem := range first.Path {
+			path = append(path, fmt.Sprint(elem))
+		}
+		return &GraphQLError{
+			StatusCode: r.StatusCode,
+			Type:       first.Type,
+			Path:       path,
+			Message:    first.Message,
+			Header:     r.Header,
+			Err:        err,
+		}
+	case r.StatusCode != http.StatusOK:
+		message := r.Message
+		if message == "" {
+			message = http.StatusText(r.StatusCode)
+		}
+		return &GraphQLError{StatusCode: r.StatusCode, Message: message, Header: r.Header, Err: err}
+	}
+	return err
+}
+
+// graphQLStatus maps GraphQL error types to the equivalent HTTP status.
+var graphQLStatus = map[string]int{
+	GraphQLNotFound:           http.StatusNotFound,
+	GraphQLForbidden:          http.StatusForbidden,
+	GraphQLInsufficientScopes: http.StatusForbidden,
+	GraphQLRateLimited:        http.StatusTooManyRequests,
+}
+
+// repositoryNotResolved matches the GraphQL error for a repository that does not exist or is not
+// visible to the token.
+var repositoryNotResolved = regexp.MustCompile(`Could not resolve to a Repository with the name '([^/']+)/([^']+)'`)
+
+// classifyAPIError converts err, returned by the REST or GraphQL API during operation on
+// repository, into the typed errors of this package so callers can use errors.Is and errors.As.
+// The status code is taken from err; statusCode is used when err carries none, and is 0 when
+// no response was received.
+//
+//   - rate limits become a RateLimitError
+//   - 401 becomes an AuthenticationError
+//   - 403, FORBIDDEN and INSUFFICIENT_SCOPES become a PermissionDeniedError
+//   - a missing repository becomes a RepositoryNotFoundError
+//   - failures without a response become a NetworkError
+//
+// Other API errors, and the errors wrapped by the typed ones, are GitHubAPIErrors with the
+// response's status code; GraphQL error types are given their equivalent HTTP status.
+func classifyAPIError(statusCode int, operation, repository, message string, err error) error {
+	if err == nil {
+		return nil
+	}
+	var (
+		rateLimitErr *RateLimitError
+		githubRate   *github.RateLimitError
+		githubAbuse  *github.AbuseRateLimitError
+		responseErr  *github.ErrorResponse
+		graphQLErr   *GraphQLError
+		netErr       net.Error
+	)
+	switch {
+	case errors.As(err, &rateLimitErr):
+		// Already classified, by the retrying transport or an earlier call.
+		return err
+	case errors.As(err, &githubRate):
+		return NewRateLimitError(githubRate.Rate.Reset.Time, githubRate.Rate.Remaining, githubRate.Message, err)
+	case errors.As(err, &githubAbuse):
+		reset := time.Now().Add(secondaryRateLimitWait)
+		if githubAbuse.RetryAfter != nil {
+			reset = time.Now().Add(*githubAbuse.RetryAfter)
+		}
+		return NewRateLimitError(reset, 0, githubAbuse.Message, err)
+	case errors.As(err, &responseErr) && responseErr.Response != nil:
+		status := responseErr.Response.StatusCode
+		var owner, name string
+		if responseErr.Response.Request != nil {
+			owner, name = repositoryPath(responseErr.Response.Request.URL.Path)
+		}
+		if status == http.StatusTooManyRequests {
+			return NewRateLimitError(headerResetTime(responseErr.Response.Header), 0, responseErr.Message, err)
+		}
+		return classifyStatus(status, responseErr.Response.Header, operation, repository, message, responseErr.Message, owner, name, err)
+	case errors.As(err, &graphQLErr):
+		status := graphQLErr.StatusCode
+		if mapped, ok := graphQLStatus[graphQLErr.Type]; ok {
+			status = mapped
+		}
+		if status == http.StatusTooManyRequests {
+			remaining, _ := strconv.Atoi(graphQLErr.Header.Get("X-RateLimit-Remaining"))
+			return NewRateLimitError(headerResetTime(graphQLErr.Header), remaining, graphQLErr.Message, err)
+		}
+		var owner, name string
+		if match := repositoryNotResolved.FindStringSubmatch(graphQLErr.Message); match != nil {
+			owner, name = match[1], match[2]
+		}
+		return classifyStatus(status, graphQLErr.Header, operation, repository, message, graphQLErr.Message, owner, name, err)
+	case errors.As(err, &netErr):
+		target := ""
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			target = urlErr.URL
+		}
+		return NewNetworkError(operation, target, message, err)
+	case statusCode == http.StatusTooManyRequests:
+		return NewRateLimitError(time.Now().Add(secondaryRateLimitWait), 0, message, err)
+	case statusCode != 0:
+		return classifyStatus(statusCode, nil, operation, repository, message, "", "", "", err)
+	}
+	return NewGitHubAPIError(0, operation, repository, message, err)
+}
+
+// ClassifyAPIError converts err, returned by the API during operation on repository, into the
+// typed errors of this package; see classifyAPIError. It also understands the errors returned by
+// genqlient clients, such as the one in the v4api package.
+func ClassifyAPIError(operation, repository, message string, err error) error {
+	return classifyAPIError(0, operation, repository, message, genqlientError(err))
+}
+
+// genqlientError converts the errors of a genqlient client into a GraphQLError wrapping err.
+// genqlient drops the type GitHub assigns GraphQL errors, so a repository that cannot be resolved
+// is recognised by its message.
+func genqlientError(err error) error {
+	var (
+		httpErr *graphql.HTTPError
+		list    gqlerror.List
+	)
+	switch {
+	case errors.As(err, &httpErr):
+		message := http.StatusText(httpErr.StatusCode)
+		if len(httpErr.Response.Errors) > 0 {
+			message = httpErr.Response.Errors[0].Message
+		}
+		return &GraphQLError{StatusCode: httpErr.StatusCode, Message: message, Err: err}
+	case errors.As(err, &list) && len(list) > 0:
+		first := list[0]
+		graphQLErr := &GraphQLError{StatusCode: http.StatusOK, Message: first.Message, Err: err}
+		if errorType, ok := first.Extensions["type"].(string); ok {
+			graphQLErr.Type = errorType
+		}
+		if graphQLErr.Type == "" && repositoryNotResolved.MatchString(first.Message) {
+			graphQLErr.Type = GraphQLNotFound
+		}
+		for _, elem := range first.Path {
+			graphQLErr.Path = append(graphQLErr.Path, fmt.Sprint(elem))
+		}
+		return graphQLErr
+	}
+	return err
+}
+
+// classifyStatus returns the typed error for an API error with the given HTTP status and response
+// headers. owner and name identify the repository a 404 refers to, when it refers to one.
+// Permission and not-found errors carry the remediation for operation.
+func classifyStatus(
+	status int, header http.Header, operation, repository, message, githubMessage, owner, name string, err error,
+) error {
+	apiErr := NewGitHubAPIError(status, operation, repository, message, err)
+	if githubMessage == "" {
+		githubMessage = message
+	}
+	switch {
+	case status == http.StatusUnauthorized:
+		return NewAuthenticationError("", githubMessage, apiErr)
+	case status == http.StatusForbidden:
+		denied := NewPermissionDeniedError(operation, repository, "", githubMessage, apiErr)
+		denied.Remediation = remediationFor(operation, header)
+		return denied
+	case status == http.StatusNotFound && owner != "":
+		notFound := NewRepositoryNotFoundError(owner, name, apiErr)
+		notFound.Remediation = remediationFor(operation, header)
+		return notFound
+	}
+	return apiErr
+}
+
+// repositoryPath returns the owner and name of a REST path addressing a repository itself, such
+// as /repos/acme/api or /api/v3/repos/acme/api.
+func repositoryPath(path string) (string, string) {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) < 3 || parts[len(parts)-3] != "repos" {
+		return "", ""
+	}
+	return parts[len(parts)-2], parts[len(parts)-1]
+}
+
+// headerResetTime returns the time in the X-RateLimit-Reset header, or a minute from now.
+func headerResetTime(header http.Header) time.Time {
+	if reset, ok := rateLimitReset(&http.Response{Header: header}); ok {
+		return reset
+	}
+	return time.Now().Add(secondaryRateLimitWait)
+}
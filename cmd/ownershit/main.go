@@ -1,7 +1,7 @@
 package main
 
 import (
-	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
@@ -20,8 +20,18 @@ import (
 var (
 	settings     *shit.PermissionsSettings
 	githubClient *shit.GitHubClient
+	// organizations holds every organization of settings and its client, set by configureClient.
+	organizations    []*organizationRun
+	organizationsFor *shit.PermissionsSettings
 )
 
+// organizationRun is one configured organization, the client for its token and any setup error.
+type organizationRun struct {
+	org    *shit.ResolvedOrganization
+	client *shit.GitHubClient
+	err    error
+}
+
 var (
 	version = "dev"
 	commit  = "none"
@@ -35,21 +45,30 @@ var (
 	ErrInvalidRepoPathFormat   = errors.New("repository path must be in format owner/repo")
 	ErrNoRepositoriesSpecified = errors.New("no repositories specified. Use 'owner/repo' format or --batch-file")
 	ErrConfigPathIsDirectory   = errors.New("configuration path is a directory")
+	ErrExpectedRepositoryName  = errors.New("expected exactly one argument: repository name")
+	ErrUnsupportedFormat       = errors.New("unsupported output format")
+	ErrInvalidConfiguration    = errors.New("configuration is invalid")
+	ErrConfigNotFormatted      = errors.New("configuration is not formatted; run 'ownershit fmt'")
+	ErrOrganizationsFailed     = errors.New("organizations failed")
+	ErrDoctorProblems          = errors.New("the token cannot run every command")
 )
 
 // main is the entry point for the ownershit CLI application.
 // It configures logging, constructs the command-line interface with subcommands
-// (init, branches, sync, archive, label, ratelimit, import, import-csv, permissions),
-// and runs the app, terminating with a fatal error if execution fails.
+// (init, branches, sync, archive, label, topics, files, render, explain, validate, schema, migrate, fmt, ratelimit, import, import-csv,
+// permissions, doctor), and runs the app, terminating with a fatal error if execution fails.
 func main() {
 	zerolog.SetGlobalLevel(zerolog.InfoLevel)
-	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr})
+	// Values read with ${secret:NAME} are kept out of logs and dry-run output; ${env:NAME} and
+	// ${file:path} values are not secret and are printed as is.
+	log.Logger = log.Output(zerolog.ConsoleWriter{Out: shit.NewRedactingWriter(os.Stderr)})
 
 	app := &cli.App{
 		Before: func(c *cli.Context) error {
 			if c.Bool("debug") {
 				zerolog.SetGlobalLevel(zerolog.DebugLevel)
 			}
+			configureCache(c)
 			return nil
 		},
 		Commands: []*cli.Command{
@@ -69,6 +88,10 @@ func main() {
 						Value: "repositories.yaml",
 						Usage: "configuration of repository updates to perform",
 					},
+					&cli.StringSliceFlag{
+						Name:  "overlay",
+						Usage: "overlay to merge over the configuration (name in overlays/ or path); repeatable",
+					},
 				},
 			},
 			{
@@ -83,6 +106,10 @@ func main() {
 						Value: "repositories.yaml",
 						Usage: "configuration of repository updates to perform",
 					},
+					&cli.StringSliceFlag{
+						Name:  "overlay",
+						Usage: "overlay to merge over the configuration (name in overlays/ or path); repeatable",
+					},
 					&cli.BoolFlag{
 						Name:    "dry-run",
 						Aliases: []string{"n"},
@@ -107,6 +134,10 @@ func main() {
 						Value: "repositories.yaml",
 						Usage: "configuration of repository updates to perform",
 					},
+					&cli.StringSliceFlag{
+						Name:  "overlay",
+						Usage: "overlay to merge over the configuration (name in overlays/ or path); repeatable",
+					},
 				},
 			},
 			{
@@ -120,6 +151,10 @@ func main() {
 						Value: "repositories.yaml",
 						Usage: "configuration of repository updates to perform",
 					},
+					&cli.StringSliceFlag{
+						Name:  "overlay",
+						Usage: "overlay to merge over the configuration (name in overlays/ or path); repeatable",
+					},
 					&cli.BoolFlag{
 						Name:  "additive",
 						Value: true,
@@ -127,6 +162,142 @@ func main() {
 					},
 				},
 			},
+			{
+				Name:      "files",
+				Usage:     "Render template files and sync them into repositories",
+				UsageText: "ownershit files --config repositories.yaml [--dry-run]",
+				Before:    configureClient,
+				Action:    filesCommand,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "config",
+						Value: "repositories.yaml",
+						Usage: "configuration of repository updates to perform",
+					},
+					&cli.StringSliceFlag{
+						Name:  "overlay",
+						Usage: "overlay to merge over the configuration (name in overlays/ or path); repeatable",
+					},
+					&cli.BoolFlag{
+						Name:    "dry-run",
+						Aliases: []string{"n"},
+						Usage:   "preview file changes without committing them",
+					},
+				},
+			},
+			{
+				Name:      "render",
+				Usage:     "Print the fully merged configuration, with includes and overlays applied",
+				UsageText: "ownershit render --config repositories.yaml [--overlay prod] [--output rendered.yaml]",
+				Action:    renderCommand,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "config",
+						Value: "repositories.yaml",
+						Usage: "configuration to render",
+					},
+					&cli.StringSliceFlag{
+						Name:  "overlay",
+						Usage: "overlay to merge over the configuration (name in overlays/ or path); repeatable",
+					},
+					&cli.StringFlag{
+						Name:    "output",
+						Aliases: []string{"o"},
+						Usage:   "output file path (default: stdout)",
+					},
+				},
+			},
+			{
+				Name:      "explain",
+				Usage:     "Show the effective settings of a repository and where each value comes from",
+				UsageText: "ownershit explain [--format text|json] <repository>",
+				Action:    explainCommand,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "config",
+						Value: "repositories.yaml",
+						Usage: "configuration to explain",
+					},
+					&cli.StringSliceFlag{
+						Name:  "overlay",
+						Usage: "overlay to merge over the configuration (name in overlays/ or path); repeatable",
+					},
+					&cli.StringFlag{
+						Name:  "format",
+						Value: "text",
+						Usage: "output format: text or json",
+					},
+				},
+			},
+			{
+				Name:      "validate",
+				Usage:     "Check the configuration against the schema and validation rules without a GitHub token",
+				UsageText: "ownershit validate --config repositories.yaml [--overlay prod] [--format text|json]",
+				Action:    validateCommand,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "config",
+						Value: "repositories.yaml",
+						Usage: "configuration to validate",
+					},
+					&cli.StringSliceFlag{
+						Name:  "overlay",
+						Usage: "overlay to merge over the configuration (name in overlays/ or path); repeatable",
+					},
+					&cli.StringFlag{
+						Name:  "format",
+						Value: "text",
+						Usage: "output format: text or json",
+					},
+				},
+			},
+			{
+				Name:      "schema",
+				Usage:     "Print the JSON Schema for the configuration file",
+				UsageText: "ownershit schema [--output config.schema.json]",
+				Action:    schemaCommand,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "output",
+						Aliases: []string{"o"},
+						Usage:   "output file path (default: stdout)",
+					},
+				},
+			},
+			{
+				Name:      "migrate",
+				Usage:     "Upgrade the configuration to the current schema version",
+				UsageText: "ownershit migrate --config repositories.yaml [--write]",
+				Action:    migrateCommand,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "config",
+						Value: "repositories.yaml",
+						Usage: "configuration file or directory to migrate",
+					},
+					&cli.BoolFlag{
+						Name:  "write",
+						Usage: "rewrite the files in place, keeping a .bak backup of each",
+					},
+				},
+			},
+			{
+				Name:      "fmt",
+				Usage:     "Rewrite the configuration in canonical form, keeping comments",
+				UsageText: "ownershit fmt --config repositories.yaml [--check]",
+				Action:    fmtCommand,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "config",
+						Value: "repositories.yaml",
+						Usage: "configuration file or directory to format",
+					},
+					&cli.BoolFlag{
+						Name:  "check",
+						Usage: "list files that are not formatted and fail instead of rewriting them",
+					},
+				},
+			},
 			{
 				Name:   "ratelimit",
 				Usage:  "get ratelimit information for the GitHub GraphQL v4 API",
@@ -176,6 +347,28 @@ func main() {
 				Usage:  "Show required GitHub token permissions for ownershit operations",
 				Action: permissionsCommand,
 			},
+			{
+				Name:      "doctor",
+				Usage:     "Check connectivity, the token's scopes and its access, and report which commands would fail",
+				UsageText: "ownershit doctor [--config repositories.yaml] [--format text|json]",
+				Action:    doctorCommand,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "config",
+						Value: "repositories.yaml",
+						Usage: "configuration whose organizations and repositories are checked; skipped when missing",
+					},
+					&cli.StringSliceFlag{
+						Name:  "overlay",
+						Usage: "overlay to merge over the configuration (name in overlays/ or path); repeatable",
+					},
+					&cli.StringFlag{
+						Name:  "format",
+						Value: "text",
+						Usage: "output format: text or json",
+					},
+				},
+			},
 		},
 		Flags: []cli.Flag{
 			&cli.StringFlag{
@@ -183,6 +376,41 @@ func main() {
 				Value: "repositories.yaml",
 				Usage: "configuration of repository updates to perform",
 			},
+			&cli.StringFlag{
+				Name:  "github-url",
+				Usage: "GitHub Enterprise Server URL; overrides github_url in the configuration and " + shit.EnvGitHubAPIURL,
+			},
+			&cli.StringFlag{
+				Name:    "token-source",
+				EnvVars: []string{"OWNERSHIT_TOKEN_SOURCE"},
+				Usage:   "where to read the GitHub token: env[:NAME], file:PATH, gh, git-credential or command:CMD",
+				Value:   shit.TokenSourceEnv,
+			},
+			&cli.StringFlag{
+				Name:    "app-id",
+				EnvVars: []string{shit.EnvGitHubAppID},
+				Usage:   "authenticate as this GitHub App instead of with a token",
+			},
+			&cli.StringFlag{
+				Name:    "app-private-key-file",
+				EnvVars: []string{shit.EnvGitHubAppPrivateKeyFile},
+				Usage:   "PEM private key of the GitHub App; defaults to the key in " + shit.EnvGitHubAppPrivateKey,
+			},
+			&cli.StringSliceFlag{
+				Name:  "overlay",
+				Usage: "overlay to merge over the configuration (name in overlays/ or path); repeatable",
+			},
+			&cli.StringFlag{
+				Name:    "cache-dir",
+				EnvVars: []string{"OWNERSHIT_CACHE_DIR"},
+				Usage:   "directory caching GitHub responses for conditional requests",
+				Value:   shit.DefaultCacheDir(),
+			},
+			&cli.BoolFlag{
+				Name:    "no-cache",
+				EnvVars: []string{"OWNERSHIT_NO_CACHE"},
+				Usage:   "do not cache GitHub responses",
+			},
 			&cli.BoolFlag{
 				Name:    "debug",
 				Aliases: []string{"d"},
@@ -211,12 +439,105 @@ func main() {
 	}
 }
 
-// configureClient sets up the GitHub client and configuration for CLI commands.
+// configureCache enables the response cache unless --no-cache is given. A cache directory that
+// cannot be created only disables caching.
+func configureCache(c *cli.Context) {
+	if c.Bool("no-cache") {
+		shit.SetHTTPCache(nil)
+		return
+	}
+	cache, err := shit.NewHTTPCache(c.String("cache-dir"))
+	if err != nil {
+		log.Warn().Err(err).Str("cacheDir", c.String("cache-dir")).Msg("response cache disabled")
+		shit.SetHTTPCache(nil)
+		return
+	}
+	shit.SetHTTPCache(cache)
+}
+
+// configureClient sets up the configuration and a GitHub client for every configured organization.
+// Organizations share a client when they use the same token and GitHub URL; with a GitHub App each
+// organization has its own installation. With an organizations list, an organization whose client
+// or selectors fail is reported and skipped by the command.
 func configureClient(c *cli.Context) error {
 	if err := readConfig(c); err != nil {
 		return fmt.Errorf("reading config file: %w", err)
 	}
-	return newGitHubClient(c)
+	multiple := len(settings.Organizations) > 0
+	organizations = nil
+	clients := make(map[string]*shit.GitHubClient)
+	for _, org := range shit.ResolveOrganizations(settings) {
+		run := &organizationRun{org: org}
+		organizations = append(organizations, run)
+		githubURL := resolveGitHubURL(c, org.GitHubURL)
+		key := org.TokenEnv + " " + githubURL
+		if spec := c.String("token-source"); spec != "" && spec != shit.TokenSourceEnv {
+			// Only the env source depends on token_env.
+			key = spec + " " + githubURL
+		}
+		if usesGitHubApp(c) {
+			// Installation tokens are scoped to one organization.
+			key = "app " + org.Name + " " + githubURL
+		}
+		client, ok := clients[key]
+		if !ok {
+			var err error
+			if client, err = newGitHubClientFor(c, org.Name, org.TokenEnv, githubURL); err != nil {
+				if !multiple {
+					return err
+				}
+				run.err = err
+				continue
+			}
+			clients[key] = client
+		}
+		run.client = client
+		if err := shit.ExpandRepositorySelectors(org.Settings, client); err != nil {
+			if !multiple {
+				return fmt.Errorf("selecting repositories: %w", err)
+			}
+			run.err = fmt.Errorf("selecting repositories: %w", err)
+		}
+	}
+	githubClient = organizations[0].client
+	organizationsFor = settings
+	return nil
+}
+
+// forEachOrganization runs fn for every configured organization, logging the outcome of each, and
+// fails if any organization failed. A single organization runs without the per-organization report.
+func forEachOrganization(operation string, fn func(*shit.PermissionsSettings, *shit.GitHubClient) error) error {
+	runs := organizations
+	if organizationsFor != settings {
+		// The configuration was loaded without configureClient.
+		runs = []*organizationRun{{org: &shit.ResolvedOrganization{Settings: settings}, client: githubClient}}
+	}
+	if len(runs) == 1 && runs[0].err == nil {
+		return fn(runs[0].org.Settings, runs[0].client)
+	}
+
+	failed := 0
+	for _, run := range runs {
+		if run.err == nil {
+			log.Info().Str("organization", run.org.Name).Str("operation", operation).Msg("processing organization")
+			run.err = fn(run.org.Settings, run.client)
+		}
+		if run.err != nil {
+			failed++
+			log.Err(run.err).Str("organization", run.org.Name).Str("operation", operation).Msg("organization failed")
+		}
+	}
+	for _, run := range runs {
+		status := "succeeded"
+		if run.err != nil {
+			status = "failed"
+		}
+		log.Info().Str("organization", run.org.Name).Str("status", status).Msg(operation + " summary")
+	}
+	if failed > 0 {
+		return fmt.Errorf("%w: %d of %d", ErrOrganizationsFailed, failed, len(runs))
+	}
+	return nil
 }
 
 // configureImportClient sets up the GitHub client specifically for import operations.
@@ -224,25 +545,81 @@ func configureImportClient(c *cli.Context) error { return newGitHubClient(c) }
 
 // newGitHubClient sets debug level and initializes githubClient; shared by configure* helpers.
 func newGitHubClient(c *cli.Context) error {
+	client, err := newGitHubClientFor(c, "", shit.DefaultTokenEnv, resolveGitHubURL(c, ""))
+	if err != nil {
+		return err
+	}
+	githubClient = client
+	return nil
+}
+
+// resolveGitHubURL returns the GitHub URL to use: the --github-url flag, then the configured URL,
+// then GITHUB_API_URL. An empty result selects github.com.
+func resolveGitHubURL(c *cli.Context, configured string) string {
+	if flagURL := c.String("github-url"); flagURL != "" {
+		return flagURL
+	}
+	if configured != "" {
+		return configured
+	}
+	return shit.GitHubURLFromEnv()
+}
+
+// usesGitHubApp reports whether the command authenticates as a GitHub App.
+func usesGitHubApp(c *cli.Context) bool {
+	return c.String("app-id") != ""
+}
+
+// tokenSource returns the token source selected with --token-source for the GitHub instance at
+// githubURL. The env source reads tokenEnv unless the flag names another variable.
+func tokenSource(c *cli.Context, tokenEnv, githubURL string) (shit.TokenSource, error) {
+	endpoints, err := shit.ResolveGitHubEndpoints(githubURL)
+	if err != nil {
+		return nil, err
+	}
+	return shit.ParseTokenSource(c.String("token-source"), tokenEnv, endpoints.Host)
+}
+
+// newGitHubClientFor sets debug level and creates a client for org on the GitHub instance at
+// githubURL. It authenticates as the GitHub App's installation in org when an app ID is given, and
+// with the token in tokenEnv otherwise. An empty org selects the app's only installation.
+func newGitHubClientFor(c *cli.Context, org, tokenEnv, githubURL string) (*shit.GitHubClient, error) {
 	if c.Bool("debug") {
 		zerolog.SetGlobalLevel(zerolog.DebugLevel)
 	}
-	client, err := shit.NewSecureGitHubClient(c.Context)
+	var client *shit.GitHubClient
+	var err error
+	if usesGitHubApp(c) {
+		var app *shit.GitHubApp
+		if app, err = shit.LoadGitHubApp(c.String("app-id"), c.String("app-private-key-file"), githubURL); err == nil {
+			client, err = shit.NewGitHubAppClient(c.Context, app, org)
+		}
+	} else {
+		var source shit.TokenSource
+		if source, err = tokenSource(c, tokenEnv, githubURL); err == nil {
+			client, err = shit.NewSecureGitHubClientFromSource(c.Context, source, githubURL)
+		}
+	}
 	if err != nil {
-		log.Err(err).
+		logEvent := log.Err(err).
 			Str("operation", "initializeGitHubClient").
-			Msg("GitHub client initialization failed")
-		return fmt.Errorf("failed to initialize GitHub client: %w", err)
+			Str("githubURL", githubURL)
+		if usesGitHubApp(c) {
+			logEvent = logEvent.Str("appID", c.String("app-id")).Str("organization", org)
+		} else {
+			logEvent = logEvent.Str("tokenSource", strings.SplitN(c.String("token-source"), ":", 2)[0]).Str("tokenEnv", tokenEnv)
+		}
+		logEvent.Msg("GitHub client initialization failed")
+		return nil, fmt.Errorf("failed to initialize GitHub client: %w", err)
 	}
-	githubClient = client
-	return nil
+	return client, nil
 }
 
-// readConfig reads and validates the configuration file specified in the CLI context.
+// readConfig reads and validates the configuration specified in the CLI context, which may be a
+// single file or a directory of YAML files.
 func readConfig(c *cli.Context) error {
 	configPath := c.String("config")
-	file, err := os.ReadFile(configPath) // #nosec G304 - config path validated by CLI
-	if err != nil {
+	if _, err := os.Stat(configPath); err != nil {
 		if os.IsNotExist(err) {
 			log.Err(err).
 				Str("configPath", configPath).
@@ -261,16 +638,15 @@ func readConfig(c *cli.Context) error {
 		return shit.NewConfigFileError(configPath, "read", "failed to read configuration file", err)
 	}
 
-	settings = &shit.PermissionsSettings{}
-	dec := yaml.NewDecoder(bytes.NewReader(file))
-	dec.KnownFields(true)
-	if err := dec.Decode(settings); err != nil {
+	loaded, err := shit.LoadConfiguration(configPath, c.StringSlice("overlay")...)
+	if err != nil {
 		log.Err(err).
 			Str("configPath", configPath).
-			Str("operation", "parseConfigFile").
-			Msg("YAML unmarshal error with config file")
-		return shit.NewConfigFileError(configPath, "parse", "failed to parse YAML configuration", err)
+			Str("operation", "loadConfig").
+			Msg("error loading configuration")
+		return err
 	}
+	settings = loaded
 
 	// Perform schema migration if needed
 	if err := shit.MigrateConfigurationSchema(settings); err != nil {
@@ -291,22 +667,25 @@ func syncCommand(c *cli.Context) error {
 		log.Info().Msg("DRY RUN MODE - No changes will be applied")
 	}
 	log.Info().Msg("mapping all permissions for repositories")
-	shit.MapPermissions(settings, githubClient, dryRun)
-	return nil
+	return forEachOrganization("sync", func(org *shit.PermissionsSettings, client *shit.GitHubClient) error {
+		return shit.MapPermissions(org, client, dryRun)
+	})
 }
 
 // branchCommand updates branch merge strategies for repositories.
 func branchCommand(c *cli.Context) error {
 	log.Info().Msg("performing branch updates on repositories")
-	shit.UpdateBranchMergeStrategies(settings, githubClient)
-	return nil
+	return forEachOrganization("branches", func(org *shit.PermissionsSettings, client *shit.GitHubClient) error {
+		return shit.UpdateBranchMergeStrategies(org, client)
+	})
 }
 
 // labelCommand synchronizes labels across repositories.
 func labelCommand(c *cli.Context) error {
 	log.Info().Msg("synchronizing labels on repositories")
-	shit.SyncLabels(settings, githubClient)
-	return nil
+	return forEachOrganization("label", func(org *shit.PermissionsSettings, client *shit.GitHubClient) error {
+		return shit.SyncLabels(org, client)
+	})
 }
 
 // topicsCommand synchronizes topics across repositories.
@@ -315,7 +694,187 @@ func topicsCommand(c *cli.Context) error {
 	log.Info().
 		Bool("additive", additive).
 		Msg("synchronizing topics on repositories")
-	shit.SyncTopics(settings, githubClient, additive)
+	return forEachOrganization("topics", func(org *shit.PermissionsSettings, client *shit.GitHubClient) error {
+		return shit.SyncTopics(org, client, additive)
+	})
+}
+
+// filesCommand renders the configured template files and writes them into repositories.
+// Relative template paths are resolved against the directory containing the configuration file.
+func filesCommand(c *cli.Context) error {
+	dryRun := c.Bool("dry-run")
+	if dryRun {
+		log.Info().Msg("DRY RUN MODE - No files will be committed")
+	}
+	log.Info().Msg("synchronizing files on repositories")
+	baseDir := shit.ConfigBaseDir(c.String("config"))
+	return forEachOrganization("files", func(org *shit.PermissionsSettings, client *shit.GitHubClient) error {
+		return shit.SyncFiles(org, client, baseDir, dryRun)
+	})
+}
+
+// renderCommand prints the merged configuration so overlays can be reviewed before syncing.
+func renderCommand(c *cli.Context) error {
+	configPath := c.String("config")
+	rendered, err := shit.RenderConfiguration(configPath, c.StringSlice("overlay")...)
+	if err != nil {
+		log.Err(err).
+			Str("configPath", configPath).
+			Str("operation", "renderConfig").
+			Msg("error rendering configuration")
+		return err
+	}
+	// Decoding catches unknown fields introduced by overlays before the result is trusted.
+	if _, err := shit.LoadConfiguration(configPath, c.StringSlice("overlay")...); err != nil {
+		return err
+	}
+
+	outputPath := c.String("output")
+	if outputPath == "" {
+		_, err = os.Stdout.Write(rendered)
+		return err
+	}
+	if err := os.WriteFile(outputPath, rendered, 0o600); err != nil {
+		return fmt.Errorf("writing rendered configuration to %q: %w", outputPath, err)
+	}
+	log.Info().Str("file", outputPath).Msg("rendered configuration written")
+	return nil
+}
+
+// explainCommand prints the resolved settings of one repository together with the provenance of
+// each value. It reads the configuration only and does not contact GitHub.
+func explainCommand(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return ErrExpectedRepositoryName
+	}
+	format := c.String("format")
+	if format != "text" && format != "json" {
+		return fmt.Errorf("%w: %q", ErrUnsupportedFormat, format)
+	}
+	if err := readConfig(c); err != nil {
+		return fmt.Errorf("reading config file: %w", err)
+	}
+	explanation, err := shit.ExplainRepository(settings, c.Args().First())
+	if err != nil {
+		return err
+	}
+	out := shit.NewRedactingWriter(os.Stdout)
+	if format == "json" {
+		return explanation.WriteJSON(out)
+	}
+	return explanation.WriteText(out)
+}
+
+// validateCommand checks the configuration offline and reports every problem with its location.
+func validateCommand(c *cli.Context) error {
+	format := c.String("format")
+	if format != "text" && format != "json" {
+		return fmt.Errorf("%w: %q", ErrUnsupportedFormat, format)
+	}
+	problems, err := shit.ValidateConfiguration(c.String("config"), c.StringSlice("overlay")...)
+	if err != nil {
+		return err
+	}
+
+	if format == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if problems == nil {
+			problems = []shit.ConfigProblem{}
+		}
+		if err := encoder.Encode(problems); err != nil {
+			return err
+		}
+	} else {
+		for _, problem := range problems {
+			fmt.Println(problem)
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %d problem(s) found", ErrInvalidConfiguration, len(problems))
+	}
+	if format == "text" {
+		fmt.Println("✅ Configuration is valid")
+	}
+	return nil
+}
+
+// schemaCommand prints the JSON Schema embedded in the binary.
+func schemaCommand(c *cli.Context) error {
+	outputPath := c.String("output")
+	if outputPath == "" {
+		_, err := os.Stdout.Write(shit.ConfigSchema())
+		return err
+	}
+	if err := os.WriteFile(outputPath, shit.ConfigSchema(), 0o600); err != nil {
+		return fmt.Errorf("writing schema to %q: %w", outputPath, err)
+	}
+	log.Info().Str("file", outputPath).Msg("configuration schema written")
+	return nil
+}
+
+// migrateCommand reports, and with --write applies, the schema migrations a configuration needs.
+func migrateCommand(c *cli.Context) error {
+	migrations, err := shit.MigrateConfigurationFiles(c.String("config"))
+	if err != nil {
+		return err
+	}
+	if len(migrations) == 0 || len(migrations[0].Applied) == 0 {
+		fmt.Printf("✅ Configuration is already at schema version %s\n", shit.CurrentSchemaVersion)
+		return nil
+	}
+
+	first := migrations[0]
+	fmt.Printf("Migrating configuration from schema version %s to %s:\n", first.From, first.To)
+	for _, step := range first.Applied {
+		fmt.Printf("  %s → %s: %s\n", step.From, step.To, step.Description)
+	}
+	write := c.Bool("write")
+	for _, m := range migrations {
+		if !m.Changed() {
+			continue
+		}
+		if !write {
+			fmt.Printf("Would rewrite %s\n", m.File)
+			continue
+		}
+		backup, err := shit.WriteConfigFileMigration(m)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Rewrote %s (backup: %s)\n", m.File, backup)
+	}
+	if !write {
+		fmt.Println("Run with --write to update the files.")
+	}
+	return nil
+}
+
+// fmtCommand formats the configuration files in place, or with --check lists those that need it.
+func fmtCommand(c *cli.Context) error {
+	files, err := shit.FormatConfiguration(c.String("config"))
+	if err != nil {
+		return err
+	}
+	check := c.Bool("check")
+	unformatted := 0
+	for _, f := range files {
+		if !f.Changed() {
+			continue
+		}
+		unformatted++
+		if check {
+			fmt.Println(f.File)
+			continue
+		}
+		if err := shit.WriteFormattedConfigFile(f); err != nil {
+			return err
+		}
+		log.Info().Str("file", f.File).Msg("formatted configuration")
+	}
+	if check && unformatted > 0 {
+		return fmt.Errorf("%w: %d file(s)", ErrConfigNotFormatted, unformatted)
+	}
 	return nil
 }
 
@@ -376,6 +935,7 @@ func importCommand(c *cli.Context) error {
 	if err != nil {
 		return fmt.Errorf("failed to marshal configuration to YAML: %w", err)
 	}
+	yamlData = []byte(shit.RedactSecrets(string(yamlData)))
 
 	outputPath := c.String("output")
 	log.Debug().Str("outputPath", outputPath).Msg("checking output path")
@@ -600,6 +1160,10 @@ version: "1.0"
 # Your GitHub organization name (REQUIRED - update this!)
 organization: your-org-name
 
+# String values can reference the environment and files, e.g. ${env:NAME} or ${file:./path}.
+# Only values read with ${secret:NAME} are redacted from logs and dry-run output; ${env:NAME}
+# values are printed in clear, so never use ${env:...} for secrets.
+
 # Branch protection rules applied to all repositories
 branches:
   # Pull request requirements
@@ -664,6 +1228,84 @@ default_labels:
 `
 }
 
+// doctorCommand diagnoses the token for every configured organization, or only the token itself
+// when there is no configuration, and fails when any command would fail.
+func doctorCommand(c *cli.Context) error {
+	format := c.String("format")
+	if format != "text" && format != "json" {
+		return fmt.Errorf("%w: %q", ErrUnsupportedFormat, format)
+	}
+	type target struct {
+		org      string
+		tokenEnv string
+		url      string
+		// settings is nil when no configuration is present, and only the token is checked.
+		settings *shit.PermissionsSettings
+	}
+	targets := []target{{tokenEnv: shit.DefaultTokenEnv, url: resolveGitHubURL(c, "")}}
+	if _, err := os.Stat(c.String("config")); err == nil {
+		if err := readConfig(c); err != nil {
+			return fmt.Errorf("reading config file: %w", err)
+		}
+		targets = nil
+		for _, org := range shit.ResolveOrganizations(settings) {
+			targets = append(targets, target{
+				org: org.Name, tokenEnv: org.TokenEnv, url: resolveGitHubURL(c, org.GitHubURL), settings: org.Settings,
+			})
+		}
+	}
+
+	var reports []*shit.DoctorReport
+	failed := false
+	for _, t := range targets {
+		client, err := newGitHubClientFor(c, t.org, t.tokenEnv, t.url)
+		if err != nil {
+			// A missing or malformed token is a finding; the other organizations are still checked.
+			failed = true
+			reports = append(reports, shit.DiagnoseTokenError(t.org, err))
+			continue
+		}
+		var repos []string
+		var selectErr error
+		if t.settings != nil {
+			// Repositories chosen by selectors are checked along with the listed ones, as sync applies to both.
+			selectErr = shit.ExpandRepositorySelectors(t.settings, client)
+			for _, repo := range t.settings.Repositories {
+				if repo.Name != nil {
+					repos = append(repos, *repo.Name)
+				}
+			}
+		}
+		report := client.Diagnose(t.org, repos)
+		if selectErr != nil {
+			report.AddCheck("selectors", shit.DoctorFail, fmt.Sprintf("selecting repositories: %v", selectErr))
+		}
+		failed = failed || report.Failed()
+		reports = append(reports, report)
+	}
+
+	if format == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(reports); err != nil {
+			return err
+		}
+	} else {
+		for i, report := range reports {
+			if i > 0 {
+				fmt.Println()
+			}
+			if err := report.WriteText(os.Stdout); err != nil {
+				return err
+			}
+		}
+	}
+	if failed {
+		return ErrDoctorProblems
+	}
+	return nil
+}
+
 // permissionsCommand displays the required GitHub token permissions.
 func permissionsCommand(c *cli.Context) error {
 	fmt.Println("GitHub Token Permissions Required for ownershit")
@@ -703,7 +1345,7 @@ func permissionsCommand(c *cli.Context) error {
 	fmt.Println("2. For classic tokens: Select the scopes listed above")
 	fmt.Println("3. For fine-grained tokens: Configure repository and organization permissions as shown")
 	fmt.Println("4. Set your token: export GITHUB_TOKEN=your_token_here")
-	fmt.Println("5. Verify with: ownershit ratelimit")
+	fmt.Println("5. Verify with: ownershit doctor")
 
 	return nil
 }
@@ -0,0 +1,25 @@
+// Package main writes the JSON Schema for the ownershit configuration file.
+package main
+
+import (
+	"flag"
+	"os"
+
+	shit "github.com/klauern/ownershit"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+func main() {
+	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr})
+	output := flag.String("output", "config.schema.json", "file to write the schema to")
+	flag.Parse()
+
+	schema, err := shit.GenerateConfigSchema()
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to generate configuration schema")
+	}
+	if err := os.WriteFile(*output, schema, 0o644); err != nil { // #nosec G306 - the schema is public
+		log.Fatal().Err(err).Str("file", *output).Msg("failed to write configuration schema")
+	}
+}
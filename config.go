@@ -1,9 +1,9 @@
 package ownershit
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"os"
 	"regexp"
 	"strings"
 
@@ -74,14 +74,28 @@ type RepositoryDefaults struct {
 
 // PermissionsSettings contains the complete configuration for repository permissions.
 type PermissionsSettings struct {
-	Version           *string `yaml:"version,omitempty"`
-	BranchPermissions `yaml:"branches"`
-	TeamPermissions   []*Permissions      `yaml:"team"`
-	Repositories      []*Repository       `yaml:"repositories"`
-	Organization      *string             `yaml:"organization"`
-	DefaultLabels     []RepoLabel         `yaml:"default_labels"`
-	DefaultTopics     []string            `yaml:"default_topics,omitempty"`
-	Defaults          *RepositoryDefaults `yaml:"defaults,omitempty"`
+	Version *string `yaml:"version,omitempty"`
+	// Include lists further configuration files or globs, relative to the including file.
+	Include      []string `yaml:"include,omitempty"`
+	Organization *string  `yaml:"organization"`
+	// GitHubURL is the GitHub Enterprise Server URL; github.com is used when it is unset.
+	GitHubURL *string `yaml:"github_url,omitempty"`
+	// Organizations configures several organizations in one file; see OrganizationConfig.
+	Organizations        []*OrganizationConfig `yaml:"organizations,omitempty"`
+	OrganizationSettings *OrganizationSettings `yaml:"organization_settings,omitempty"`
+	Defaults             *RepositoryDefaults   `yaml:"defaults,omitempty"`
+	BranchPermissions    `yaml:"branches"`
+	TeamPermissions      []*Permissions              `yaml:"team"`
+	Teams                []*TeamDefinition           `yaml:"teams,omitempty"`
+	Security             *SecuritySettings           `yaml:"security,omitempty"`
+	Autolinks            []Autolink                  `yaml:"autolinks,omitempty"`
+	DefaultLabels        []RepoLabel                 `yaml:"default_labels"`
+	DefaultTopics        []string                    `yaml:"default_topics,omitempty"`
+	Files                []*FileSync                 `yaml:"files,omitempty"`
+	CustomProperties     []*CustomPropertyDefinition `yaml:"custom_properties,omitempty"`
+	Profiles             map[string]*Profile         `yaml:"profiles,omitempty"`
+	Selectors            []*RepositorySelector       `yaml:"selectors,omitempty"`
+	Repositories         []*Repository               `yaml:"repositories"`
 	// Deprecated: Use Defaults.Wiki instead
 	DefaultWiki *bool `yaml:"default_wiki,omitempty"`
 	// Deprecated: Use Defaults.Issues instead
@@ -93,6 +107,8 @@ type PermissionsSettings struct {
 // Repository defines the configuration for a single GitHub repository.
 type Repository struct {
 	Name *string `yaml:"name"`
+	// Profile names an entry in profiles whose settings are layered over the global settings.
+	Profile *string `yaml:"profile,omitempty"`
 	// Topics is reserved for future per-repository topic customization.
 	// Currently, only DefaultTopics in PermissionsSettings is used by the topics command.
 	Topics                 []string `yaml:"topics,omitempty"`
@@ -108,6 +124,12 @@ type Repository struct {
 	DeleteBranchOnMerge    *bool    `yaml:"delete_branch_on_merge"`
 	HasDiscussionsEnabled  *bool    `yaml:"discussions_enabled"`
 	HasSponsorshipsEnabled *bool    `yaml:"sponsorships_enabled,omitempty"`
+	// Security overrides the global security block for this repository.
+	Security *SecuritySettings `yaml:"security,omitempty"`
+	// Autolinks are merged with the global autolinks; entries with the same key prefix win.
+	Autolinks []Autolink `yaml:"autolinks,omitempty"`
+	// Properties sets organization custom property values for this repository.
+	Properties map[string]PropertyValue `yaml:"properties,omitempty"`
 }
 
 // RepoLabel defines a label that can be applied to GitHub repositories.
@@ -118,11 +140,38 @@ type RepoLabel struct {
 	Description string
 }
 
+// ErrRepositoriesFailed is returned when an operation failed on some of the repositories it
+// processed. The individual failures are logged as they occur.
+var ErrRepositoriesFailed = errors.New("repositories failed")
+
+// repositoryFailures records the repositories an operation failed on.
+type repositoryFailures struct {
+	failed []string
+	total  int
+}
+
+// record counts repo as processed, and as failed when any of errs is non-nil.
+func (f *repositoryFailures) record(repo string, errs ...error) {
+	f.total++
+	if errors.Join(errs...) != nil {
+		f.failed = append(f.failed, repo)
+	}
+}
+
+// err returns an ErrRepositoriesFailed naming the failed repositories, or nil.
+func (f *repositoryFailures) err() error {
+	if len(f.failed) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: %d of %d (%s)", ErrRepositoriesFailed, len(f.failed), f.total, strings.Join(f.failed, ", "))
+}
+
 // GitHub token validation errors.
 var (
-	ErrTokenEmpty    = errors.New("GitHub token is empty")
-	ErrTokenInvalid  = errors.New("GitHub token format is invalid")
-	ErrTokenNotFound = errors.New("GITHUB_TOKEN environment variable not set")
+	ErrTokenEmpty     = errors.New("GitHub token is empty")
+	ErrTokenInvalid   = errors.New("GitHub token format is invalid")
+	ErrTokenNotFound  = errors.New("GITHUB_TOKEN environment variable not set")
+	ErrTokenEnvNotSet = errors.New("token environment variable not set")
 )
 
 // ValidateGitHubToken validates a GitHub token format and content.
@@ -168,9 +217,14 @@ func ValidateGitHubToken(token string) error {
 
 // GetValidatedGitHubToken gets and validates the GitHub token from environment.
 func GetValidatedGitHubToken() (string, error) {
-	token := os.Getenv("GITHUB_TOKEN")
-	if token == "" {
-		return "", ErrTokenNotFound
+	return GetValidatedGitHubTokenFromEnv(DefaultTokenEnv)
+}
+
+// GetValidatedGitHubTokenFromEnv gets and validates the GitHub token from the named environment variable.
+func GetValidatedGitHubTokenFromEnv(name string) (string, error) {
+	token, err := EnvTokenSource{Name: name}.Token(context.Background())
+	if err != nil {
+		return "", err
 	}
 
 	if err := ValidateGitHubToken(token); err != nil {
@@ -192,27 +246,32 @@ func GetRequiredTokenPermissions() map[string][]string {
 		},
 		"fine_grained_permissions": {
 			"Repository permissions:",
-			"- Administration: Write", // Manage repository settings
-			"- Metadata: Read",        // Read repository metadata
-			"- Contents: Read",        // Read repository contents
-			"- Pull requests: Write",  // Manage branch protection
-			"- Issues: Write",         // Manage repository issues
+			"- Administration: Write",        // Manage repository settings
+			"- Metadata: Read",               // Read repository metadata
+			"- Contents: Write",              // Read and sync repository files
+			"- Pull requests: Write",         // Manage branch protection
+			"- Issues: Write",                // Manage repository issues
+			"- Secret scanning alerts: Read", // Read secret scanning state
+			"- Dependabot alerts: Read",      // Read vulnerability alert state
 			"",
 			"Organization permissions:",
-			"- Administration: Read",  // Read org settings
-			"- Members: Read",         // Read organization members
-			"- Team membership: Read", // Read team memberships
-		},
-		"operations_requiring_permissions": {
-			"Sync repositories: repo, admin:org",
-			"Import repository config: repo, read:org",
-			"Manage branch protection: repo",
-			"Archive repositories: repo, admin:org",
-			"Manage teams: admin:org",
+			"- Administration: Write",    // Manage org settings
+			"- Members: Write",           // Create teams and manage team membership
+			"- Custom properties: Admin", // Define custom property schema
 		},
+		"operations_requiring_permissions": requiredOperations(),
 	}
 }
 
+// requiredOperations describes each operation with the classic scopes it needs.
+func requiredOperations() []string {
+	operations := make([]string, 0, len(tokenRequirements))
+	for _, req := range tokenRequirements {
+		operations = append(operations, fmt.Sprintf("%s: %s", req.Operation, strings.Join(req.Scopes, ", ")))
+	}
+	return operations
+}
+
 // ValidateBranchPermissions validates branch protection configuration.
 func ValidateBranchPermissions(perms *BranchPermissions) error {
 	if perms == nil {
@@ -341,61 +400,108 @@ func validateConflicts(perms *BranchPermissions) error {
 }
 
 // ValidatePermissionsSettings validates the overall permissions configuration.
+// It returns the first problem found; see PermissionsSettingsErrors for all of them.
 func ValidatePermissionsSettings(settings *PermissionsSettings) error {
+	if errs := PermissionsSettingsErrors(settings); len(errs) > 0 {
+		return errs[0]
+	}
+	return nil
+}
+
+// PermissionsSettingsErrors validates the overall permissions configuration and returns every
+// problem found, in the order ValidatePermissionsSettings checks them.
+func PermissionsSettingsErrors(settings *PermissionsSettings) []error {
 	if settings == nil {
-		return NewConfigValidationError("settings", nil, "permissions settings cannot be nil", nil)
+		return []error{NewConfigValidationError("settings", nil, "permissions settings cannot be nil", nil)}
+	}
+	if len(settings.Organizations) > 0 {
+		return organizationsErrors(settings)
+	}
+	var errs []error
+	check := func(err error) {
+		if err != nil {
+			errs = append(errs, err)
+		}
 	}
 
 	// Validate schema version
-	if err := ValidateSchemaVersion(settings.Version); err != nil {
-		return err
-	}
+	check(ValidateSchemaVersion(settings.Version))
 
-	// Validate organization field
+	// Validate organization field and name format (GitHub usernames/orgs)
 	if settings.Organization == nil || *settings.Organization == "" {
-		return NewConfigValidationError("organization", settings.Organization,
-			"organization must be specified and cannot be empty", nil)
+		check(NewConfigValidationError("organization", settings.Organization,
+			"organization must be specified and cannot be empty", nil))
+	} else if orgName := strings.TrimSpace(*settings.Organization); len(orgName) < 1 || len(orgName) > 39 {
+		check(NewConfigValidationError("organization", orgName,
+			"organization name must be between 1 and 39 characters", nil))
 	}
 
-	// Validate organization name format (GitHub usernames/orgs)
-	orgName := strings.TrimSpace(*settings.Organization)
-	if len(orgName) < 1 || len(orgName) > 39 {
-		return NewConfigValidationError("organization", orgName,
-			"organization name must be between 1 and 39 characters", nil)
-	}
+	check(ValidateGitHubURL("github_url", settings.GitHubURL))
 
 	// Validate branch permissions
-	if err := ValidateBranchPermissions(&settings.BranchPermissions); err != nil {
-		return err
-	}
+	check(ValidateBranchPermissions(&settings.BranchPermissions))
+
+	// Validate global security toggles
+	check(ValidateSecuritySettings("security", settings.Security))
 
-	// Validate repositories
-	if len(settings.Repositories) == 0 {
-		return NewConfigValidationError("repositories", settings.Repositories,
-			"at least one repository must be specified", nil)
+	// Validate global autolinks
+	check(ValidateAutolinks("autolinks", settings.Autolinks))
+
+	// Validate organization-wide settings
+	check(ValidateOrganizationSettings(settings.OrganizationSettings))
+
+	// Validate declared teams
+	check(ValidateTeamDefinitions(settings.Teams))
+
+	// Validate custom property schema and repository selectors
+	check(ValidateCustomPropertyDefinitions(settings.CustomProperties))
+	check(ValidateRepositorySelectors(settings.Selectors))
+
+	// Validate profiles and the repositories and selectors that reference them
+	check(ValidateProfiles(settings))
+
+	// Validate repositories; selectors may supply them at runtime
+	if len(settings.Repositories) == 0 && len(settings.Selectors) == 0 {
+		check(NewConfigValidationError("repositories", settings.Repositories,
+			"at least one repository must be specified", nil))
 	}
 
 	// Validate each repository
 	repoNames := make(map[string]bool)
 	for i, repo := range settings.Repositories {
 		if repo == nil {
-			return NewConfigValidationError(fmt.Sprintf("repositories[%d]", i), nil,
-				"repository cannot be nil", nil)
+			check(NewConfigValidationError(fmt.Sprintf("repositories[%d]", i), nil,
+				"repository cannot be nil", nil))
+			continue
 		}
 		if repo.Name == nil || *repo.Name == "" {
-			return NewConfigValidationError(fmt.Sprintf("repositories[%d].name", i), repo.Name,
-				"repository name must be specified and cannot be empty", nil)
+			check(NewConfigValidationError(fmt.Sprintf("repositories[%d].name", i), repo.Name,
+				"repository name must be specified and cannot be empty", nil))
+			continue
 		}
 
 		repoName := strings.TrimSpace(*repo.Name)
 		if repoNames[repoName] {
-			return NewConfigValidationError(fmt.Sprintf("repositories[%d].name", i), repoName,
-				"duplicate repository name", nil)
+			check(NewConfigValidationError(fmt.Sprintf("repositories[%d].name", i), repoName,
+				"duplicate repository name", nil))
 		}
 		repoNames[repoName] = true
+
+		check(ValidateSecuritySettings(fmt.Sprintf("repositories[%d].security", i),
+			ResolveSecuritySettings(settings.Security, repo.Security)))
+		check(ValidateAutolinks(fmt.Sprintf("repositories[%d].autolinks", i), repo.Autolinks))
+		check(ValidateRepositoryProperties(fmt.Sprintf("repositories[%d].properties", i),
+			repo.Properties, settings.CustomProperties))
 	}
 
-	return nil
+	// Validate file sync entries
+	for i, f := range settings.Files {
+		if err := ValidateFileSync(f); err != nil {
+			check(fmt.Errorf("files[%d]: %w", i, err))
+		}
+	}
+
+	return errs
 }
 
 // ValidateSchemaVersion validates the configuration schema version.
@@ -406,17 +512,22 @@ func ValidateSchemaVersion(version *string) error {
 	}
 
 	versionStr := strings.TrimSpace(*version)
-	switch versionStr {
-	case CurrentSchemaVersion, LegacySchemaVersion:
-		return nil
-	case "":
+	if versionStr == "" {
 		// Empty version is allowed (defaults to current)
 		return nil
-	default:
+	}
+	// Supported versions are the current one and every version a registered migration starts from
+	if _, err := configMigrationPath(versionStr); err == nil {
+		return nil
+	}
+	if isNewerSchemaVersion(versionStr) {
 		return NewConfigValidationError("version", versionStr,
-			fmt.Sprintf("unsupported schema version, supported versions: %s, %s",
-				CurrentSchemaVersion, LegacySchemaVersion), nil)
+			fmt.Sprintf("unsupported schema version: this version of ownershit supports up to %s; upgrade ownershit",
+				CurrentSchemaVersion), ErrSchemaVersionTooNew)
 	}
+	return NewConfigValidationError("version", versionStr,
+		fmt.Sprintf("unsupported schema version, supported versions: %s",
+			strings.Join(supportedSchemaVersions(), ", ")), nil)
 }
 
 // MigrateConfigurationSchema migrates configuration from older schema versions to current.
@@ -436,27 +547,26 @@ func MigrateConfigurationSchema(settings *PermissionsSettings) error {
 		return fmt.Errorf("cannot migrate unsupported schema version: %w", err)
 	}
 
+	steps, err := configMigrationPath(currentVersion)
+	if err != nil {
+		return fmt.Errorf("cannot migrate unsupported schema version: %w", err)
+	}
 	// No migration needed if already current
-	if currentVersion == CurrentSchemaVersion {
+	if len(steps) == 0 {
 		return nil
 	}
 
-	// Migrate from legacy version to current
-	if currentVersion == LegacySchemaVersion {
-		log.Info().
-			Str("fromVersion", currentVersion).
-			Str("toVersion", CurrentSchemaVersion).
-			Msg("migrating configuration schema")
-
-		// Migration steps from 0.9 to 1.0
-		// In this case, no structural changes are needed - just version bump
-		newVersion := CurrentSchemaVersion
-		settings.Version = &newVersion
-
-		log.Info().
-			Str("version", CurrentSchemaVersion).
-			Msg("configuration schema migration completed")
-	}
+	// Structural changes are applied to the YAML document when the configuration is loaded (see
+	// MigrateConfigDocument); settings built in memory only need the version bump.
+	log.Info().
+		Str("fromVersion", currentVersion).
+		Str("toVersion", CurrentSchemaVersion).
+		Msg("migrating configuration schema")
+	newVersion := CurrentSchemaVersion
+	settings.Version = &newVersion
+	log.Info().
+		Str("version", CurrentSchemaVersion).
+		Msg("configuration schema migration completed")
 
 	return nil
 }
@@ -513,20 +623,31 @@ func (s *PermissionsSettings) MigrateToNestedDefaults() {
 // It validates the configuration, adds team permissions, updates branch settings,
 // applies enhanced branch protection, and sets repository-level features.
 // If dryRun is true, it logs planned changes without applying them.
-func MapPermissions(settings *PermissionsSettings, client *GitHubClient, dryRun bool) {
+// Failures are logged as they occur and processing continues; the returned error reports the
+// failed organization-level steps and an ErrRepositoriesFailed for the repositories that failed.
+func MapPermissions(settings *PermissionsSettings, client *GitHubClient, dryRun bool) error {
 	// Migrate legacy default_* fields to nested defaults block
 	settings.MigrateToNestedDefaults()
 
 	// Validate complete configuration
 	if err := ValidatePermissionsSettings(settings); err != nil {
 		log.Err(err).Msg("configuration validation failed")
-		return
+		return err
 	}
 
 	if dryRun {
 		log.Info().Msg("DRY RUN: Analyzing configuration changes...")
 	}
 
+	// Organization-level settings are applied before repository settings that depend on them
+	errs := []error{
+		ApplyOrganizationSettings(settings, client, dryRun),
+		ApplyCustomPropertySchema(settings, client, dryRun),
+		ApplyTeams(settings, client, dryRun),
+	}
+
+	var failures repositoryFailures
+	ids, states := prefetchRepositories(settings, client, dryRun)
 	for _, repo := range settings.Repositories {
 		// Skip archived repositories - they are read-only
 		if repo.Archived != nil && *repo.Archived {
@@ -539,28 +660,43 @@ func MapPermissions(settings *PermissionsSettings, client *GitHubClient, dryRun
 		if dryRun {
 			log.Info().Str("repository", *repo.Name).Msg("Would process repository")
 		}
-		applyTeamPermissions(settings, repo, client, dryRun)
-		updateRepoBranchSettings(settings, repo, client, dryRun)
-		repoID, ok := getRepositoryID(settings, repo, client)
-		if !ok {
+		// Layer the repository's profile, if any, over the global settings
+		effective := settings.EffectiveSettings(repo)
+		if state := states[*repo.Name]; state != nil {
+			logRepositoryDrift(effective, repo, state)
+		}
+		permissionsErr := applyTeamPermissions(effective, repo, client, dryRun)
+		branchErr := updateRepoBranchSettings(effective, repo, client, dryRun)
+		repoID, err := getRepositoryID(effective, repo, client, ids)
+		if err != nil {
+			failures.record(*repo.Name, err)
 			continue
 		}
-		applyEnhancedBranchProtection(settings, repo, repoID, client, dryRun)
-		// REST fallback for unsupported fields or existing rule conflicts
-		applyBranchProtectionFallback(settings, repo, client, dryRun)
-		setRepositoryFeatures(repo, repoID, settings, client, dryRun)
-		setAdvancedRepoSettings(settings, repo, client, dryRun)
+		failures.record(*repo.Name,
+			permissionsErr,
+			branchErr,
+			applyEnhancedBranchProtection(effective, repo, repoID, client, dryRun),
+			// REST fallback for unsupported fields or existing rule conflicts
+			applyBranchProtectionFallback(effective, repo, client, dryRun),
+			setRepositoryFeatures(repo, repoID, effective, client, dryRun),
+			setAdvancedRepoSettings(effective, repo, client, dryRun),
+			applySecuritySettings(effective, repo, client, dryRun),
+			applyAutolinks(effective, repo, client, dryRun),
+			applyCustomProperties(effective, repo, client, dryRun),
+		)
 	}
 
 	if dryRun {
 		log.Info().Msg("DRY RUN: Complete. No changes were applied.")
 	}
+	return errors.Join(append(errs, failures.err())...)
 }
 
-func applyTeamPermissions(settings *PermissionsSettings, repo *Repository, client *GitHubClient, dryRun bool) {
+func applyTeamPermissions(settings *PermissionsSettings, repo *Repository, client *GitHubClient, dryRun bool) error {
 	if len(settings.TeamPermissions) == 0 {
-		return
+		return nil
 	}
+	var errs []error
 	for _, perm := range settings.TeamPermissions {
 		if dryRun {
 			log.Info().
@@ -583,11 +719,13 @@ func applyTeamPermissions(settings *PermissionsSettings, repo *Repository, clien
 				Str("permissions-team", *perm.Team).
 				Str("operation", "addTeamPermissions").
 				Msg("setting team permissions")
+			errs = append(errs, err)
 		}
 	}
+	return errors.Join(errs...)
 }
 
-func updateRepoBranchSettings(settings *PermissionsSettings, repo *Repository, client *GitHubClient, dryRun bool) {
+func updateRepoBranchSettings(settings *PermissionsSettings, repo *Repository, client *GitHubClient, dryRun bool) error {
 	if dryRun {
 		logEvent := log.Info().Str("repository", *repo.Name)
 		if settings.AllowMergeCommit != nil {
@@ -600,33 +738,102 @@ func updateRepoBranchSettings(settings *PermissionsSettings, repo *Repository, c
 			logEvent = logEvent.Bool("allow_rebase_merge", *settings.AllowRebaseMerge)
 		}
 		logEvent.Msg("Would update branch merge strategies")
-		return
+		return nil
 	}
 	if err := client.UpdateBranchPermissions(*settings.Organization, *repo.Name, &settings.BranchPermissions); err != nil {
 		log.Err(err).
 			Str("repository", *repo.Name).
 			Str("organization", *settings.Organization).
 			Msg("updating repository settings")
+		return err
+	}
+	return nil
+}
+
+// prefetchRepositories reads every repository that will be processed with batched queries, so each
+// repository does not need its own lookup. A real run only needs the IDs; a dry run reads the full
+// state of each repository too, so it can report drift. Repositories missing from the result are
+// looked up individually by getRepositoryID.
+func prefetchRepositories(
+	settings *PermissionsSettings, client *GitHubClient, dryRun bool,
+) (map[string]githubv4.ID, map[string]*RepositoryState) {
+	names := make([]string, 0, len(settings.Repositories))
+	for _, repo := range settings.Repositories {
+		if repo.Archived != nil && *repo.Archived {
+			continue
+		}
+		names = append(names, *repo.Name)
+	}
+	if len(names) == 0 {
+		return nil, nil
+	}
+	if !dryRun {
+		ids, err := client.GetRepositoryIDs(*settings.Organization, names)
+		if err != nil {
+			log.Warn().Err(err).
+				Str("organization", *settings.Organization).
+				Int("read", len(ids)).
+				Msg("batched repository lookup failed; looking up remaining repositories individually")
+		}
+		return ids, nil
+	}
+
+	states, err := client.GetRepositoryStates(*settings.Organization, names)
+	if err != nil {
+		log.Warn().Err(err).
+			Str("organization", *settings.Organization).
+			Int("read", len(states)).
+			Msg("batched repository lookup failed; drift is not reported for the remaining repositories")
+	}
+	ids := make(map[string]githubv4.ID, len(states))
+	for name, state := range states {
+		ids[name] = state.ID
+	}
+	return ids, states
+}
+
+// logRepositoryDrift logs each setting a real run would change on the repository.
+func logRepositoryDrift(settings *PermissionsSettings, repo *Repository, state *RepositoryState) {
+	changes := DiffRepositoryState(settings, repo, state)
+	if len(changes) == 0 {
+		log.Info().Str("repository", *repo.Name).Msg("repository settings are up to date")
+		return
+	}
+	for _, change := range changes {
+		log.Info().
+			Str("repository", *repo.Name).
+			Str("setting", change.Setting).
+			Interface("current", change.Current).
+			Interface("desired", change.Desired).
+			Msg("Would change repository setting")
 	}
 }
 
-func getRepositoryID(settings *PermissionsSettings, repo *Repository, client *GitHubClient) (githubv4.ID, bool) {
+func getRepositoryID(
+	settings *PermissionsSettings, repo *Repository, client *GitHubClient, ids map[string]githubv4.ID,
+) (githubv4.ID, error) {
+	if id, ok := ids[*repo.Name]; ok {
+		log.Debug().Interface("repoID", id).Msg("Repository ID")
+		return id, nil
+	}
 	repoID, err := client.GetRepository(repo.Name, settings.Organization)
 	if err != nil {
 		log.Err(err).Str("repository", *repo.Name).Msg("getting repository")
-		return githubv4.ID(""), false
+		return githubv4.ID(""), err
 	}
 	log.Debug().Interface("repoID", repoID).Msg("Repository ID")
-	return repoID, true
+	return repoID, nil
 }
 
-func applyEnhancedBranchProtection(settings *PermissionsSettings, repo *Repository, repoID githubv4.ID, client *GitHubClient, dryRun bool) {
+func applyEnhancedBranchProtection(
+	settings *PermissionsSettings, repo *Repository, repoID githubv4.ID, client *GitHubClient, dryRun bool,
+) error {
 	// Skip if there are no meaningful branch protection settings
 	if !hasMeaningfulBranchProtection(&settings.BranchPermissions) {
 		log.Debug().
 			Str("repository", *repo.Name).
 			Msg("Skipping branch protection - no meaningful protection rules configured")
-		return
+		return nil
 	}
 
 	branch := getDefaultBranch(repo)
@@ -635,7 +842,7 @@ func applyEnhancedBranchProtection(settings *PermissionsSettings, repo *Reposito
 			Str("repository", *repo.Name).
 			Str("branch", branch).
 			Msg("Would apply enhanced branch protection rules")
-		return
+		return nil
 	}
 	if err := client.SetEnhancedBranchProtection(repoID, branch, &settings.BranchPermissions); err != nil {
 		log.Err(err).
@@ -643,16 +850,18 @@ func applyEnhancedBranchProtection(settings *PermissionsSettings, repo *Reposito
 			Str("organization", *settings.Organization).
 			Str("branch", branch).
 			Msg("setting enhanced branch protection")
+		return err
 	}
+	return nil
 }
 
-func applyBranchProtectionFallback(settings *PermissionsSettings, repo *Repository, client *GitHubClient, dryRun bool) {
+func applyBranchProtectionFallback(settings *PermissionsSettings, repo *Repository, client *GitHubClient, dryRun bool) error {
 	// Skip if there are no meaningful branch protection settings
 	if !hasMeaningfulBranchProtection(&settings.BranchPermissions) {
 		log.Debug().
 			Str("repository", *repo.Name).
 			Msg("Skipping branch protection fallback - no meaningful protection rules configured")
-		return
+		return nil
 	}
 
 	branch := getDefaultBranch(repo)
@@ -661,7 +870,7 @@ func applyBranchProtectionFallback(settings *PermissionsSettings, repo *Reposito
 			Str("repository", *repo.Name).
 			Str("branch", branch).
 			Msg("Would apply branch protection fallback via REST API")
-		return
+		return nil
 	}
 	if err := client.SetBranchProtectionFallback(*settings.Organization, *repo.Name, branch, &settings.BranchPermissions); err != nil {
 		log.Err(err).
@@ -669,7 +878,9 @@ func applyBranchProtectionFallback(settings *PermissionsSettings, repo *Reposito
 			Str("organization", *settings.Organization).
 			Str("branch", branch).
 			Msg("setting branch protection fallback via REST API")
+		return err
 	}
+	return nil
 }
 
 // getDefaultBranch returns the branch name to protect for a repository.
@@ -752,19 +963,31 @@ func coalesceBoolPtr(repoValue, defaultValue *bool) *bool {
 	return defaultValue
 }
 
-func setRepositoryFeatures(repo *Repository, repoID githubv4.ID, settings *PermissionsSettings, client *GitHubClient, dryRun bool) {
-	// Apply defaults from settings if repo-level values are nil
-	var wiki, issues, projects *bool
+// repositoryFeatures returns the wiki, issues and projects settings for repo, applying the defaults
+// from settings where the repository sets none.
+func repositoryFeatures(repo *Repository, settings *PermissionsSettings) (wiki, issues, projects *bool) {
 	if settings.Defaults != nil {
-		wiki = coalesceBoolPtr(repo.Wiki, settings.Defaults.Wiki)
-		issues = coalesceBoolPtr(repo.Issues, settings.Defaults.Issues)
-		projects = coalesceBoolPtr(repo.Projects, settings.Defaults.Projects)
-	} else {
-		// Fallback to legacy default_* fields for backward compatibility
-		wiki = coalesceBoolPtr(repo.Wiki, settings.DefaultWiki)
-		issues = coalesceBoolPtr(repo.Issues, settings.DefaultIssues)
-		projects = coalesceBoolPtr(repo.Projects, settings.DefaultProjects)
+		return coalesceBoolPtr(repo.Wiki, settings.Defaults.Wiki),
+			coalesceBoolPtr(repo.Issues, settings.Defaults.Issues),
+			coalesceBoolPtr(repo.Projects, settings.Defaults.Projects)
+	}
+	// Fallback to legacy default_* fields for backward compatibility
+	return coalesceBoolPtr(repo.Wiki, settings.DefaultWiki),
+		coalesceBoolPtr(repo.Issues, settings.DefaultIssues),
+		coalesceBoolPtr(repo.Projects, settings.DefaultProjects)
+}
+
+// repositoryDeleteBranchOnMerge returns the delete_branch_on_merge setting for repo, applying the
+// default from settings where the repository sets none.
+func repositoryDeleteBranchOnMerge(settings *PermissionsSettings, repo *Repository) *bool {
+	if settings.Defaults != nil {
+		return coalesceBoolPtr(repo.DeleteBranchOnMerge, settings.Defaults.DeleteBranchOnMerge)
 	}
+	return repo.DeleteBranchOnMerge
+}
+
+func setRepositoryFeatures(repo *Repository, repoID githubv4.ID, settings *PermissionsSettings, client *GitHubClient, dryRun bool) error {
+	wiki, issues, projects := repositoryFeatures(repo, settings)
 
 	if dryRun {
 		logEvent := log.Info().Str("repository", *repo.Name)
@@ -784,7 +1007,7 @@ func setRepositoryFeatures(repo *Repository, repoID githubv4.ID, settings *Permi
 			logEvent = logEvent.Bool("sponsorships", *repo.HasSponsorshipsEnabled)
 		}
 		logEvent.Msg("Would update repository features")
-		return
+		return nil
 	}
 
 	if err := client.SetRepository(
@@ -812,20 +1035,15 @@ func setRepositoryFeatures(repo *Repository, repoID githubv4.ID, settings *Permi
 			logEvent = logEvent.Bool("sponsorshipsEnabled", *repo.HasSponsorshipsEnabled)
 		}
 		logEvent.Msg("setting repository fields")
+		return err
 	}
+	return nil
 }
 
-func setAdvancedRepoSettings(settings *PermissionsSettings, repo *Repository, client *GitHubClient, dryRun bool) {
-	// Apply default for delete_branch_on_merge if repo-level value is nil
-	var deleteBranchOnMerge *bool
-	if settings.Defaults != nil {
-		deleteBranchOnMerge = coalesceBoolPtr(repo.DeleteBranchOnMerge, settings.Defaults.DeleteBranchOnMerge)
-	} else {
-		deleteBranchOnMerge = repo.DeleteBranchOnMerge
-	}
-
+func setAdvancedRepoSettings(settings *PermissionsSettings, repo *Repository, client *GitHubClient, dryRun bool) error {
+	deleteBranchOnMerge := repositoryDeleteBranchOnMerge(settings, repo)
 	if deleteBranchOnMerge == nil {
-		return
+		return nil
 	}
 
 	if dryRun {
@@ -833,7 +1051,7 @@ func setAdvancedRepoSettings(settings *PermissionsSettings, repo *Repository, cl
 			Str("repository", *repo.Name).
 			Bool("delete_branch_on_merge", *deleteBranchOnMerge).
 			Msg("Would update delete_branch_on_merge setting")
-		return
+		return nil
 	}
 
 	if err := client.SetRepositoryAdvancedSettings(*settings.Organization, *repo.Name, deleteBranchOnMerge); err != nil {
@@ -842,18 +1060,23 @@ func setAdvancedRepoSettings(settings *PermissionsSettings, repo *Repository, cl
 			Str("organization", *settings.Organization).
 			Bool("deleteBranchOnMerge", *deleteBranchOnMerge).
 			Msg("setting advanced repository settings")
+		return err
 	}
+	return nil
 }
 
 // UpdateBranchMergeStrategies updates merge strategy settings (merge, rebase, squash)
-// for all repositories in the provided configuration using the REST API.
-func UpdateBranchMergeStrategies(settings *PermissionsSettings, client *GitHubClient) {
+// for all repositories in the provided configuration using the REST API. Failed repositories are
+// logged and reported with ErrRepositoriesFailed.
+func UpdateBranchMergeStrategies(settings *PermissionsSettings, client *GitHubClient) error {
 	// Validate branch permissions configuration
 	if err := ValidateBranchPermissions(&settings.BranchPermissions); err != nil {
 		log.Err(err).Msg("branch permissions validation failed")
-		return
+		return err
 	}
 
+	var failures repositoryFailures
+
 	for _, repo := range settings.Repositories {
 		// Skip archived repositories - they are read-only
 		if repo.Archived != nil && *repo.Archived {
@@ -863,6 +1086,7 @@ func UpdateBranchMergeStrategies(settings *PermissionsSettings, client *GitHubCl
 			continue
 		}
 
+		effective := settings.EffectiveSettings(repo)
 		b := func(p *bool) bool {
 			if p == nil {
 				return false
@@ -871,19 +1095,24 @@ func UpdateBranchMergeStrategies(settings *PermissionsSettings, client *GitHubCl
 		}
 		log.Info().
 			Str("repository", *repo.Name).
-			Bool("squash-commits", b(settings.AllowSquashMerge)).
-			Bool("merges", b(settings.AllowMergeCommit)).
-			Bool("rebase-merge", b(settings.AllowRebaseMerge)).
+			Bool("squash-commits", b(effective.AllowSquashMerge)).
+			Bool("merges", b(effective.AllowMergeCommit)).
+			Bool("rebase-merge", b(effective.AllowRebaseMerge)).
 			Msg("Updating settings")
-		if err := client.UpdateBranchPermissions(*settings.Organization, *repo.Name, &settings.BranchPermissions); err != nil {
+		err := client.UpdateBranchPermissions(*settings.Organization, *repo.Name, &effective.BranchPermissions)
+		if err != nil {
 			log.Err(err).Str("repository", *repo.Name).Str("organization", *settings.Organization).Msg("updating repository settings")
 		}
+		failures.record(*repo.Name, err)
 	}
+	return failures.err()
 }
 
 // SyncLabels synchronizes labels for each repository in the configuration,
-// creating, updating, or deleting labels to match the desired state.
-func SyncLabels(settings *PermissionsSettings, client *GitHubClient) {
+// creating, updating, or deleting labels to match the desired state. Failed repositories are
+// logged and reported with ErrRepositoriesFailed.
+func SyncLabels(settings *PermissionsSettings, client *GitHubClient) error {
+	var failures repositoryFailures
 	for _, repo := range settings.Repositories {
 		// Skip archived repositories - they are read-only
 		if repo.Archived != nil && *repo.Archived {
@@ -896,15 +1125,21 @@ func SyncLabels(settings *PermissionsSettings, client *GitHubClient) {
 		log.Info().
 			Str("repository", *repo.Name).
 			Msg("Updating Labels")
-		if err := client.SyncLabels(*settings.Organization, *repo.Name, settings.DefaultLabels); err != nil {
+		labels := settings.EffectiveSettings(repo).DefaultLabels
+		err := client.SyncLabels(*settings.Organization, *repo.Name, labels)
+		if err != nil {
 			log.Err(err).Msg("synchronizing Labels")
 		}
+		failures.record(*repo.Name, err)
 	}
+	return failures.err()
 }
 
 // SyncTopics synchronizes topics for each repository in the configuration,
-// either additively or by replacement depending on the additive flag.
-func SyncTopics(settings *PermissionsSettings, client *GitHubClient, additive bool) {
+// either additively or by replacement depending on the additive flag. Failed repositories are
+// logged and reported with ErrRepositoriesFailed.
+func SyncTopics(settings *PermissionsSettings, client *GitHubClient, additive bool) error {
+	var failures repositoryFailures
 	for _, repo := range settings.Repositories {
 		// Skip archived repositories - they are read-only
 		if repo.Archived != nil && *repo.Archived {
@@ -918,8 +1153,12 @@ func SyncTopics(settings *PermissionsSettings, client *GitHubClient, additive bo
 			Str("repository", *repo.Name).
 			Bool("additive", additive).
 			Msg("Updating Topics")
-		if err := client.SyncTopics(*settings.Organization, *repo.Name, settings.DefaultTopics, additive); err != nil {
+		topics := settings.EffectiveSettings(repo).DefaultTopics
+		err := client.SyncTopics(*settings.Organization, *repo.Name, topics, additive)
+		if err != nil {
 			log.Err(err).Msg("synchronizing Topics")
 		}
+		failures.record(*repo.Name, err)
 	}
+	return failures.err()
 }
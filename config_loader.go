@@ -0,0 +1,490 @@
+package ownershit
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Top-level configuration keys whose entries are combined across files rather than set once.
+const (
+	configKeyInclude       = "include"
+	configKeyRepositories  = "repositories"
+	configKeyOrganizations = "organizations"
+	configKeyTeam          = "team"
+	configKeyDefaultLabels = "default_labels"
+	configKeyDefaultTopics = "default_topics"
+)
+
+// configLocation identifies where a configuration value was defined.
+type configLocation struct {
+	file string
+	line int
+}
+
+func (l configLocation) String() string {
+	return fmt.Sprintf("%s:%d", l.file, l.line)
+}
+
+// configLoader merges configuration files at the YAML node level, remembering where each
+// value came from so conflicts can be reported against the offending file and line.
+type configLoader struct {
+	// lenient skips the per-file strict decode so validation can report every problem at once.
+	lenient bool
+	// files maps every parsed node to the file it was read from.
+	files map[*yaml.Node]string
+	// mergeErrors collects conflicts between files in lenient mode instead of stopping at the first.
+	mergeErrors []error
+	loaded      map[string]bool
+	order       []string
+	keys        []string
+	keyNodes    map[string]*yaml.Node
+	values      map[string]*yaml.Node
+	origins     map[string]configLocation
+	repos       map[string]configLocation
+	orgs        map[string]configLocation
+	teams       map[string]configLocation
+	labels      map[string]configLocation
+	topics      map[string]bool
+}
+
+func newConfigLoader() *configLoader {
+	return &configLoader{
+		files:    make(map[*yaml.Node]string),
+		keyNodes: make(map[string]*yaml.Node),
+		loaded:   make(map[string]bool),
+		values:   make(map[string]*yaml.Node),
+		origins:  make(map[string]configLocation),
+		repos:    make(map[string]configLocation),
+		orgs:     make(map[string]configLocation),
+		teams:    make(map[string]configLocation),
+		labels:   make(map[string]configLocation),
+		topics:   make(map[string]bool),
+	}
+}
+
+// LoadConfiguration reads the configuration at path, which may be a single YAML file or a directory
+// of *.yaml/*.yml files (read recursively in lexical order, skipping the overlays directory). Files may
+// pull in others with an `include:` list of paths or globs relative to the including file. repositories,
+// organizations, team, default_labels and default_topics are combined across files; every other setting may only be
+// defined once, or identically. The named overlays are then merged on top, in order (see
+// ResolveOverlayPath). Finally ${env:NAME}, ${secret:NAME} and ${file:path} references in string values
+// are resolved (see interpolateString), and secret values registered for redaction. Errors are ConfigFileErrors naming the
+// offending file.
+func LoadConfiguration(path string, overlays ...string) (*PermissionsSettings, error) {
+	return loadConfiguration(path, true, overlays...)
+}
+
+// loadConfiguration loads the configuration at path, resolving interpolation references only when
+// interpolate is set; commands that rewrite the files must see the references themselves.
+func loadConfiguration(path string, interpolate bool, overlays ...string) (*PermissionsSettings, error) {
+	loader := newConfigLoader()
+	doc, err := loader.render(path, overlays...)
+	if err != nil {
+		return nil, err
+	}
+	if interpolate {
+		var interpolateErr error
+		loader.interpolate(doc, ConfigBaseDir(path), func(_ *yaml.Node, err error) {
+			if interpolateErr == nil {
+				interpolateErr = err
+			}
+		})
+		if interpolateErr != nil {
+			return nil, interpolateErr
+		}
+	}
+	merged, err := yaml.Marshal(doc)
+	if err != nil {
+		return nil, NewConfigFileError(path, "merge", "failed to merge configuration files", err)
+	}
+	settings := &PermissionsSettings{}
+	dec := yaml.NewDecoder(bytes.NewReader(merged))
+	dec.KnownFields(true)
+	if err := dec.Decode(settings); err != nil {
+		return nil, NewConfigFileError(path, "parse", "failed to parse merged configuration", err)
+	}
+	return settings, nil
+}
+
+// RenderConfiguration returns the fully merged configuration at path, with overlays applied, as YAML.
+// Interpolation references are left as written, so secrets never appear in the output.
+func RenderConfiguration(path string, overlays ...string) ([]byte, error) {
+	doc, err := newConfigLoader().render(path, overlays...)
+	if err != nil {
+		return nil, err
+	}
+	merged, err := yaml.Marshal(doc)
+	if err != nil {
+		return nil, NewConfigFileError(path, "merge", "failed to merge configuration files", err)
+	}
+	return merged, nil
+}
+
+// render loads the configuration at path and applies the overlays, returning the merged document.
+func (l *configLoader) render(path string, overlays ...string) (*yaml.Node, error) {
+	if err := l.loadAll(path); err != nil {
+		return nil, err
+	}
+	if len(l.keys) == 0 {
+		return nil, NewConfigFileError(path, "parse", "configuration is empty", io.EOF)
+	}
+
+	doc := l.document()
+	// Older configurations are migrated in memory; overlays are written against the current schema.
+	if _, _, err := MigrateConfigDocument(doc.Content[0]); err != nil {
+		return nil, NewConfigFileError(path, "migrate", err.Error(), err)
+	}
+	for _, overlay := range overlays {
+		file, err := ResolveOverlayPath(path, overlay)
+		if err != nil {
+			return nil, err
+		}
+		if err := l.applyOverlay(doc, file); err != nil {
+			return nil, err
+		}
+	}
+	return doc, nil
+}
+
+// loadAll loads the configuration file at path, or every YAML file below the directory at path.
+func (l *configLoader) loadAll(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return NewConfigFileError(path, "read", "failed to read configuration file", err)
+	}
+
+	files := []string{path}
+	if info.IsDir() {
+		files, err = configDirectoryFiles(path)
+		if err != nil {
+			return NewConfigFileError(path, "read", "failed to list configuration directory", err)
+		}
+		if len(files) == 0 {
+			return NewConfigFileError(path, "read", "configuration directory contains no YAML files", nil)
+		}
+	}
+
+	for _, file := range files {
+		if err := l.load(file); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ConfigBaseDir returns the directory relative paths in the configuration at path resolve from:
+// the directory itself, or the directory containing the file.
+func ConfigBaseDir(path string) string {
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		return path
+	}
+	return filepath.Dir(path)
+}
+
+// track records file as the source of node and everything below it.
+func (l *configLoader) track(file string, node *yaml.Node) {
+	l.files[node] = file
+	for _, child := range node.Content {
+		l.track(file, child)
+	}
+}
+
+// configDirectoryFiles returns every YAML file below dir in lexical order, except overlays.
+func configDirectoryFiles(dir string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && p != dir && d.Name() == overlayDirectory {
+			return filepath.SkipDir
+		}
+		if !d.IsDir() && isYAMLFile(p) {
+			files = append(files, p)
+		}
+		return nil
+	})
+	return files, err
+}
+
+func isYAMLFile(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// load parses file, merges its settings and then loads its includes. Files already loaded are skipped.
+func (l *configLoader) load(file string) error {
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return NewConfigFileError(file, "read", "failed to resolve configuration path", err)
+	}
+	if l.loaded[abs] {
+		return nil
+	}
+	l.loaded[abs] = true
+	l.order = append(l.order, file)
+
+	data, err := os.ReadFile(file) // #nosec G304 - configuration paths come from the user
+	if err != nil {
+		return NewConfigFileError(file, "read", "failed to read configuration file", err)
+	}
+
+	// Strict decoding reports unknown fields and type errors with this file's line numbers.
+	if !l.lenient {
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(&PermissionsSettings{}); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return NewConfigFileError(file, "parse", "failed to parse YAML configuration", err)
+		}
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return NewConfigFileError(file, "parse", "failed to parse YAML configuration", err)
+	}
+	if len(doc.Content) == 0 {
+		return nil
+	}
+	if doc.Content[0].Kind != yaml.MappingNode {
+		return NewConfigFileError(file, "parse", "configuration must be a YAML mapping", nil)
+	}
+
+	l.track(file, doc.Content[0])
+
+	var includes *yaml.Node
+	root := doc.Content[0]
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, value := root.Content[i], root.Content[i+1]
+		if key.Value == configKeyInclude {
+			includes = value
+			continue
+		}
+		if err := l.merge(file, key, value); err != nil {
+			if !l.lenient {
+				return err
+			}
+			l.mergeErrors = append(l.mergeErrors, err)
+		}
+	}
+
+	if includes != nil {
+		return l.loadIncludes(file, includes)
+	}
+	return nil
+}
+
+// loadIncludes resolves the include patterns of file relative to its directory and loads them.
+func (l *configLoader) loadIncludes(file string, includes *yaml.Node) error {
+	var patterns []string
+	if err := includes.Decode(&patterns); err != nil {
+		return NewConfigFileError(file, "parse",
+			fmt.Sprintf("line %d: include must be a list of paths", includes.Line), err)
+	}
+	baseDir := filepath.Dir(file)
+	for i, pattern := range patterns {
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(baseDir, pattern)
+		}
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return NewConfigFileError(file, "include",
+				fmt.Sprintf("line %d: invalid include pattern %q", includes.Content[i].Line, patterns[i]), err)
+		}
+		if len(matches) == 0 {
+			return NewConfigFileError(file, "include",
+				fmt.Sprintf("line %d: include %q matched no files", includes.Content[i].Line, patterns[i]), nil)
+		}
+		sort.Strings(matches)
+		for _, match := range matches {
+			if err := l.loadIncludedPath(match); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// loadIncludedPath loads an included file, or every YAML file below an included directory.
+func (l *configLoader) loadIncludedPath(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return NewConfigFileError(path, "read", "failed to read included configuration", err)
+	}
+	if !info.IsDir() {
+		return l.load(path)
+	}
+	files, err := configDirectoryFiles(path)
+	if err != nil {
+		return NewConfigFileError(path, "read", "failed to list included directory", err)
+	}
+	for _, file := range files {
+		if err := l.load(file); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// merge adds a top-level key from file to the merged configuration.
+func (l *configLoader) merge(file string, key, value *yaml.Node) error {
+	name := key.Value
+	existing, seen := l.values[name]
+	if !seen {
+		l.keys = append(l.keys, name)
+		l.keyNodes[name] = key
+		l.origins[name] = configLocation{file: file, line: key.Line}
+	}
+
+	switch name {
+	case configKeyRepositories, configKeyOrganizations, configKeyTeam, configKeyDefaultLabels, configKeyDefaultTopics:
+		if value.Kind != yaml.SequenceNode {
+			if value.Tag == "!!null" {
+				if !seen {
+					l.values[name] = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+				}
+				return nil
+			}
+			return NewConfigFileError(file, "parse", fmt.Sprintf("line %d: %s must be a list", value.Line, name), nil)
+		}
+		if !seen {
+			existing = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+			l.values[name] = existing
+		}
+		for _, item := range value.Content {
+			keep, err := l.checkListItem(file, name, item)
+			if err != nil {
+				return err
+			}
+			if keep {
+				existing.Content = append(existing.Content, item)
+			}
+		}
+		return nil
+	}
+
+	if !seen {
+		l.values[name] = value
+		return nil
+	}
+	same, err := sameYAMLValue(existing, value)
+	if err != nil {
+		return NewConfigFileError(file, "merge", fmt.Sprintf("line %d: cannot compare %s", key.Line, name), err)
+	}
+	if !same {
+		return NewConfigFileError(file, "merge",
+			fmt.Sprintf("line %d: %s conflicts with the value defined at %s", key.Line, name, l.origins[name]), nil)
+	}
+	return nil
+}
+
+// checkListItem reports whether a list entry should be appended, returning an error for duplicate
+// repositories or organizations and for team permissions or labels that conflict with earlier definitions.
+func (l *configLoader) checkListItem(file, list string, item *yaml.Node) (bool, error) {
+	here := configLocation{file: file, line: item.Line}
+	switch list {
+	case configKeyRepositories:
+		name := strings.TrimSpace(mappingValue(item, "name"))
+		if name == "" {
+			return true, nil
+		}
+		if first, ok := l.repos[name]; ok {
+			return false, NewConfigFileError(file, "merge",
+				fmt.Sprintf("line %d: duplicate repository %q (first defined at %s)", item.Line, name, first), nil)
+		}
+		l.repos[name] = here
+	case configKeyOrganizations:
+		name := strings.ToLower(strings.TrimSpace(mappingValue(item, "name")))
+		if name == "" {
+			return true, nil
+		}
+		if first, ok := l.orgs[name]; ok {
+			return false, NewConfigFileError(file, "merge",
+				fmt.Sprintf("line %d: duplicate organization %q (first defined at %s)", item.Line, mappingValue(item, "name"), first), nil)
+		}
+		l.orgs[name] = here
+	case configKeyTeam, configKeyDefaultLabels:
+		index := l.teams
+		if list == configKeyDefaultLabels {
+			index = l.labels
+		}
+		name := mappingValue(item, "name")
+		if name == "" {
+			return true, nil
+		}
+		first, ok := index[name]
+		if !ok {
+			index[name] = here
+			return true, nil
+		}
+		previous := l.findListItem(list, name)
+		same, err := sameYAMLValue(previous, item)
+		if err != nil || !same {
+			return false, NewConfigFileError(file, "merge",
+				fmt.Sprintf("line %d: %s entry %q conflicts with the definition at %s", item.Line, list, name, first), err)
+		}
+		return false, nil
+	case configKeyDefaultTopics:
+		if l.topics[item.Value] {
+			return false, nil
+		}
+		l.topics[item.Value] = true
+	}
+	return true, nil
+}
+
+// findListItem returns the merged entry of list whose name is name.
+func (l *configLoader) findListItem(list, name string) *yaml.Node {
+	for _, item := range l.values[list].Content {
+		if mappingValue(item, "name") == name {
+			return item
+		}
+	}
+	return nil
+}
+
+// document builds the merged configuration document in first-seen key order.
+func (l *configLoader) document() *yaml.Node {
+	root := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	for _, name := range l.keys {
+		root.Content = append(root.Content, l.keyNodes[name], l.values[name])
+	}
+	return &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{root}}
+}
+
+// mappingValue returns the scalar value of key in a mapping node, or "".
+func mappingValue(node *yaml.Node, key string) string {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return ""
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1].Value
+		}
+	}
+	return ""
+}
+
+// sameYAMLValue compares two nodes by their decoded values, ignoring style and comments.
+func sameYAMLValue(a, b *yaml.Node) (bool, error) {
+	var av, bv interface{}
+	if err := a.Decode(&av); err != nil {
+		return false, err
+	}
+	if err := b.Decode(&bv); err != nil {
+		return false, err
+	}
+	return reflect.DeepEqual(av, bv), nil
+}
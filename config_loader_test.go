@@ -0,0 +1,187 @@
+package ownershit
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeConfigFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestLoadConfigurationSingleFile(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"repositories.yaml": "organization: org\nrepositories:\n  - name: a\n",
+	})
+	settings, err := LoadConfiguration(filepath.Join(dir, "repositories.yaml"))
+	if err != nil {
+		t.Fatalf("LoadConfiguration() error = %v", err)
+	}
+	if *settings.Organization != "org" || len(settings.Repositories) != 1 {
+		t.Errorf("unexpected settings: %+v", settings)
+	}
+}
+
+func TestLoadConfigurationDirectory(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"00-global.yaml": `organization: org
+team:
+  - name: developers
+    level: push
+default_topics: [managed]
+default_labels:
+  - name: bug
+    color: ff0000
+`,
+		"teams/payments.yaml": `repositories:
+  - name: payments-api
+  - name: payments-web
+team:
+  - name: developers
+    level: push
+default_topics: [managed, payments]
+`,
+		"teams/platform.yml": `repositories:
+  - name: platform-tools
+default_labels:
+  - name: bug
+    color: ff0000
+  - name: infra
+    color: 00ff00
+`,
+		"README.md": "not configuration",
+	})
+
+	settings, err := LoadConfiguration(dir)
+	if err != nil {
+		t.Fatalf("LoadConfiguration() error = %v", err)
+	}
+	if len(settings.Repositories) != 3 {
+		t.Errorf("expected 3 repositories, got %d", len(settings.Repositories))
+	}
+	if len(settings.TeamPermissions) != 1 {
+		t.Errorf("expected identical team entries to be merged, got %d", len(settings.TeamPermissions))
+	}
+	if len(settings.DefaultLabels) != 2 {
+		t.Errorf("expected 2 labels, got %d", len(settings.DefaultLabels))
+	}
+	if strings.Join(settings.DefaultTopics, ",") != "managed,payments" {
+		t.Errorf("unexpected topics: %v", settings.DefaultTopics)
+	}
+}
+
+func TestLoadConfigurationIncludes(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"repositories.yaml": `organization: org
+include:
+  - repos/*.yaml
+  - shared.yaml
+repositories:
+  - name: root-repo
+`,
+		"repos/a.yaml": "repositories:\n  - name: a\n",
+		"repos/b.yaml": "include: [../shared.yaml]\nrepositories:\n  - name: b\n",
+		"shared.yaml":  "branches:\n  require_code_owners: true\n",
+	})
+	settings, err := LoadConfiguration(filepath.Join(dir, "repositories.yaml"))
+	if err != nil {
+		t.Fatalf("LoadConfiguration() error = %v", err)
+	}
+	if len(settings.Repositories) != 3 {
+		t.Errorf("expected 3 repositories, got %d", len(settings.Repositories))
+	}
+	if settings.RequireCodeOwners == nil || !*settings.RequireCodeOwners {
+		t.Error("expected branches from shared include")
+	}
+}
+
+func TestLoadConfigurationErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    map[string]string
+		wantFile string
+		wantMsg  string
+	}{
+		{
+			name: "duplicate repository",
+			files: map[string]string{
+				"a.yaml": "organization: org\nrepositories:\n  - name: svc\n",
+				"b.yaml": "repositories:\n  - name: other\n  - name: svc\n",
+			},
+			wantFile: "b.yaml",
+			wantMsg:  `line 3: duplicate repository "svc" (first defined at`,
+		},
+		{
+			name: "conflicting global setting",
+			files: map[string]string{
+				"a.yaml": "organization: org\n",
+				"b.yaml": "\norganization: other\n",
+			},
+			wantFile: "b.yaml",
+			wantMsg:  "line 2: organization conflicts with the value defined at",
+		},
+		{
+			name: "conflicting team level",
+			files: map[string]string{
+				"a.yaml": "organization: org\nteam:\n  - name: devs\n    level: push\n",
+				"b.yaml": "team:\n  - name: devs\n    level: admin\n",
+			},
+			wantFile: "b.yaml",
+			wantMsg:  `team entry "devs" conflicts`,
+		},
+		{
+			name: "unknown field",
+			files: map[string]string{
+				"a.yaml": "organization: org\n",
+				"b.yaml": "repositorys: []\n",
+			},
+			wantFile: "b.yaml",
+			wantMsg:  "failed to parse YAML configuration",
+		},
+		{
+			name: "include without matches",
+			files: map[string]string{
+				"a.yaml": "organization: org\ninclude: [missing/*.yaml]\n",
+			},
+			wantFile: "a.yaml",
+			wantMsg:  `line 2: include "missing/*.yaml" matched no files`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeConfigFiles(t, tt.files)
+			_, err := LoadConfiguration(dir)
+			var fileErr *ConfigFileError
+			if !errors.As(err, &fileErr) {
+				t.Fatalf("expected ConfigFileError, got %v", err)
+			}
+			if filepath.Base(fileErr.Filename) != tt.wantFile {
+				t.Errorf("Filename = %s, want %s", fileErr.Filename, tt.wantFile)
+			}
+			if !strings.Contains(fileErr.Message, tt.wantMsg) {
+				t.Errorf("Message = %q, want it to contain %q", fileErr.Message, tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestLoadConfigurationEmpty(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{"repositories.yaml": "# nothing yet\n"})
+	if _, err := LoadConfiguration(filepath.Join(dir, "repositories.yaml")); err == nil {
+		t.Fatal("expected error for empty configuration")
+	}
+}
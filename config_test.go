@@ -397,11 +397,14 @@ func TestMapPermissions(t *testing.T) {
 		Return(nil, defaultGoodResponse, nil)
 
 	mocks.graphMock.EXPECT().Query(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().Return(nil).
-		Do(func(ctx context.Context, query *GetRepoQuery, things map[string]interface{}) {
-			query.Repository.ID = githubv4.ID("12345")
-			query.Repository.HasIssuesEnabled = false
-			query.Repository.HasProjectsEnabled = false
-			query.Repository.HasWikiEnabled = false
+		Do(func(ctx context.Context, q interface{}, things map[string]interface{}) {
+			// Batched state queries read nothing, so repositories are looked up individually.
+			if query, ok := q.(*GetRepoQuery); ok {
+				query.Repository.ID = githubv4.ID("12345")
+				query.Repository.HasIssuesEnabled = false
+				query.Repository.HasProjectsEnabled = false
+				query.Repository.HasWikiEnabled = false
+			}
 		})
 
 	type args struct {
@@ -453,11 +456,14 @@ func TestUpdateBranchMergeStrategies(t *testing.T) {
 		Return(nil, defaultGoodResponse, nil)
 
 	mocks.graphMock.EXPECT().Query(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().Return(nil).
-		Do(func(ctx context.Context, query *GetRepoQuery, things map[string]interface{}) {
-			query.Repository.ID = githubv4.ID("12345")
-			query.Repository.HasIssuesEnabled = false
-			query.Repository.HasProjectsEnabled = false
-			query.Repository.HasWikiEnabled = false
+		Do(func(ctx context.Context, q interface{}, things map[string]interface{}) {
+			// Batched state queries read nothing, so repositories are looked up individually.
+			if query, ok := q.(*GetRepoQuery); ok {
+				query.Repository.ID = githubv4.ID("12345")
+				query.Repository.HasIssuesEnabled = false
+				query.Repository.HasProjectsEnabled = false
+				query.Repository.HasWikiEnabled = false
+			}
 		})
 
 	type args struct {
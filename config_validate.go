@@ -0,0 +1,352 @@
+package ownershit
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigProblem is a single configuration error and the place in the YAML it refers to.
+type ConfigProblem struct {
+	File    string `json:"file"`
+	Line    int    `json:"line,omitempty"`
+	Column  int    `json:"column,omitempty"`
+	Path    string `json:"path,omitempty"`
+	Message string `json:"message"`
+}
+
+func (p ConfigProblem) String() string {
+	location := p.File
+	switch {
+	case p.Line > 0 && p.Column > 0:
+		location = fmt.Sprintf("%s:%d:%d", p.File, p.Line, p.Column)
+	case p.Line > 0:
+		location = fmt.Sprintf("%s:%d", p.File, p.Line)
+	}
+	if p.Path == "" {
+		return location + ": " + p.Message
+	}
+	return fmt.Sprintf("%s: %s: %s", location, p.Path, p.Message)
+}
+
+// ConfigProblemsError reports the located problems that keep a configuration from loading, for
+// commands that need it loaded. Err is the error loading returned.
+type ConfigProblemsError struct {
+	Problems []ConfigProblem
+	Err      error
+}
+
+func (e *ConfigProblemsError) Error() string {
+	lines := make([]string, 0, len(e.Problems)+1)
+	lines = append(lines, fmt.Sprintf("%d configuration problem(s) found:", len(e.Problems)))
+	for _, problem := range e.Problems {
+		lines = append(lines, "  "+problem.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (e *ConfigProblemsError) Unwrap() error {
+	return e.Err
+}
+
+// locateLoadError returns a ConfigProblemsError with the problems ValidateConfiguration finds in the
+// configuration at path when loading it failed with err, or err itself when none are found.
+func locateLoadError(path string, err error, overlays ...string) error {
+	problems, validateErr := ValidateConfiguration(path, overlays...)
+	if validateErr != nil || len(problems) == 0 {
+		return err
+	}
+	return &ConfigProblemsError{Problems: problems, Err: err}
+}
+
+// ValidateConfiguration checks the configuration at path, with overlays applied, without contacting
+// GitHub. It checks the structure against the embedded JSON Schema and then runs the semantic checks
+// of ValidatePermissionsSettings, returning every problem found. The error is reserved for
+// configurations that cannot be loaded at all, such as unreadable files or conflicting definitions.
+func ValidateConfiguration(path string, overlays ...string) ([]ConfigProblem, error) {
+	loader := newConfigLoader()
+	loader.lenient = true
+	doc, err := loader.render(path, overlays...)
+	if err != nil {
+		return nil, err
+	}
+	schema := &JSONSchema{}
+	if err := json.Unmarshal(ConfigSchema(), schema); err != nil {
+		return nil, fmt.Errorf("parsing embedded configuration schema: %w", err)
+	}
+
+	root := doc.Content[0]
+	v := &schemaValidator{root: schema, files: loader.files, fallback: path, invalid: make(map[*yaml.Node]bool)}
+	for _, err := range loader.mergeErrors {
+		v.problems = append(v.problems, mergeProblem(err))
+	}
+	v.validate(root, schema, "")
+	loader.interpolate(root, ConfigBaseDir(path), func(node *yaml.Node, err error) {
+		var fileErr *ConfigFileError
+		if errors.As(err, &fileErr) {
+			v.report(node, "", strings.TrimPrefix(fileErr.Message, fmt.Sprintf("line %d: ", node.Line)))
+			return
+		}
+		v.report(node, "", err.Error())
+	})
+
+	// Decoding is lenient: unknown fields and type errors were reported by the schema check above,
+	// and the semantic checks still run on everything that did decode.
+	settings := &PermissionsSettings{}
+	var typeErr *yaml.TypeError
+	if err := root.Decode(settings); err != nil && !errors.As(err, &typeErr) {
+		v.report(root, "", err.Error())
+	}
+	for _, err := range PermissionsSettingsErrors(settings) {
+		v.reportValidationError(root, err)
+	}
+
+	sort.SliceStable(v.problems, func(i, j int) bool {
+		a, b := v.problems[i], v.problems[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	return v.problems, nil
+}
+
+// lineMessage splits the "line N: " prefix off configuration file error messages.
+var lineMessage = regexp.MustCompile(`^line (\d+): (.*)$`)
+
+// mergeProblem converts a conflict between configuration files into a problem.
+func mergeProblem(err error) ConfigProblem {
+	var fileErr *ConfigFileError
+	if !errors.As(err, &fileErr) {
+		return ConfigProblem{Message: err.Error()}
+	}
+	problem := ConfigProblem{File: fileErr.Filename, Message: fileErr.Message}
+	if match := lineMessage.FindStringSubmatch(fileErr.Message); match != nil {
+		problem.Line, _ = strconv.Atoi(match[1])
+		problem.Message = match[2]
+	}
+	return problem
+}
+
+// UnmarshalJSON decodes additionalProperties as either a boolean or a schema.
+func (s *JSONSchema) UnmarshalJSON(data []byte) error {
+	type plain JSONSchema
+	raw := struct {
+		*plain
+		AdditionalProperties json.RawMessage `json:"additionalProperties,omitempty"`
+	}{plain: (*plain)(s)}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	switch strings.TrimSpace(string(raw.AdditionalProperties)) {
+	case "":
+		s.AdditionalProperties = nil
+	case "true", "false":
+		s.AdditionalProperties = string(raw.AdditionalProperties) == "true"
+	default:
+		additional := &JSONSchema{}
+		if err := json.Unmarshal(raw.AdditionalProperties, additional); err != nil {
+			return err
+		}
+		s.AdditionalProperties = additional
+	}
+	return nil
+}
+
+// schemaValidator checks YAML nodes against a JSONSchema, collecting every problem.
+type schemaValidator struct {
+	root     *JSONSchema
+	files    map[*yaml.Node]string
+	fallback string
+	problems []ConfigProblem
+	// invalid holds nodes that already have a schema problem, so semantic checks don't repeat it.
+	invalid map[*yaml.Node]bool
+}
+
+func (v *schemaValidator) report(node *yaml.Node, path, message string) {
+	file, ok := v.files[node]
+	if !ok {
+		file = v.fallback
+	}
+	v.problems = append(v.problems, ConfigProblem{
+		File: file, Line: node.Line, Column: node.Column, Path: path, Message: message,
+	})
+	if v.invalid != nil {
+		v.invalid[node] = true
+	}
+}
+
+// resolve follows local $defs references.
+func (v *schemaValidator) resolve(schema *JSONSchema) *JSONSchema {
+	for schema != nil && schema.Ref != "" {
+		schema = v.root.Defs[strings.TrimPrefix(schema.Ref, "#/$defs/")]
+	}
+	if schema == nil {
+		return &JSONSchema{}
+	}
+	return schema
+}
+
+func (v *schemaValidator) validate(node *yaml.Node, schema *JSONSchema, path string) {
+	schema = v.resolve(schema)
+	if node.Kind == yaml.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+	if node.Tag == "!!null" {
+		return
+	}
+	if len(schema.AnyOf) > 0 {
+		for _, option := range schema.AnyOf {
+			if v.matches(node, option) {
+				return
+			}
+		}
+		v.report(node, path, "value does not match any allowed form")
+		return
+	}
+
+	switch schema.Type {
+	case "object":
+		if node.Kind != yaml.MappingNode {
+			v.report(node, path, "expected a mapping")
+			return
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			child := joinConfigPath(path, key.Value)
+			if property, ok := schema.Properties[key.Value]; ok {
+				v.validate(value, property, child)
+				continue
+			}
+			switch additional := schema.AdditionalProperties.(type) {
+			case *JSONSchema:
+				v.validate(value, additional, child)
+			case bool:
+				if !additional {
+					v.report(key, child, fmt.Sprintf("unknown field %q", key.Value))
+				}
+			}
+		}
+	case "array":
+		if node.Kind != yaml.SequenceNode {
+			v.report(node, path, "expected a list")
+			return
+		}
+		if schema.Items == nil {
+			return
+		}
+		for i, item := range node.Content {
+			v.validate(item, schema.Items, fmt.Sprintf("%s[%d]", path, i))
+		}
+	case "string", "boolean", "integer", "number":
+		switch {
+		case node.Kind != yaml.ScalarNode:
+			v.report(node, path, "expected "+schema.Type)
+		case !scalarMatchesType(node, schema.Type):
+			v.report(node, path, fmt.Sprintf("expected %s, got %q", schema.Type, node.Value))
+		case len(schema.Enum) > 0 && !containsString(schema.Enum, node.Value):
+			v.report(node, path, fmt.Sprintf("%q is not one of %s", node.Value, strings.Join(schema.Enum, ", ")))
+		}
+	}
+}
+
+// matches reports whether node is valid against schema, without recording problems.
+func (v *schemaValidator) matches(node *yaml.Node, schema *JSONSchema) bool {
+	sub := &schemaValidator{root: v.root}
+	sub.validate(node, schema, "")
+	return len(sub.problems) == 0
+}
+
+// scalarMatchesType mirrors the YAML decoder: any scalar decodes into a string, while booleans and
+// numbers need the matching resolved tag.
+func scalarMatchesType(node *yaml.Node, schemaType string) bool {
+	switch schemaType {
+	case "boolean":
+		return node.ShortTag() == "!!bool"
+	case "integer":
+		return node.ShortTag() == "!!int"
+	case "number":
+		return node.ShortTag() == "!!int" || node.ShortTag() == "!!float"
+	default:
+		return true
+	}
+}
+
+// filesErrorPrefix matches the "files[N]: " prefix added to file sync validation errors, including
+// those of an organizations entry.
+var filesErrorPrefix = regexp.MustCompile(`^((?:organizations\[\d+\]\.)?files\[\d+\]): `)
+
+// reportValidationError locates a semantic validation error in the document by its field path.
+func (v *schemaValidator) reportValidationError(root *yaml.Node, err error) {
+	path, message := "", err.Error()
+	var validationErr *ConfigValidationError
+	if errors.As(err, &validationErr) {
+		path, message = validationErr.Field, validationErr.Message
+	}
+	if match := filesErrorPrefix.FindStringSubmatch(err.Error()); match != nil {
+		path = match[1]
+	}
+	node := findConfigPath(root, path)
+	if v.invalid[node] {
+		return
+	}
+	v.report(node, path, message)
+}
+
+// findConfigPath returns the deepest node along a path such as "repositories[2].security.secret_scanning".
+// Branch protection fields are reported without their block, so unknown top-level names are looked up
+// under branches as well.
+func findConfigPath(root *yaml.Node, path string) *yaml.Node {
+	if path == "" {
+		return root
+	}
+	segments := strings.Split(path, ".")
+	if index := mappingIndex(root, strings.SplitN(segments[0], "[", 2)[0]); index < 0 {
+		if branches := mappingIndex(root, "branches"); branches >= 0 {
+			root = root.Content[branches+1]
+		}
+	}
+
+	node := root
+	for _, segment := range segments {
+		name, indexes := segment, []int(nil)
+		if open := strings.IndexByte(segment, '['); open >= 0 {
+			name = segment[:open]
+			for _, part := range strings.Split(strings.TrimSuffix(segment[open+1:], "]"), "][") {
+				i, err := strconv.Atoi(part)
+				if err != nil {
+					return node
+				}
+				indexes = append(indexes, i)
+			}
+		}
+		index := mappingIndex(node, name)
+		if index < 0 {
+			return node
+		}
+		node = node.Content[index+1]
+		for _, i := range indexes {
+			if node.Kind != yaml.SequenceNode || i >= len(node.Content) {
+				return node
+			}
+			node = node.Content[i]
+		}
+	}
+	return node
+}
+
+func joinConfigPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,91 @@
+package ownershit
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateConfigurationReportsEveryProblem(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"repositories.yaml": `organization: acme
+include: [teams.yaml]
+branches:
+  require_approving_count: many
+  require_linear_history: true
+  allow_force_pushes: true
+repositories:
+  - name: api
+    wikii: true
+`,
+		"teams.yaml": `teams:
+  - name: core
+    privacy: public
+repositories:
+  - name: api
+`,
+	})
+
+	problems, err := ValidateConfiguration(filepath.Join(dir, "repositories.yaml"))
+	if err != nil {
+		t.Fatalf("ValidateConfiguration() error = %v", err)
+	}
+	want := []string{
+		"repositories.yaml:4:28: branches.require_approving_count: expected integer",
+		"repositories.yaml:6:23: allow_force_pushes: RequireLinearHistory and AllowForcePushes cannot both be enabled",
+		`repositories.yaml:9:5: repositories[0].wikii: unknown field "wikii"`,
+		`teams.yaml:3:14: teams[0].privacy: "public" is not one of closed, secret`,
+		`teams.yaml:5: duplicate repository "api"`,
+	}
+	if len(problems) != len(want) {
+		t.Fatalf("got %d problems, want %d: %v", len(problems), len(want), problems)
+	}
+	for i, problem := range problems {
+		got := strings.TrimPrefix(problem.String(), dir+string(filepath.Separator))
+		if !strings.HasPrefix(got, want[i]) {
+			t.Errorf("problem %d = %q, want prefix %q", i, got, want[i])
+		}
+	}
+}
+
+func TestValidateConfigurationValid(t *testing.T) {
+	problems, err := ValidateConfiguration("example-repositories.yaml")
+	if err != nil {
+		t.Fatalf("ValidateConfiguration() error = %v", err)
+	}
+	if len(problems) != 0 {
+		t.Errorf("expected no problems, got %v", problems)
+	}
+}
+
+func TestValidateConfigurationOverlayProblems(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"repositories.yaml":  "organization: acme\nrepositories:\n  - name: api\n",
+		"overlays/prod.yaml": "organization: \"\"\ndefaults:\n  wiki: sometimes\n",
+	})
+	problems, err := ValidateConfiguration(filepath.Join(dir, "repositories.yaml"), "prod")
+	if err != nil {
+		t.Fatalf("ValidateConfiguration() error = %v", err)
+	}
+	if len(problems) != 2 {
+		t.Fatalf("expected 2 problems, got %v", problems)
+	}
+	for _, problem := range problems {
+		if filepath.Base(problem.File) != "prod.yaml" {
+			t.Errorf("problem %v should be attributed to the overlay", problem)
+		}
+	}
+}
+
+func TestPermissionsSettingsErrors(t *testing.T) {
+	settings := &PermissionsSettings{
+		Repositories: []*Repository{{Name: stringPtr("")}, nil},
+	}
+	errs := PermissionsSettingsErrors(settings)
+	if len(errs) != 3 {
+		t.Fatalf("expected organization and two repository errors, got %v", errs)
+	}
+	if err := ValidatePermissionsSettings(settings); err == nil || err.Error() != errs[0].Error() {
+		t.Errorf("ValidatePermissionsSettings() = %v, want the first error %v", err, errs[0])
+	}
+}
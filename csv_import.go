@@ -26,6 +26,8 @@ func getCSVHeaders() []string {
 		"require_up_to_date_branch", "enforce_admins", "restrict_pushes",
 		"require_conversation_resolution", "require_linear_history",
 		"allow_force_pushes", "allow_deletions", "status_checks", "push_allowlist",
+		"vulnerability_alerts", "dependabot_security_updates", "secret_scanning",
+		"secret_scanning_push_protection", "private_vulnerability_reporting",
 	}
 }
 
@@ -46,6 +48,10 @@ func convertToCSVRow(config *PermissionsSettings, owner, repo string) []string {
 
 	repoConfig := config.Repositories[0] // Single repository context
 	branchPerms := &config.BranchPermissions
+	security := repoConfig.Security
+	if security == nil {
+		security = &SecuritySettings{}
+	}
 
 	return []string{
 		sanitizeCSV(owner),                                       // owner
@@ -77,6 +83,11 @@ func convertToCSVRow(config *PermissionsSettings, owner, repo string) []string {
 		safeBoolValue(branchPerms.AllowDeletions),                // allow_deletions
 		joinStringSlice(branchPerms.StatusChecks),                // status_checks
 		joinStringSlice(branchPerms.PushAllowlist),               // push_allowlist
+		safeBoolValue(security.VulnerabilityAlerts),              // vulnerability_alerts
+		safeBoolValue(security.DependabotSecurityUpdates),        // dependabot_security_updates
+		safeBoolValue(security.SecretScanning),                   // secret_scanning
+		safeBoolValue(security.SecretScanningPushProtection),     // secret_scanning_push_protection
+		safeBoolValue(security.PrivateVulnerabilityReporting),    // private_vulnerability_reporting
 	}
 }
 
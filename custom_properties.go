@@ -0,0 +1,326 @@
+package ownershit
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/go-github/v66/github"
+	"github.com/rs/zerolog/log"
+	"gopkg.in/yaml.v3"
+)
+
+// Custom property value types supported by GitHub.
+const (
+	PropertyTypeString       = "string"
+	PropertyTypeSingleSelect = "single_select"
+	PropertyTypeMultiSelect  = "multi_select"
+	PropertyTypeTrueFalse    = "true_false"
+)
+
+// CustomPropertyDefinition declares an organization-level custom property schema.
+type CustomPropertyDefinition struct {
+	Name             string   `yaml:"name"`
+	ValueType        string   `yaml:"value_type"`
+	Required         *bool    `yaml:"required,omitempty"`
+	DefaultValue     *string  `yaml:"default_value,omitempty"`
+	Description      *string  `yaml:"description,omitempty"`
+	AllowedValues    []string `yaml:"allowed_values,omitempty"`
+	ValuesEditableBy *string  `yaml:"values_editable_by,omitempty"`
+}
+
+// PropertyValue holds a repository custom property value. It is written in YAML either as
+// a scalar (string, single_select, true_false) or as a list (multi_select).
+type PropertyValue []string
+
+// UnmarshalYAML accepts either a scalar or a sequence of scalars.
+func (p *PropertyValue) UnmarshalYAML(value *yaml.Node) error {
+	switch value.Kind {
+	case yaml.ScalarNode:
+		*p = PropertyValue{value.Value}
+		return nil
+	case yaml.SequenceNode:
+		var values []string
+		if err := value.Decode(&values); err != nil {
+			return err
+		}
+		*p = values
+		return nil
+	default:
+		return fmt.Errorf("line %d: property value must be a string or a list of strings", value.Line)
+	}
+}
+
+// MarshalYAML writes single values as scalars and multiple values as a list.
+func (p PropertyValue) MarshalYAML() (interface{}, error) {
+	if len(p) == 1 {
+		return p[0], nil
+	}
+	return []string(p), nil
+}
+
+// ValidateCustomPropertyDefinitions checks the organization custom property schema.
+func ValidateCustomPropertyDefinitions(defs []*CustomPropertyDefinition) error {
+	seen := make(map[string]bool)
+	for i, def := range defs {
+		field := fmt.Sprintf("custom_properties[%d]", i)
+		if def == nil {
+			return NewConfigValidationError(field, nil, "custom property cannot be nil", nil)
+		}
+		name := strings.TrimSpace(def.Name)
+		if name == "" {
+			return NewConfigValidationError(field+".name", def.Name, "custom property name cannot be empty", nil)
+		}
+		if seen[name] {
+			return NewConfigValidationError(field+".name", name, "duplicate custom property name", nil)
+		}
+		seen[name] = true
+
+		switch def.ValueType {
+		case PropertyTypeString, PropertyTypeTrueFalse:
+		case PropertyTypeSingleSelect, PropertyTypeMultiSelect:
+			if len(def.AllowedValues) == 0 {
+				return NewConfigValidationError(field+".allowed_values", def.AllowedValues,
+					fmt.Sprintf("%s properties require allowed_values", def.ValueType), nil)
+			}
+		default:
+			return NewConfigValidationError(field+".value_type", def.ValueType,
+				fmt.Sprintf("value_type must be one of %s, %s, %s, %s",
+					PropertyTypeString, PropertyTypeSingleSelect, PropertyTypeMultiSelect, PropertyTypeTrueFalse), nil)
+		}
+		if def.DefaultValue != nil && len(def.AllowedValues) > 0 && !containsString(def.AllowedValues, *def.DefaultValue) {
+			return NewConfigValidationError(field+".default_value", *def.DefaultValue,
+				"default value is not in allowed_values", nil)
+		}
+	}
+	return nil
+}
+
+// ValidateRepositoryProperties checks repository property values against any declared schema.
+// Properties without a local definition are accepted as they may already exist in the organization.
+func ValidateRepositoryProperties(field string, values map[string]PropertyValue, defs []*CustomPropertyDefinition) error {
+	for name, value := range values {
+		def := findPropertyDefinition(defs, name)
+		if def == nil {
+			continue
+		}
+		if len(value) > 1 && def.ValueType != PropertyTypeMultiSelect {
+			return NewConfigValidationError(fmt.Sprintf("%s.%s", field, name), []string(value),
+				fmt.Sprintf("%s property accepts a single value", def.ValueType), nil)
+		}
+		if def.ValueType == PropertyTypeTrueFalse && len(value) == 1 && value[0] != "true" && value[0] != "false" {
+			return NewConfigValidationError(fmt.Sprintf("%s.%s", field, name), value[0],
+				"true_false property must be true or false", nil)
+		}
+		if len(def.AllowedValues) == 0 {
+			continue
+		}
+		for _, v := range value {
+			if !containsString(def.AllowedValues, v) {
+				return NewConfigValidationError(fmt.Sprintf("%s.%s", field, name), v,
+					fmt.Sprintf("value not in allowed_values %v", def.AllowedValues), nil)
+			}
+		}
+	}
+	return nil
+}
+
+func findPropertyDefinition(defs []*CustomPropertyDefinition, name string) *CustomPropertyDefinition {
+	for _, def := range defs {
+		if def != nil && def.Name == name {
+			return def
+		}
+	}
+	return nil
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// toGitHubCustomProperty converts a definition to the go-github schema type.
+func (d *CustomPropertyDefinition) toGitHubCustomProperty() *github.CustomProperty {
+	return &github.CustomProperty{
+		PropertyName:     github.String(d.Name),
+		ValueType:        d.ValueType,
+		Required:         d.Required,
+		DefaultValue:     d.DefaultValue,
+		Description:      d.Description,
+		AllowedValues:    d.AllowedValues,
+		ValuesEditableBy: d.ValuesEditableBy,
+	}
+}
+
+// buildCustomPropertyValues converts repository properties into API values, sorted by name.
+// multi_select properties are always sent as lists; everything else as a single string.
+func buildCustomPropertyValues(values map[string]PropertyValue, defs []*CustomPropertyDefinition) []*github.CustomPropertyValue {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	result := make([]*github.CustomPropertyValue, 0, len(names))
+	for _, name := range names {
+		value := values[name]
+		cpv := &github.CustomPropertyValue{PropertyName: name}
+		def := findPropertyDefinition(defs, name)
+		switch {
+		case len(value) == 0:
+			cpv.Value = nil
+		case len(value) > 1 || (def != nil && def.ValueType == PropertyTypeMultiSelect):
+			cpv.Value = []string(value)
+		default:
+			cpv.Value = value[0]
+		}
+		result = append(result, cpv)
+	}
+	return result
+}
+
+// fromGitHubPropertyValues converts API values into repository properties, skipping unset values.
+func fromGitHubPropertyValues(values []*github.CustomPropertyValue) map[string]PropertyValue {
+	result := make(map[string]PropertyValue)
+	for _, v := range values {
+		if v == nil {
+			continue
+		}
+		switch val := v.Value.(type) {
+		case string:
+			result[v.PropertyName] = PropertyValue{val}
+		case []string:
+			result[v.PropertyName] = PropertyValue(val)
+		}
+	}
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
+
+// ApplyCustomPropertySchema creates or updates the organization's custom property definitions.
+// If dryRun is true, it logs the definitions that would be written.
+func ApplyCustomPropertySchema(settings *PermissionsSettings, client *GitHubClient, dryRun bool) error {
+	if len(settings.CustomProperties) == 0 {
+		return nil
+	}
+	if dryRun {
+		for _, def := range settings.CustomProperties {
+			log.Info().
+				Str("organization", *settings.Organization).
+				Str("property", def.Name).
+				Str("value_type", def.ValueType).
+				Strs("allowed_values", def.AllowedValues).
+				Msg("Would define custom property")
+		}
+		return nil
+	}
+	if err := client.SetCustomPropertySchema(*settings.Organization, settings.CustomProperties); err != nil {
+		log.Err(err).
+			Str("organization", *settings.Organization).
+			Msg("defining custom properties")
+		return err
+	}
+	return nil
+}
+
+// SetCustomPropertySchema creates or updates the given custom property definitions for an organization.
+func (c *GitHubClient) SetCustomPropertySchema(org string, defs []*CustomPropertyDefinition) error {
+	if !c.supportsSetting(FeatureCustomProperties, "custom_properties", org) {
+		return nil
+	}
+	properties := make([]*github.CustomProperty, 0, len(defs))
+	for _, def := range defs {
+		properties = append(properties, def.toGitHubCustomProperty())
+	}
+	_, resp, err := c.Organizations.CreateOrUpdateCustomProperties(c.Context, org, properties)
+	if err != nil {
+		return classifyAPIError(responseStatus(resp), "update custom property schema", org,
+			"failed to define custom properties", err)
+	}
+	log.Info().
+		Str("organization", org).
+		Int("count", len(properties)).
+		Msg("Successfully defined custom properties")
+	return nil
+}
+
+func applyCustomProperties(settings *PermissionsSettings, repo *Repository, client *GitHubClient, dryRun bool) error {
+	if len(repo.Properties) == 0 {
+		return nil
+	}
+	values := buildCustomPropertyValues(repo.Properties, settings.CustomProperties)
+	if dryRun {
+		for _, v := range values {
+			log.Info().
+				Str("repository", *repo.Name).
+				Str("property", v.PropertyName).
+				Interface("value", v.Value).
+				Msg("Would set custom property")
+		}
+		return nil
+	}
+	if err := client.SetRepositoryCustomProperties(*settings.Organization, *repo.Name, values); err != nil {
+		log.Err(err).
+			Str("repository", *repo.Name).
+			Str("organization", *settings.Organization).
+			Msg("setting custom property values")
+		return err
+	}
+	return nil
+}
+
+// SetRepositoryCustomProperties creates or updates custom property values for a repository.
+func (c *GitHubClient) SetRepositoryCustomProperties(org, repo string, values []*github.CustomPropertyValue) error {
+	if !c.supportsSetting(FeatureCustomProperties, "properties", fmt.Sprintf("%s/%s", org, repo)) {
+		return nil
+	}
+	resp, err := c.Repositories.CreateOrUpdateCustomProperties(c.Context, org, repo, values)
+	if err != nil {
+		return classifyAPIError(responseStatus(resp), "update custom property values", fmt.Sprintf("%s/%s", org, repo),
+			"failed to set custom property values", err)
+	}
+	log.Info().
+		Str("repository", fmt.Sprintf("%s/%s", org, repo)).
+		Int("count", len(values)).
+		Msg("Successfully set custom property values")
+	return nil
+}
+
+// getCustomPropertyValues retrieves the custom property values of a repository for import.
+// Failures are logged and result in no properties, as not every token can read them.
+func getCustomPropertyValues(client *GitHubClient, owner, repo string) map[string]PropertyValue {
+	values, _, err := client.Repositories.GetAllCustomPropertyValues(client.Context, owner, repo)
+	if err != nil {
+		log.Warn().Err(err).Str("repo", repo).Msg("unable to read custom property values")
+		return nil
+	}
+	return fromGitHubPropertyValues(values)
+}
+
+// ListRepositoryPropertyValues returns the custom property values of every repository in the
+// organization, keyed by repository name.
+func (c *GitHubClient) ListRepositoryPropertyValues(org string) (map[string]map[string]PropertyValue, error) {
+	result := make(map[string]map[string]PropertyValue)
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		page, resp, err := c.Organizations.ListCustomPropertyValues(c.Context, org, opts)
+		if err != nil {
+			return nil, classifyAPIError(responseStatus(resp), "list custom property values", org,
+				"failed to list repository custom property values", err)
+		}
+		for _, r := range page {
+			result[r.RepositoryName] = fromGitHubPropertyValues(r.Properties)
+		}
+		if resp == nil || resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return result, nil
+}
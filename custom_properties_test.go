@@ -0,0 +1,133 @@
+package ownershit
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/go-github/v66/github"
+	"go.uber.org/mock/gomock"
+	"gopkg.in/yaml.v3"
+)
+
+func TestPropertyValueYAML(t *testing.T) {
+	var repo Repository
+	input := `name: svc
+properties:
+  tier: critical
+  regions: [us, eu]
+`
+	if err := yaml.Unmarshal([]byte(input), &repo); err != nil {
+		t.Fatalf("yaml.Unmarshal() error = %v", err)
+	}
+	if !reflect.DeepEqual(repo.Properties["tier"], PropertyValue{"critical"}) {
+		t.Errorf("tier = %v", repo.Properties["tier"])
+	}
+	if !reflect.DeepEqual(repo.Properties["regions"], PropertyValue{"us", "eu"}) {
+		t.Errorf("regions = %v", repo.Properties["regions"])
+	}
+
+	out, err := yaml.Marshal(map[string]PropertyValue{"tier": {"critical"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != "tier: critical\n" {
+		t.Errorf("yaml.Marshal() = %q", out)
+	}
+
+	if err := yaml.Unmarshal([]byte("properties:\n  tier: {a: b}\n"), &repo); err == nil {
+		t.Error("expected error for mapping property value")
+	}
+}
+
+func TestValidateCustomPropertyDefinitions(t *testing.T) {
+	tests := []struct {
+		name    string
+		defs    []*CustomPropertyDefinition
+		wantErr bool
+	}{
+		{name: "valid", defs: []*CustomPropertyDefinition{
+			{Name: "tier", ValueType: PropertyTypeSingleSelect, AllowedValues: []string{"critical", "standard"}, DefaultValue: stringPtr("standard")},
+			{Name: "owner-team", ValueType: PropertyTypeString},
+		}},
+		{name: "empty name", defs: []*CustomPropertyDefinition{{ValueType: PropertyTypeString}}, wantErr: true},
+		{name: "bad type", defs: []*CustomPropertyDefinition{{Name: "x", ValueType: "number"}}, wantErr: true},
+		{name: "select without values", defs: []*CustomPropertyDefinition{{Name: "x", ValueType: PropertyTypeSingleSelect}}, wantErr: true},
+		{name: "default not allowed", defs: []*CustomPropertyDefinition{
+			{Name: "x", ValueType: PropertyTypeSingleSelect, AllowedValues: []string{"a"}, DefaultValue: stringPtr("b")},
+		}, wantErr: true},
+		{name: "duplicate", defs: []*CustomPropertyDefinition{
+			{Name: "x", ValueType: PropertyTypeString}, {Name: "x", ValueType: PropertyTypeString},
+		}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateCustomPropertyDefinitions(tt.defs); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateCustomPropertyDefinitions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateRepositoryProperties(t *testing.T) {
+	defs := []*CustomPropertyDefinition{
+		{Name: "tier", ValueType: PropertyTypeSingleSelect, AllowedValues: []string{"critical", "standard"}},
+		{Name: "public", ValueType: PropertyTypeTrueFalse},
+	}
+	tests := []struct {
+		name    string
+		values  map[string]PropertyValue
+		wantErr bool
+	}{
+		{name: "valid", values: map[string]PropertyValue{"tier": {"critical"}, "public": {"true"}, "undeclared": {"x"}}},
+		{name: "not allowed", values: map[string]PropertyValue{"tier": {"gold"}}, wantErr: true},
+		{name: "multiple for single select", values: map[string]PropertyValue{"tier": {"critical", "standard"}}, wantErr: true},
+		{name: "bad boolean", values: map[string]PropertyValue{"public": {"yes"}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateRepositoryProperties("properties", tt.values, defs); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateRepositoryProperties() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBuildCustomPropertyValues(t *testing.T) {
+	defs := []*CustomPropertyDefinition{{Name: "langs", ValueType: PropertyTypeMultiSelect, AllowedValues: []string{"go"}}}
+	got := buildCustomPropertyValues(map[string]PropertyValue{
+		"tier":  {"critical"},
+		"langs": {"go"},
+	}, defs)
+	want := []*github.CustomPropertyValue{
+		{PropertyName: "langs", Value: []string{"go"}},
+		{PropertyName: "tier", Value: "critical"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("buildCustomPropertyValues() = %v, want %v", got, want)
+	}
+}
+
+func TestSetCustomPropertySchema(t *testing.T) {
+	m := setupMocks(t)
+	m.orgsMock.EXPECT().CreateOrUpdateCustomProperties(gomock.Any(), "org", []*github.CustomProperty{{
+		PropertyName:  github.String("tier"),
+		ValueType:     PropertyTypeSingleSelect,
+		AllowedValues: []string{"critical"},
+	}}).Return(nil, defaultGoodResponse, nil)
+
+	err := m.client.SetCustomPropertySchema("org", []*CustomPropertyDefinition{
+		{Name: "tier", ValueType: PropertyTypeSingleSelect, AllowedValues: []string{"critical"}},
+	})
+	if err != nil {
+		t.Fatalf("SetCustomPropertySchema() error = %v", err)
+	}
+}
+
+func TestSetRepositoryCustomPropertiesError(t *testing.T) {
+	m := setupMocks(t)
+	m.repoMock.EXPECT().CreateOrUpdateCustomProperties(gomock.Any(), "org", "repo", gomock.Any()).
+		Return(nil, ErrDummyV3Error)
+	if err := m.client.SetRepositoryCustomProperties("org", "repo", nil); err == nil {
+		t.Fatal("expected error")
+	}
+}
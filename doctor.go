@@ -0,0 +1,566 @@
+package ownershit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/google/go-github/v66/github"
+)
+
+// Token kinds reported by TokenKind.
+const (
+	TokenKindClassic     = "classic"
+	TokenKindFineGrained = "fine-grained"
+	TokenKindApp         = "app"
+	TokenKindOAuth       = "oauth"
+	TokenKindUnknown     = "unknown"
+)
+
+// Repository access levels, from least to most privileged.
+const (
+	AccessNone  = "none"
+	AccessRead  = "read"
+	AccessWrite = "write"
+	AccessAdmin = "admin"
+)
+
+var accessRank = map[string]int{AccessNone: 0, AccessRead: 1, AccessWrite: 2, AccessAdmin: 3}
+
+// TokenRequirement is what one ownershit operation needs from the token.
+type TokenRequirement struct {
+	Operation string
+	// Commands are the CLI commands performing the operation.
+	Commands []string
+	// Scopes are the classic token scopes the operation needs.
+	Scopes []string
+	// FineGrained are the fine-grained token permissions the operation needs.
+	FineGrained []string
+	// RepositoryAccess is the access needed to each configured repository, empty for none.
+	RepositoryAccess string
+	// OrganizationAdmin is true when the operation needs the organization owner role.
+	OrganizationAdmin bool
+	// apiOperations are the operation names carried by errors from the operation's API calls.
+	apiOperations []string
+}
+
+// tokenRequirements lists every operation that needs token permissions.
+var tokenRequirements = []TokenRequirement{
+	{
+		Operation: "Sync repositories", Commands: []string{"sync"},
+		Scopes:           []string{"repo", "admin:org"},
+		FineGrained:      []string{"Repository Administration: Write", "Organization Members: Read"},
+		RepositoryAccess: AccessAdmin,
+		apiOperations: []string{
+			"update repository settings", "set advanced repository settings", "update repository",
+			"get repository states",
+		},
+	},
+	{
+		Operation: "Import repository config", Commands: []string{"import", "import-csv"},
+		Scopes:           []string{"repo", "read:org"},
+		FineGrained:      []string{"Repository Metadata: Read", "Repository Administration: Read"},
+		RepositoryAccess: AccessRead,
+		apiOperations:    []string{"get repository", "list teams", "list labels", "list repositories"},
+	},
+	{
+		Operation: "Manage branch protection", Commands: []string{"sync", "branches"},
+		Scopes:           []string{"repo"},
+		FineGrained:      []string{"Repository Administration: Write"},
+		RepositoryAccess: AccessAdmin,
+		apiOperations:    []string{"create branch protection rule", "update branch protection"},
+	},
+	{
+		Operation: "Archive repositories", Commands: []string{"archive"},
+		Scopes:           []string{"repo", "admin:org"},
+		FineGrained:      []string{"Repository Administration: Write"},
+		RepositoryAccess: AccessAdmin,
+		apiOperations:    []string{"query archivable repositories", "archive repository"},
+	},
+	{
+		Operation: "Manage teams", Commands: []string{"sync"},
+		Scopes:            []string{"admin:org"},
+		FineGrained:       []string{"Organization Members: Write"},
+		OrganizationAdmin: true,
+		apiOperations:     []string{"add team permissions"},
+	},
+	{
+		Operation: "Create teams and manage membership", Commands: []string{"sync"},
+		Scopes:            []string{"admin:org"},
+		FineGrained:       []string{"Organization Members: Write"},
+		OrganizationAdmin: true,
+		apiOperations:     []string{"get team", "create team", "edit team", "list team members", "add team membership", "remove team membership"},
+	},
+	{
+		Operation: "Manage organization settings", Commands: []string{"sync"},
+		Scopes:            []string{"admin:org"},
+		FineGrained:       []string{"Organization Administration: Write"},
+		OrganizationAdmin: true,
+		apiOperations:     []string{"get organization", "edit organization"},
+	},
+	{
+		Operation: "Sync labels", Commands: []string{"label"},
+		Scopes:           []string{"repo"},
+		FineGrained:      []string{"Repository Issues: Write"},
+		RepositoryAccess: AccessWrite,
+		apiOperations:    []string{"create label", "edit label", "list repository labels"},
+	},
+	{
+		Operation: "Sync topics", Commands: []string{"topics"},
+		Scopes:           []string{"repo"},
+		FineGrained:      []string{"Repository Administration: Write"},
+		RepositoryAccess: AccessAdmin,
+		apiOperations:    []string{"list topics", "replace topics"},
+	},
+	{
+		Operation: "Sync template files", Commands: []string{"files"},
+		Scopes:           []string{"repo"},
+		FineGrained:      []string{"Repository Contents: Write", "Repository Pull requests: Write"},
+		RepositoryAccess: AccessWrite,
+		apiOperations:    []string{"get ref", "create ref", "create pull request", "get contents", "create file", "update file"},
+	},
+	{
+		Operation: "Manage security features", Commands: []string{"sync"},
+		Scopes: []string{"repo", "security_events"},
+		FineGrained: []string{
+			"Repository Administration: Write", "Repository Secret scanning alerts: Read",
+			"Repository Dependabot alerts: Read",
+		},
+		RepositoryAccess: AccessAdmin,
+		apiOperations: []string{
+			"set vulnerability alerts", "set security and analysis", "set dependabot security updates",
+			"set private vulnerability reporting",
+		},
+	},
+	{
+		Operation: "Manage autolinks", Commands: []string{"sync"},
+		Scopes:           []string{"repo"},
+		FineGrained:      []string{"Repository Administration: Write"},
+		RepositoryAccess: AccessAdmin,
+		apiOperations:    []string{"list autolinks", "delete autolink", "create autolink"},
+	},
+	{
+		Operation: "Define custom properties", Commands: []string{"sync"},
+		Scopes:            []string{"admin:org"},
+		FineGrained:       []string{"Organization Custom properties: Admin"},
+		OrganizationAdmin: true,
+		apiOperations:     []string{"update custom property schema"},
+	},
+	{
+		Operation: "Set custom property values", Commands: []string{"sync"},
+		Scopes:           []string{"repo"},
+		FineGrained:      []string{"Repository Custom properties: Write"},
+		RepositoryAccess: AccessWrite,
+		apiOperations:    []string{"update custom property values", "list custom property values"},
+	},
+}
+
+// TokenRequirements returns the permissions needed by each ownershit operation.
+func TokenRequirements() []TokenRequirement {
+	requirements := make([]TokenRequirement, len(tokenRequirements))
+	copy(requirements, tokenRequirements)
+	return requirements
+}
+
+// impliedScopes lists the classic scopes granted by a broader scope.
+var impliedScopes = map[string][]string{
+	"repo":      {"public_repo", "repo:status", "repo_deployment", "repo:invite", "security_events"},
+	"admin:org": {"write:org", "read:org"},
+	"write:org": {"read:org"},
+	"user":      {"read:user", "user:email", "user:follow"},
+}
+
+// scopeGranted reports whether scope is one of granted or implied by one of them.
+func scopeGranted(granted []string, scope string) bool {
+	for _, g := range granted {
+		if g == scope {
+			return true
+		}
+		for _, implied := range impliedScopes[g] {
+			if implied == scope {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// parseScopes splits an X-OAuth-Scopes header into scopes.
+func parseScopes(header string) []string {
+	scopes := []string{}
+	for _, s := range strings.Split(header, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			scopes = append(scopes, s)
+		}
+	}
+	return scopes
+}
+
+// TokenKind returns the kind of token from its prefix.
+func TokenKind(token string) string {
+	switch {
+	case strings.HasPrefix(token, "ghp_"):
+		return TokenKindClassic
+	case strings.HasPrefix(token, "github_pat_"):
+		return TokenKindFineGrained
+	case strings.HasPrefix(token, "ghs_"):
+		return TokenKindApp
+	case strings.HasPrefix(token, "gho_"), strings.HasPrefix(token, "ghu_"):
+		return TokenKindOAuth
+	}
+	return TokenKindUnknown
+}
+
+// TokenKind returns the kind of token the client authenticates with.
+func (c *GitHubClient) TokenKind() string {
+	if c.tokenKind == "" {
+		return TokenKindUnknown
+	}
+	return c.tokenKind
+}
+
+// DoctorStatus is the outcome of a doctor check.
+type DoctorStatus string
+
+// Doctor check outcomes.
+const (
+	DoctorOK   DoctorStatus = "ok"
+	DoctorWarn DoctorStatus = "warn"
+	DoctorFail DoctorStatus = "fail"
+)
+
+var doctorStatusRank = map[DoctorStatus]int{DoctorOK: 0, DoctorWarn: 1, DoctorFail: 2}
+
+// DoctorCheck is the result of one check of the token or its access.
+type DoctorCheck struct {
+	Name   string       `json:"name"`
+	Status DoctorStatus `json:"status"`
+	Detail string       `json:"detail,omitempty"`
+}
+
+// CommandDiagnosis reports whether a command would succeed with the current token, and why not.
+type CommandDiagnosis struct {
+	Command string       `json:"command"`
+	Status  DoctorStatus `json:"status"`
+	Reasons []string     `json:"reasons,omitempty"`
+}
+
+// DoctorReport is the diagnosis of the token for one organization.
+type DoctorReport struct {
+	Organization string `json:"organization,omitempty"`
+	Login        string `json:"login,omitempty"`
+	TokenKind    string `json:"token_kind"`
+	// Scopes are the classic scopes granted; nil when the token does not report scopes.
+	Scopes []string `json:"scopes"`
+	// TokenExpiration is the expiry GitHub reports for the token, if any.
+	TokenExpiration string `json:"token_expiration,omitempty"`
+	// OrganizationRole is the token owner's role in the organization, empty when unknown.
+	OrganizationRole string `json:"organization_role,omitempty"`
+	// RepositoryAccess maps each checked repository to the token's access to it.
+	RepositoryAccess map[string]string  `json:"repository_access,omitempty"`
+	Checks           []DoctorCheck      `json:"checks"`
+	Commands         []CommandDiagnosis `json:"commands"`
+
+	reachable bool
+	validated bool
+	// tokenUnavailable records that no token could be obtained, so GitHub was never contacted.
+	tokenUnavailable bool
+}
+
+// Failed reports whether any check failed or any command would fail.
+func (r *DoctorReport) Failed() bool {
+	for _, check := range r.Checks {
+		if check.Status == DoctorFail {
+			return true
+		}
+	}
+	for _, command := range r.Commands {
+		if command.Status == DoctorFail {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *DoctorReport) add(name string, status DoctorStatus, format string, args ...interface{}) {
+	r.Checks = append(r.Checks, DoctorCheck{Name: name, Status: status, Detail: fmt.Sprintf(format, args...)})
+}
+
+// AddCheck records the outcome of a check made outside Diagnose.
+func (r *DoctorReport) AddCheck(name string, status DoctorStatus, detail string) {
+	r.add(name, status, "%s", detail)
+}
+
+// DiagnoseTokenError returns the report for org when no client could be created for it, for
+// example because its token is unset or malformed. err is reported as a failed token check.
+func DiagnoseTokenError(org string, err error) *DoctorReport {
+	report := &DoctorReport{Organization: org, tokenUnavailable: true}
+	report.add("token", DoctorFail, "%v", err)
+	report.Commands = diagnoseCommands(report)
+	return report
+}
+
+// Diagnose checks that GitHub is reachable, that the token is accepted, which scopes it grants,
+// the token owner's role in org and its access to each of repos, then reports which commands
+// would fail and why. An empty org checks only the token.
+func (c *GitHubClient) Diagnose(org string, repos []string) *DoctorReport {
+	report := &DoctorReport{Organization: org, TokenKind: c.TokenKind()}
+	c.diagnoseToken(report)
+	if report.validated && org != "" {
+		c.diagnoseOrganization(report, org)
+		c.diagnoseRepositories(report, org, repos)
+	}
+	report.Commands = diagnoseCommands(report)
+	return report
+}
+
+func (c *GitHubClient) diagnoseToken(report *DoctorReport) {
+	ctx := c.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	req, err := c.v3.NewRequest(http.MethodGet, "rate_limit", nil)
+	if err != nil {
+		report.add("connectivity", DoctorFail, "building request: %v", err)
+		return
+	}
+	resp, err := c.v3.Do(ctx, req, nil)
+	if resp == nil || resp.Response == nil {
+		report.add("connectivity", DoctorFail, "cannot reach %s: %v", c.v3.BaseURL, err)
+		return
+	}
+	report.reachable = true
+	report.add("connectivity", DoctorOK, "reached %s", c.v3.BaseURL)
+	if resp.StatusCode == http.StatusUnauthorized {
+		report.add("token", DoctorFail, "GitHub rejected the token (401); it may be expired or revoked")
+		return
+	}
+	if err != nil {
+		report.add("token", DoctorFail, "checking the token: %v", err)
+		return
+	}
+	report.validated = true
+	report.TokenExpiration = resp.Header.Get("GitHub-Authentication-Token-Expiration")
+	if _, ok := resp.Header[http.CanonicalHeaderKey("X-OAuth-Scopes")]; ok {
+		report.Scopes = parseScopes(resp.Header.Get("X-OAuth-Scopes"))
+	}
+
+	if report.TokenKind == TokenKindApp {
+		report.add("token", DoctorOK, "GitHub App installation token; permissions are set by the app")
+		return
+	}
+	user, _, err := c.v3.Users.Get(ctx, "")
+	if err != nil {
+		report.add("token", DoctorWarn, "token accepted but the authenticated user could not be read: %v", err)
+		return
+	}
+	report.Login = user.GetLogin()
+	detail := fmt.Sprintf("authenticated as %s with a %s token", report.Login, report.TokenKind)
+	if report.TokenExpiration != "" {
+		detail += ", expiring " + report.TokenExpiration
+	}
+	report.add("token", DoctorOK, "%s", detail)
+	switch {
+	case report.Scopes != nil:
+		report.add("scopes", DoctorOK, "granted scopes: %s", strings.Join(report.Scopes, ", "))
+	default:
+		report.add("scopes", DoctorWarn, "token does not report scopes; fine-grained permissions are checked through repository access only")
+	}
+}
+
+func (c *GitHubClient) diagnoseOrganization(report *DoctorReport, org string) {
+	if report.TokenKind == TokenKindApp {
+		report.add("organization "+org, DoctorOK, "app is installed in %s", org)
+		return
+	}
+	membership, resp, err := c.v3.Organizations.GetOrgMembership(c.Context, "", org)
+	switch {
+	case err == nil && membership.GetState() != "active":
+		report.add("organization "+org, DoctorFail, "membership in %s is %s", org, membership.GetState())
+	case err == nil:
+		report.OrganizationRole = membership.GetRole()
+		status := DoctorOK
+		if report.OrganizationRole != "admin" {
+			status = DoctorWarn
+		}
+		report.add("organization "+org, status, "%s is an organization %s", report.Login, report.OrganizationRole)
+	case responseStatus(resp) == http.StatusNotFound:
+		report.OrganizationRole = AccessNone
+		report.add("organization "+org, DoctorFail, "%s is not a member of %s", report.Login, org)
+	default:
+		report.add("organization "+org, DoctorWarn, "membership in %s could not be read: %v", org, err)
+	}
+}
+
+func (c *GitHubClient) diagnoseRepositories(report *DoctorReport, org string, repos []string) {
+	if len(repos) == 0 {
+		return
+	}
+	report.RepositoryAccess = make(map[string]string)
+	for _, name := range repos {
+		fullName := org + "/" + name
+		repo, resp, err := c.Repositories.Get(c.Context, org, name)
+		if err != nil {
+			if responseStatus(resp) == http.StatusNotFound {
+				report.RepositoryAccess[fullName] = AccessNone
+				report.add("repository "+fullName, DoctorFail, "not found or not visible to the token")
+			} else {
+				report.add("repository "+fullName, DoctorWarn, "could not be read: %v", err)
+			}
+			continue
+		}
+		access := repositoryAccess(repo)
+		report.RepositoryAccess[fullName] = access
+		status := DoctorOK
+		if access != AccessAdmin {
+			status = DoctorWarn
+		}
+		report.add("repository "+fullName, status, "%s access", access)
+	}
+}
+
+// repositoryAccess maps the token's repository permissions to an access level.
+func repositoryAccess(repo *github.Repository) string {
+	perms := repo.GetPermissions()
+	switch {
+	case perms["admin"]:
+		return AccessAdmin
+	case perms["maintain"], perms["push"]:
+		return AccessWrite
+	case perms["triage"], perms["pull"]:
+		return AccessRead
+	}
+	return AccessNone
+}
+
+// diagnoseCommands evaluates every requirement against the report and groups the results by
+// command.
+func diagnoseCommands(report *DoctorReport) []CommandDiagnosis {
+	var order []string
+	byCommand := make(map[string]*CommandDiagnosis)
+	for _, req := range tokenRequirements {
+		status, reasons := evaluateRequirement(report, req)
+		for _, command := range req.Commands {
+			diagnosis, ok := byCommand[command]
+			if !ok {
+				diagnosis = &CommandDiagnosis{Command: command, Status: DoctorOK}
+				byCommand[command] = diagnosis
+				order = append(order, command)
+			}
+			if doctorStatusRank[status] > doctorStatusRank[diagnosis.Status] {
+				diagnosis.Status = status
+			}
+			for _, reason := range reasons {
+				diagnosis.Reasons = appendUnique(diagnosis.Reasons, reason)
+			}
+		}
+	}
+	diagnoses := make([]CommandDiagnosis, 0, len(order))
+	for _, command := range order {
+		diagnoses = append(diagnoses, *byCommand[command])
+	}
+	return diagnoses
+}
+
+func evaluateRequirement(report *DoctorReport, req TokenRequirement) (DoctorStatus, []string) {
+	switch {
+	case report.tokenUnavailable:
+		return DoctorFail, []string{"no usable token"}
+	case !report.reachable:
+		return DoctorFail, []string{"GitHub is unreachable"}
+	case !report.validated:
+		return DoctorFail, []string{"the token is not accepted"}
+	}
+	var reasons []string
+	for _, scope := range req.Scopes {
+		if report.Scopes != nil && !scopeGranted(report.Scopes, scope) {
+			reasons = append(reasons, fmt.Sprintf("%s: token lacks the %s scope", req.Operation, scope))
+		}
+	}
+	if req.OrganizationAdmin && report.OrganizationRole != "" && report.OrganizationRole != "admin" {
+		reasons = append(reasons, fmt.Sprintf("%s: %s is not an owner of %s", req.Operation, report.Login, report.Organization))
+	}
+	if req.RepositoryAccess != "" {
+		var lacking []string
+		for repo, access := range report.RepositoryAccess {
+			if accessRank[access] < accessRank[req.RepositoryAccess] {
+				lacking = append(lacking, repo)
+			}
+		}
+		if len(lacking) > 0 {
+			sort.Strings(lacking)
+			reasons = append(reasons, fmt.Sprintf("%s: needs %s access to %s", req.Operation, req.RepositoryAccess, strings.Join(lacking, ", ")))
+		}
+	}
+	if len(reasons) > 0 {
+		return DoctorFail, reasons
+	}
+	if report.Scopes == nil && report.TokenKind != TokenKindApp && len(req.FineGrained) > 0 {
+		return DoctorWarn, []string{fmt.Sprintf("%s: cannot verify; grant %s", req.Operation, strings.Join(req.FineGrained, ", "))}
+	}
+	return DoctorOK, nil
+}
+
+func appendUnique(list []string, value string) []string {
+	for _, v := range list {
+		if v == value {
+			return list
+		}
+	}
+	return append(list, value)
+}
+
+// WriteText writes the report as a checklist followed by a table of commands.
+func (r *DoctorReport) WriteText(w io.Writer) error {
+	if r.Organization != "" {
+		if _, err := fmt.Fprintf(w, "Organization: %s\n", r.Organization); err != nil {
+			return err
+		}
+	}
+	for _, check := range r.Checks {
+		if _, err := fmt.Fprintf(w, "%s %s: %s\n", doctorSymbol(check.Status), check.Name, check.Detail); err != nil {
+			return err
+		}
+	}
+	if _, err := fmt.Fprintln(w); err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "COMMAND\tSTATUS\tREASON")
+	for _, command := range r.Commands {
+		if len(command.Reasons) == 0 {
+			fmt.Fprintf(tw, "%s\t%s\t\n", command.Command, command.Status)
+			continue
+		}
+		for i, reason := range command.Reasons {
+			if i == 0 {
+				fmt.Fprintf(tw, "%s\t%s\t%s\n", command.Command, command.Status, reason)
+			} else {
+				fmt.Fprintf(tw, "\t\t%s\n", reason)
+			}
+		}
+	}
+	return tw.Flush()
+}
+
+// WriteJSON writes the report as indented JSON.
+func (r *DoctorReport) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+func doctorSymbol(status DoctorStatus) string {
+	switch status {
+	case DoctorOK:
+		return "✅"
+	case DoctorWarn:
+		return "⚠️ "
+	}
+	return "❌"
+}
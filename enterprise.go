@@ -0,0 +1,225 @@
+package ownershit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// DefaultGitHubAPIURL is the REST API root of github.com.
+	DefaultGitHubAPIURL = "https://api.github.com/"
+	// DefaultGitHubGraphQLURL is the GraphQL endpoint of github.com.
+	DefaultGitHubGraphQLURL = "https://api.github.com/graphql"
+	// EnvGitHubAPIURL is the environment variable holding the GitHub base URL.
+	EnvGitHubAPIURL = "GITHUB_API_URL"
+)
+
+// ErrInvalidGitHubURL is returned when a GitHub base URL cannot be used.
+var ErrInvalidGitHubURL = errors.New("invalid GitHub URL")
+
+// GitHubEndpoints holds the API endpoints of a GitHub instance.
+type GitHubEndpoints struct {
+	// Host is the web host name, used to look up stored credentials.
+	Host    string
+	REST    string
+	Upload  string
+	GraphQL string
+	// Enterprise is true for GitHub Enterprise Server, whose features depend on its version.
+	Enterprise bool
+}
+
+// ResolveGitHubEndpoints returns the endpoints for baseURL, which may name a GitHub Enterprise
+// Server host (https://github.example.com) or either of its API roots (/api/v3 or /api/graphql).
+// An empty baseURL, github.com and api.github.com resolve to the github.com endpoints.
+func ResolveGitHubEndpoints(baseURL string) (GitHubEndpoints, error) {
+	baseURL = strings.TrimSpace(baseURL)
+	if baseURL == "" {
+		return defaultGitHubEndpoints(), nil
+	}
+	if !strings.Contains(baseURL, "://") {
+		baseURL = "https://" + baseURL
+	}
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return GitHubEndpoints{}, fmt.Errorf("%w %q: %w", ErrInvalidGitHubURL, baseURL, err)
+	}
+	if u.Scheme != "https" && u.Scheme != "http" {
+		return GitHubEndpoints{}, fmt.Errorf("%w %q: scheme must be http or https", ErrInvalidGitHubURL, baseURL)
+	}
+	if u.Host == "" || u.RawQuery != "" || u.Fragment != "" {
+		return GitHubEndpoints{}, fmt.Errorf("%w %q: expected a host such as https://github.example.com", ErrInvalidGitHubURL, baseURL)
+	}
+	host := strings.ToLower(u.Hostname())
+	if host == "github.com" || host == "api.github.com" {
+		return defaultGitHubEndpoints(), nil
+	}
+
+	root := strings.TrimRight(u.Path, "/")
+	for _, suffix := range []string{"/api/v3", "/api/graphql", "/api/uploads", "/api"} {
+		if strings.HasSuffix(root, suffix) {
+			root = strings.TrimSuffix(root, suffix)
+			break
+		}
+	}
+	prefix := u.Scheme + "://" + u.Host + root
+	return GitHubEndpoints{
+		Host:       u.Host,
+		REST:       prefix + "/api/v3/",
+		Upload:     prefix + "/api/uploads/",
+		GraphQL:    prefix + "/api/graphql",
+		Enterprise: true,
+	}, nil
+}
+
+func defaultGitHubEndpoints() GitHubEndpoints {
+	return GitHubEndpoints{
+		Host:    "github.com",
+		REST:    DefaultGitHubAPIURL,
+		Upload:  "https://uploads.github.com/",
+		GraphQL: DefaultGitHubGraphQLURL,
+	}
+}
+
+// GitHubURLFromEnv returns the GitHub base URL set in GITHUB_API_URL, if any.
+func GitHubURLFromEnv() string {
+	return os.Getenv(EnvGitHubAPIURL)
+}
+
+// ValidateGitHubURL checks the github_url setting at field.
+func ValidateGitHubURL(field string, baseURL *string) error {
+	if baseURL == nil {
+		return nil
+	}
+	if _, err := ResolveGitHubEndpoints(*baseURL); err != nil {
+		return NewConfigValidationError(field, *baseURL, "github_url must be the URL of a GitHub instance", err)
+	}
+	return nil
+}
+
+// GitHubFeature names an API feature that older GitHub Enterprise Server versions lack.
+type GitHubFeature string
+
+// Features that are skipped on GitHub Enterprise Server versions without them.
+const (
+	FeatureDiscussions                   GitHubFeature = "discussions"
+	FeatureSponsorships                  GitHubFeature = "sponsorships"
+	FeatureSecretScanningPushProtection  GitHubFeature = "secret_scanning_push_protection"
+	FeaturePrivateVulnerabilityReporting GitHubFeature = "private_vulnerability_reporting"
+	FeatureCustomProperties              GitHubFeature = "custom_properties"
+)
+
+// enterpriseFeatureVersions is the first GitHub Enterprise Server version supporting each
+// feature. An empty version means the feature is not available on GitHub Enterprise Server.
+var enterpriseFeatureVersions = map[GitHubFeature]string{
+	FeatureDiscussions:                   "3.6",
+	FeatureSponsorships:                  "",
+	FeatureSecretScanningPushProtection:  "3.5",
+	FeaturePrivateVulnerabilityReporting: "3.10",
+	FeatureCustomProperties:              "3.13",
+}
+
+// serverInfo records the detected version of a GitHub Enterprise Server instance.
+type serverInfo struct {
+	once    sync.Once
+	version string
+}
+
+// ServerVersion returns the installed GitHub Enterprise Server version, detected from the meta
+// endpoint on first use. It is empty for github.com and when the version cannot be detected.
+func (c *GitHubClient) ServerVersion() string {
+	if c.server == nil || c.v3 == nil {
+		return ""
+	}
+	c.server.once.Do(func() {
+		req, err := c.v3.NewRequest("GET", "meta", nil)
+		if err != nil {
+			log.Warn().Err(err).Msg("building GitHub Enterprise Server version request")
+			return
+		}
+		var meta struct {
+			InstalledVersion string `json:"installed_version"`
+		}
+		ctx := c.Context
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		if _, err := c.v3.Do(ctx, req, &meta); err != nil {
+			log.Warn().Err(err).Msg("detecting GitHub Enterprise Server version; assuming all features are available")
+			return
+		}
+		c.server.version = meta.InstalledVersion
+		log.Debug().Str("version", meta.InstalledVersion).Msg("detected GitHub Enterprise Server version")
+	})
+	return c.server.version
+}
+
+// Supports reports whether the GitHub instance supports feature. github.com supports every
+// feature; on GitHub Enterprise Server the installed version is compared with the first version
+// offering the feature. Features are assumed available when the version is unknown.
+func (c *GitHubClient) Supports(feature GitHubFeature) bool {
+	if c.server == nil {
+		return true
+	}
+	minimum, ok := enterpriseFeatureVersions[feature]
+	if !ok {
+		return true
+	}
+	if minimum == "" {
+		return false
+	}
+	installed := c.ServerVersion()
+	if installed == "" {
+		return true
+	}
+	return versionAtLeast(installed, minimum)
+}
+
+// supportsSetting reports whether feature is supported, logging that the named setting of target
+// is skipped when it is not.
+func (c *GitHubClient) supportsSetting(feature GitHubFeature, setting, target string) bool {
+	if c.Supports(feature) {
+		return true
+	}
+	log.Warn().
+		Str("setting", setting).
+		Str("target", target).
+		Str("serverVersion", c.ServerVersion()).
+		Msg("skipping setting not supported by this GitHub Enterprise Server version")
+	return false
+}
+
+// versionAtLeast compares dotted numeric versions such as 3.9.2, ignoring any suffix after a
+// non-numeric component.
+func versionAtLeast(version, minimum string) bool {
+	have, want := versionParts(version), versionParts(minimum)
+	for i := 0; i < len(want); i++ {
+		var part int
+		if i < len(have) {
+			part = have[i]
+		}
+		if part != want[i] {
+			return part > want[i]
+		}
+	}
+	return true
+}
+
+func versionParts(version string) []int {
+	var parts []int
+	for _, field := range strings.Split(strings.TrimPrefix(version, "v"), ".") {
+		n, err := strconv.Atoi(field)
+		if err != nil {
+			break
+		}
+		parts = append(parts, n)
+	}
+	return parts
+}
@@ -27,11 +27,17 @@ type GitHubAPIError struct {
 	Err        error
 }
 
+// Error describes the failure. Without a status code the wrapped error is the only detail of what
+// went wrong, so it is included.
 func (e *GitHubAPIError) Error() string {
+	message := e.Message
+	if e.StatusCode == 0 && e.Err != nil {
+		message = fmt.Sprintf("%s: %v", e.Message, e.Err)
+	}
 	if e.Repository != "" {
-		return fmt.Sprintf("GitHub API error [%d] for %s on %s: %s", e.StatusCode, e.Operation, e.Repository, e.Message)
+		return fmt.Sprintf("GitHub API error [%d] for %s on %s: %s", e.StatusCode, e.Operation, e.Repository, message)
 	}
-	return fmt.Sprintf("GitHub API error [%d] for %s: %s", e.StatusCode, e.Operation, e.Message)
+	return fmt.Sprintf("GitHub API error [%d] for %s: %s", e.StatusCode, e.Operation, message)
 }
 
 func (e *GitHubAPIError) Unwrap() error {
@@ -132,10 +138,16 @@ func NewRateLimitError(resetTime time.Time, remaining int, message string, err e
 type RepositoryNotFoundError struct {
 	Owner string
 	Name  string
-	Err   error
+	// Remediation, when set, describes the access needed in case the repository exists but is
+	// not visible to the token.
+	Remediation *Remediation
+	Err         error
 }
 
 func (e *RepositoryNotFoundError) Error() string {
+	if hint := e.Remediation.String(); hint != "" {
+		return fmt.Sprintf("repository %s/%s not found or not visible to the token; to fix: %s", e.Owner, e.Name, hint)
+	}
 	return fmt.Sprintf("repository %s/%s not found", e.Owner, e.Name)
 }
 
@@ -163,15 +175,23 @@ type PermissionDeniedError struct {
 	Repository string
 	Required   string
 	Message    string
-	Err        error
+	// Remediation, when set, describes how to give the token the access the operation needs.
+	Remediation *Remediation
+	Err         error
 }
 
 func (e *PermissionDeniedError) Error() string {
+	var msg string
 	if e.Required != "" {
-		return fmt.Sprintf("permission denied for %s on %s (requires %s): %s",
+		msg = fmt.Sprintf("permission denied for %s on %s (requires %s): %s",
 			e.Operation, e.Repository, e.Required, e.Message)
+	} else {
+		msg = fmt.Sprintf("permission denied for %s on %s: %s", e.Operation, e.Repository, e.Message)
+	}
+	if hint := e.Remediation.String(); hint != "" {
+		msg += "; to fix: " + hint
 	}
-	return fmt.Sprintf("permission denied for %s on %s: %s", e.Operation, e.Repository, e.Message)
+	return msg
 }
 
 func (e *PermissionDeniedError) Unwrap() error {
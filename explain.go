@@ -0,0 +1,432 @@
+package ownershit
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+)
+
+// ValueSource identifies where an effective repository setting came from.
+type ValueSource string
+
+const (
+	// SourceRepository is a value set on the repository entry itself.
+	SourceRepository ValueSource = "repository"
+	// SourceProfile is a value inherited from the repository's profile.
+	SourceProfile ValueSource = "profile"
+	// SourceOrganization is a value inherited from the repository's organizations entry.
+	SourceOrganization ValueSource = "organization"
+	// SourceDefaults is a value inherited from the defaults block.
+	SourceDefaults ValueSource = "defaults"
+	// SourceLegacyDefault is a value inherited from a deprecated default_* field.
+	SourceLegacyDefault ValueSource = "legacy default"
+	// SourceGlobal is a value inherited from a top-level block such as branches or security.
+	SourceGlobal ValueSource = "global"
+	// SourceBuiltin is a value ownershit falls back to when nothing is configured.
+	SourceBuiltin ValueSource = "built-in default"
+	// SourceUnset means nothing is configured and GitHub keeps its current value.
+	SourceUnset ValueSource = "unset"
+)
+
+// ExplainedValue is a single effective setting and where it came from.
+type ExplainedValue struct {
+	Field  string      `json:"field"`
+	Value  interface{} `json:"value"`
+	Source ValueSource `json:"source"`
+	// Detail names the profile, block or field the value was taken from.
+	Detail string `json:"detail,omitempty"`
+}
+
+// RepositoryExplanation lists the fully resolved settings of one repository.
+type RepositoryExplanation struct {
+	Repository   string           `json:"repository"`
+	Organization string           `json:"organization"`
+	Profile      string           `json:"profile,omitempty"`
+	Archived     bool             `json:"archived"`
+	Values       []ExplainedValue `json:"values"`
+}
+
+// ErrAmbiguousRepository is returned when a repository name is configured in several organizations.
+var ErrAmbiguousRepository = errors.New("repository is configured in several organizations; use org/repo")
+
+// ExplainRepository resolves the settings ownershit would apply to the named repository and
+// records, for every field, which level of the configuration supplied the value. With an
+// organizations list, name may be given as org/repo and must be when it is not unique.
+// It must be called before MigrateToNestedDefaults so legacy default_* fields can be told apart
+// from the defaults block. Repositories matched only by selectors must be expanded first.
+func ExplainRepository(settings *PermissionsSettings, name string) (*RepositoryExplanation, error) {
+	if settings == nil {
+		return nil, NewConfigValidationError("settings", nil, "settings cannot be nil", nil)
+	}
+	shared := settings
+	org, name, err := explainOrganization(settings, name)
+	if err != nil {
+		return nil, err
+	}
+	if org != nil {
+		settings = shared.forOrganization(org)
+	}
+	var repo *Repository
+	for _, candidate := range settings.Repositories {
+		if candidate != nil && strings.EqualFold(stringValue(candidate.Name), name) {
+			repo = candidate
+			break
+		}
+	}
+	if repo == nil {
+		return nil, NewConfigValidationError("repository", name,
+			"repository is not declared in the configuration (selector matches are resolved only against GitHub)", nil)
+	}
+
+	explanation := &RepositoryExplanation{
+		Repository:   stringValue(repo.Name),
+		Organization: stringValue(settings.Organization),
+		Archived:     repo.Archived != nil && *repo.Archived,
+	}
+	var profile *Profile
+	if repo.Profile != nil {
+		explanation.Profile = *repo.Profile
+		profile = settings.Profiles[*repo.Profile]
+		if profile == nil {
+			return nil, NewConfigValidationError("repository.profile", *repo.Profile, "profile is not defined", nil)
+		}
+	}
+
+	e := &explainer{repo: repo, profile: profile, profileName: explanation.Profile, org: org}
+	e.defaultBranch()
+	e.features(shared)
+	e.branches(shared)
+	e.teams(settings)
+	e.security(shared)
+	e.lists(settings)
+	explanation.Values = e.values
+	return explanation, nil
+}
+
+// explainOrganization finds the organizations entry holding the named repository, accepting
+// org/repo, and returns it with the bare repository name. The entry is nil without an
+// organizations list, or when no organization declares the repository.
+func explainOrganization(settings *PermissionsSettings, name string) (*OrganizationConfig, string, error) {
+	orgName, repoName, qualified := strings.Cut(name, "/")
+	if len(settings.Organizations) == 0 {
+		if qualified && strings.EqualFold(orgName, stringValue(settings.Organization)) {
+			return nil, repoName, nil
+		}
+		return nil, name, nil
+	}
+	if qualified {
+		for _, org := range settings.Organizations {
+			if org != nil && strings.EqualFold(org.Name, orgName) {
+				return org, repoName, nil
+			}
+		}
+		return nil, name, fmt.Errorf("%w: %s", ErrOrganizationNotFound, orgName)
+	}
+
+	var match *OrganizationConfig
+	for _, org := range settings.Organizations {
+		if org == nil {
+			continue
+		}
+		for _, repo := range org.Repositories {
+			if repo != nil && strings.EqualFold(stringValue(repo.Name), name) {
+				if match != nil {
+					return nil, name, fmt.Errorf("%w: %s", ErrAmbiguousRepository, name)
+				}
+				match = org
+				break
+			}
+		}
+	}
+	return match, name, nil
+}
+
+// WriteText writes the explanation as an aligned table.
+func (e *RepositoryExplanation) WriteText(w io.Writer) error {
+	header := fmt.Sprintf("Repository: %s/%s", e.Organization, e.Repository)
+	if e.Profile != "" {
+		header += fmt.Sprintf(" (profile %s)", e.Profile)
+	}
+	if e.Archived {
+		header += " [archived: skipped by sync]"
+	}
+	if _, err := fmt.Fprintln(w, header); err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "FIELD\tVALUE\tSOURCE")
+	for _, v := range e.Values {
+		source := string(v.Source)
+		if v.Detail != "" {
+			source += " (" + v.Detail + ")"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", v.Field, formatExplainedValue(v.Value), source)
+	}
+	return tw.Flush()
+}
+
+// WriteJSON writes the explanation as indented JSON.
+func (e *RepositoryExplanation) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(e)
+}
+
+func formatExplainedValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "-"
+	case []string:
+		if len(v) == 0 {
+			return "[]"
+		}
+		return strings.Join(v, ", ")
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// explainer accumulates explained values for one repository.
+type explainer struct {
+	repo        *Repository
+	profile     *Profile
+	profileName string
+	// org is the repository's organizations entry, if the configuration has an organizations list.
+	org    *OrganizationConfig
+	values []ExplainedValue
+}
+
+func (e *explainer) add(field string, value interface{}, source ValueSource, detail string) {
+	e.values = append(e.values, ExplainedValue{Field: field, Value: value, Source: source, Detail: detail})
+}
+
+// addFirst records the first non-nil candidate, or an unset value when none is configured.
+func (e *explainer) addFirst(field string, candidates ...explainCandidate) {
+	for _, c := range candidates {
+		if value, ok := derefValue(c.value); ok {
+			e.add(field, value, c.source, c.detail)
+			return
+		}
+	}
+	e.add(field, nil, SourceUnset, "")
+}
+
+type explainCandidate struct {
+	value  interface{}
+	source ValueSource
+	detail string
+}
+
+func (e *explainer) fromRepository(value interface{}, field string) explainCandidate {
+	return explainCandidate{value: value, source: SourceRepository, detail: field}
+}
+
+func (e *explainer) fromProfile(value interface{}, field string) explainCandidate {
+	return explainCandidate{value: value, source: SourceProfile, detail: e.profileName + "." + field}
+}
+
+func (e *explainer) fromOrganization(value interface{}, field string) explainCandidate {
+	return explainCandidate{value: value, source: SourceOrganization, detail: e.org.Name + "." + field}
+}
+
+func (e *explainer) defaultBranch() {
+	if e.repo.DefaultBranch != nil && *e.repo.DefaultBranch != "" {
+		e.add("default_branch", *e.repo.DefaultBranch, SourceRepository, "default_branch")
+		return
+	}
+	e.add("default_branch", DefaultBranchName, SourceBuiltin, "")
+}
+
+// features mirrors setRepositoryFeatures and setAdvancedRepoSettings: the repository entry wins,
+// then the profile defaults, then the organization defaults, then the defaults block, then the
+// legacy default_* fields.
+func (e *explainer) features(settings *PermissionsSettings) {
+	defaults := settings.Defaults
+	if defaults == nil {
+		defaults = &RepositoryDefaults{}
+	}
+	profileDefaults := &RepositoryDefaults{}
+	if e.profile != nil && e.profile.Defaults != nil {
+		profileDefaults = e.profile.Defaults
+	}
+	orgDefaults := &RepositoryDefaults{}
+	if e.org != nil && e.org.Defaults != nil {
+		orgDefaults = e.org.Defaults
+	}
+	feature := func(field string, repoValue, profileValue, orgValue, defaultValue, legacyValue *bool) {
+		candidates := []explainCandidate{
+			e.fromRepository(repoValue, field),
+			e.fromProfile(profileValue, "defaults."+field),
+		}
+		if e.org != nil {
+			candidates = append(candidates, e.fromOrganization(orgValue, "defaults."+field))
+		}
+		candidates = append(candidates, explainCandidate{value: defaultValue, source: SourceDefaults, detail: "defaults." + field})
+		if legacyValue != nil {
+			candidates = append(candidates,
+				explainCandidate{value: legacyValue, source: SourceLegacyDefault, detail: "default_" + field})
+		}
+		e.addFirst(field, candidates...)
+	}
+	feature("wiki", e.repo.Wiki, profileDefaults.Wiki, orgDefaults.Wiki, defaults.Wiki, settings.DefaultWiki)
+	feature("issues", e.repo.Issues, profileDefaults.Issues, orgDefaults.Issues, defaults.Issues, settings.DefaultIssues)
+	feature("projects", e.repo.Projects, profileDefaults.Projects, orgDefaults.Projects, defaults.Projects,
+		settings.DefaultProjects)
+	feature("delete_branch_on_merge", e.repo.DeleteBranchOnMerge, profileDefaults.DeleteBranchOnMerge,
+		orgDefaults.DeleteBranchOnMerge, defaults.DeleteBranchOnMerge, nil)
+	e.addFirst("discussions_enabled", e.fromRepository(e.repo.HasDiscussionsEnabled, "discussions_enabled"))
+	e.addFirst("sponsorships_enabled", e.fromRepository(e.repo.HasSponsorshipsEnabled, "sponsorships_enabled"))
+}
+
+// branches explains every branch protection and merge strategy field of the branches block.
+func (e *explainer) branches(settings *PermissionsSettings) {
+	var profileBranches, orgBranches *BranchPermissions
+	if e.profile != nil {
+		profileBranches = e.profile.BranchPermissions
+	}
+	if e.org != nil {
+		orgBranches = e.org.BranchPermissions
+	}
+	e.structFields("branches", reflect.ValueOf(settings.BranchPermissions), profileBranches, orgBranches, nil)
+}
+
+// security explains the security toggles: repository overrides, then the organization's security
+// block, then the global security block.
+func (e *explainer) security(settings *PermissionsSettings) {
+	global := settings.Security
+	if global == nil {
+		global = &SecuritySettings{}
+	}
+	var orgSecurity *SecuritySettings
+	if e.org != nil {
+		orgSecurity = e.org.Security
+	}
+	e.structFields("security", reflect.ValueOf(*global), nil, orgSecurity, e.repo.Security)
+}
+
+// structFields walks the yaml-tagged fields of global, preferring the same field in the
+// organization override, above that in the profile override and, above that, in the repository override.
+func (e *explainer) structFields(block string, global reflect.Value, profileOverride, orgOverride, repoOverride interface{}) {
+	profileValue := indirectStruct(profileOverride)
+	orgValue := indirectStruct(orgOverride)
+	repoValue := indirectStruct(repoOverride)
+	for i := 0; i < global.NumField(); i++ {
+		tag := strings.Split(global.Type().Field(i).Tag.Get("yaml"), ",")[0]
+		field := block + "." + tag
+		var candidates []explainCandidate
+		if repoValue.IsValid() {
+			candidates = append(candidates, e.fromRepository(repoValue.Field(i).Interface(), field))
+		}
+		if profileValue.IsValid() {
+			candidates = append(candidates, e.fromProfile(profileValue.Field(i).Interface(), block))
+		}
+		if orgValue.IsValid() {
+			candidates = append(candidates, e.fromOrganization(orgValue.Field(i).Interface(), block))
+		}
+		candidates = append(candidates, explainCandidate{value: global.Field(i).Interface(), source: SourceGlobal, detail: block})
+		e.addFirst(field, candidates...)
+	}
+}
+
+// teams explains each team permission: profile entries replace global entries of the same team.
+func (e *explainer) teams(settings *PermissionsSettings) {
+	var profileTeams []*Permissions
+	if e.profile != nil {
+		profileTeams = e.profile.TeamPermissions
+	}
+	fromProfile := make(map[string]bool, len(profileTeams))
+	for _, perm := range profileTeams {
+		fromProfile[stringValue(perm.Team)] = true
+	}
+	for _, perm := range mergeTeamPermissions(settings.TeamPermissions, profileTeams) {
+		team := stringValue(perm.Team)
+		if fromProfile[team] {
+			e.add("team."+team, stringValue(perm.Level), SourceProfile, e.profileName+".team")
+			continue
+		}
+		if e.org != nil && containsTeam(e.org.TeamPermissions, team) {
+			e.add("team."+team, stringValue(perm.Level), SourceOrganization, e.org.Name+".team")
+			continue
+		}
+		e.add("team."+team, stringValue(perm.Level), SourceGlobal, "team")
+	}
+}
+
+// lists explains the label and topic sets synced to the repository.
+func (e *explainer) lists(settings *PermissionsSettings) {
+	var profileLabels []RepoLabel
+	var profileTopics []string
+	if e.profile != nil {
+		profileLabels = e.profile.Labels
+		profileTopics = e.profile.Topics
+	}
+	labels := mergeLabels(settings.DefaultLabels, profileLabels)
+	names := make([]string, 0, len(labels))
+	for _, label := range labels {
+		names = append(names, label.Name)
+	}
+	var orgLabels []RepoLabel
+	var orgTopics []string
+	if e.org != nil {
+		orgLabels, orgTopics = e.org.DefaultLabels, e.org.DefaultTopics
+	}
+	e.addList("labels", names, profileLabels != nil, orgLabels != nil, settings.DefaultLabels != nil, "default_labels")
+	topics := mergeTopics(settings.DefaultTopics, profileTopics)
+	e.addList("topics", topics, profileTopics != nil, orgTopics != nil, settings.DefaultTopics != nil, "default_topics")
+}
+
+// addList records a merged list, attributing it to the profile or organization entry when they
+// contribute entries.
+func (e *explainer) addList(field string, value []string, fromProfile, fromOrganization, fromGlobal bool, globalField string) {
+	switch {
+	case fromProfile:
+		e.add(field, value, SourceProfile, "merged over "+globalField+" by "+e.profileName)
+	case fromOrganization:
+		e.add(field, value, SourceOrganization, "merged over "+globalField+" by "+e.org.Name)
+	case fromGlobal:
+		e.add(field, value, SourceGlobal, globalField)
+	default:
+		e.add(field, value, SourceUnset, "")
+	}
+}
+
+func containsTeam(perms []*Permissions, team string) bool {
+	for _, perm := range perms {
+		if stringValue(perm.Team) == team {
+			return true
+		}
+	}
+	return false
+}
+
+// derefValue unwraps pointer values, reporting false for nil pointers and nil slices.
+func derefValue(value interface{}) (interface{}, bool) {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Invalid:
+		return nil, false
+	case reflect.Ptr:
+		if v.IsNil() {
+			return nil, false
+		}
+		return v.Elem().Interface(), true
+	case reflect.Slice:
+		if v.IsNil() {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// indirectStruct returns the struct a non-nil pointer refers to, or an invalid value.
+func indirectStruct(ptr interface{}) reflect.Value {
+	v := reflect.ValueOf(ptr)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return reflect.Value{}
+	}
+	return v.Elem()
+}
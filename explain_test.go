@@ -0,0 +1,155 @@
+package ownershit
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+const explainConfig = `organization: acme
+default_wiki: false
+default_projects: true
+defaults:
+  issues: true
+  projects: false
+branches:
+  require_code_owners: true
+team:
+  - name: devs
+    level: push
+security:
+  secret_scanning: true
+profiles:
+  critical:
+    branches:
+      require_approving_count: 2
+    team:
+      - name: devs
+        level: pull
+    defaults:
+      wiki: true
+    topics: [critical]
+default_topics: [managed]
+repositories:
+  - name: api
+    profile: critical
+  - name: web
+    issues: false
+    default_branch: trunk
+    security:
+      secret_scanning: false
+`
+
+func explainTestSettings(t *testing.T) *PermissionsSettings {
+	t.Helper()
+	settings := &PermissionsSettings{}
+	if err := yaml.Unmarshal([]byte(explainConfig), settings); err != nil {
+		t.Fatal(err)
+	}
+	return settings
+}
+
+func explainedValues(e *RepositoryExplanation) map[string]ExplainedValue {
+	values := make(map[string]ExplainedValue, len(e.Values))
+	for _, v := range e.Values {
+		values[v.Field] = v
+	}
+	return values
+}
+
+func TestExplainRepository(t *testing.T) {
+	settings := explainTestSettings(t)
+
+	tests := []struct {
+		repo   string
+		field  string
+		value  interface{}
+		source ValueSource
+		detail string
+	}{
+		{"web", "default_branch", "trunk", SourceRepository, "default_branch"},
+		{"web", "wiki", false, SourceLegacyDefault, "default_wiki"},
+		{"web", "issues", false, SourceRepository, "issues"},
+		{"web", "projects", false, SourceDefaults, "defaults.projects"},
+		{"web", "delete_branch_on_merge", nil, SourceUnset, ""},
+		{"web", "branches.require_code_owners", true, SourceGlobal, "branches"},
+		{"web", "security.secret_scanning", false, SourceRepository, "security.secret_scanning"},
+		{"web", "team.devs", "push", SourceGlobal, "team"},
+		{"api", "default_branch", DefaultBranchName, SourceBuiltin, ""},
+		{"api", "wiki", true, SourceProfile, "critical.defaults.wiki"},
+		{"api", "branches.require_approving_count", 2, SourceProfile, "critical.branches"},
+		{"api", "security.secret_scanning", true, SourceGlobal, "security"},
+		{"api", "team.devs", "pull", SourceProfile, "critical.team"},
+	}
+	explanations := map[string]map[string]ExplainedValue{}
+	for _, tt := range tests {
+		t.Run(tt.repo+"/"+tt.field, func(t *testing.T) {
+			values, ok := explanations[tt.repo]
+			if !ok {
+				explanation, err := ExplainRepository(settings, tt.repo)
+				if err != nil {
+					t.Fatalf("ExplainRepository() error = %v", err)
+				}
+				values = explainedValues(explanation)
+				explanations[tt.repo] = values
+			}
+			got, ok := values[tt.field]
+			if !ok {
+				t.Fatalf("field %s not explained", tt.field)
+			}
+			if got.Value != tt.value || got.Source != tt.source || got.Detail != tt.detail {
+				t.Errorf("got %v from %s (%s), want %v from %s (%s)",
+					got.Value, got.Source, got.Detail, tt.value, tt.source, tt.detail)
+			}
+		})
+	}
+}
+
+func TestExplainRepositoryTopics(t *testing.T) {
+	explanation, err := ExplainRepository(explainTestSettings(t), "API")
+	if err != nil {
+		t.Fatalf("ExplainRepository() error = %v", err)
+	}
+	topics := explainedValues(explanation)["topics"]
+	if strings.Join(topics.Value.([]string), ",") != "managed,critical" || topics.Source != SourceProfile {
+		t.Errorf("topics = %+v", topics)
+	}
+}
+
+func TestExplainRepositoryUnknown(t *testing.T) {
+	if _, err := ExplainRepository(explainTestSettings(t), "missing"); err == nil {
+		t.Fatal("expected error for undeclared repository")
+	}
+}
+
+func TestRepositoryExplanationOutput(t *testing.T) {
+	explanation, err := ExplainRepository(explainTestSettings(t), "api")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var text bytes.Buffer
+	if err := explanation.WriteText(&text); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"Repository: acme/api (profile critical)", "wiki", "profile (critical.defaults.wiki)"} {
+		if !strings.Contains(text.String(), want) {
+			t.Errorf("text output missing %q:\n%s", want, text.String())
+		}
+	}
+
+	var out bytes.Buffer
+	if err := explanation.WriteJSON(&out); err != nil {
+		t.Fatal(err)
+	}
+	var decoded RepositoryExplanation
+	if err := json.Unmarshal(out.Bytes(), &decoded); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if decoded.Repository != "api" || decoded.Profile != "critical" || len(decoded.Values) != len(explanation.Values) {
+		t.Errorf("unexpected JSON explanation: %+v", decoded)
+	}
+}
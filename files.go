@@ -0,0 +1,429 @@
+package ownershit
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/google/go-github/v66/github"
+	"github.com/rs/zerolog/log"
+)
+
+// FileSyncMode controls how a rendered file is written to a repository.
+type FileSyncMode string
+
+const (
+	// FileSyncCreateOnly writes the file only when it does not already exist.
+	FileSyncCreateOnly FileSyncMode = "create_only"
+	// FileSyncOverwrite commits the file directly to the default branch, replacing any existing content.
+	FileSyncOverwrite FileSyncMode = "overwrite"
+	// FileSyncPullRequest commits the file to a branch and opens a pull request against the default branch.
+	FileSyncPullRequest FileSyncMode = "pull_request"
+
+	// fileSyncBranchPrefix is the prefix for branches created in pull_request mode.
+	fileSyncBranchPrefix = "ownershit/files/"
+)
+
+// FileSync maps a local template file to a destination path within each repository.
+// The source is rendered with Go's text/template using FileTemplateData before being committed.
+type FileSync struct {
+	Source        string       `yaml:"source"`
+	Destination   string       `yaml:"destination"`
+	Mode          FileSyncMode `yaml:"mode,omitempty"`
+	CommitMessage string       `yaml:"commit_message,omitempty"`
+}
+
+// FileTemplateData holds the repository fields available to file templates,
+// e.g. {{ .Name }}, {{ .Description }} or {{ .Organization }}.
+type FileTemplateData struct {
+	Name          string
+	Organization  string
+	Description   string
+	Homepage      string
+	DefaultBranch string
+	Private       bool
+	Topics        []string
+}
+
+// Sentinel errors for file sync configuration.
+var (
+	ErrFileSyncSourceEmpty      = errors.New("file source must be specified")
+	ErrFileSyncDestinationEmpty = errors.New("file destination must be specified")
+	ErrFileSyncInvalidMode      = errors.New("invalid file sync mode")
+)
+
+// GetMode returns the configured sync mode, defaulting to create_only.
+func (f *FileSync) GetMode() FileSyncMode {
+	if f.Mode == "" {
+		return FileSyncCreateOnly
+	}
+	return f.Mode
+}
+
+// ValidateFileSync validates a single file sync entry.
+func ValidateFileSync(f *FileSync) error {
+	if f == nil {
+		return NewConfigValidationError("files", nil, "file entry cannot be nil", nil)
+	}
+	if strings.TrimSpace(f.Source) == "" {
+		return NewConfigValidationError("files.source", f.Source, "source path cannot be empty", ErrFileSyncSourceEmpty)
+	}
+	dest := strings.TrimSpace(f.Destination)
+	if dest == "" {
+		return NewConfigValidationError("files.destination", f.Destination, "destination path cannot be empty", ErrFileSyncDestinationEmpty)
+	}
+	if strings.HasPrefix(dest, "/") || slices.Contains(strings.Split(dest, "/"), "..") {
+		return NewConfigValidationError("files.destination", f.Destination,
+			"destination must be a relative path inside the repository", nil)
+	}
+	switch f.GetMode() {
+	case FileSyncCreateOnly, FileSyncOverwrite, FileSyncPullRequest:
+	default:
+		return NewConfigValidationError("files.mode", f.Mode,
+			fmt.Sprintf("mode must be one of %s, %s, %s", FileSyncCreateOnly, FileSyncOverwrite, FileSyncPullRequest),
+			ErrFileSyncInvalidMode)
+	}
+	return nil
+}
+
+// newFileTemplateData builds the template data for a repository.
+func newFileTemplateData(settings *PermissionsSettings, repo *Repository) FileTemplateData {
+	data := FileTemplateData{
+		DefaultBranch: getDefaultBranch(repo),
+		Topics:        repo.Topics,
+	}
+	if repo.Name != nil {
+		data.Name = *repo.Name
+	}
+	if settings.Organization != nil {
+		data.Organization = *settings.Organization
+	}
+	if repo.Description != nil {
+		data.Description = *repo.Description
+	}
+	if repo.Homepage != nil {
+		data.Homepage = *repo.Homepage
+	}
+	if repo.Private != nil {
+		data.Private = *repo.Private
+	}
+	if len(data.Topics) == 0 {
+		data.Topics = settings.EffectiveSettings(repo).DefaultTopics
+	}
+	return data
+}
+
+// RenderFileTemplate reads the template at path and renders it with data.
+// Missing keys are treated as errors so typos in templates are caught before anything is committed.
+func RenderFileTemplate(path string, data FileTemplateData) ([]byte, error) {
+	raw, err := os.ReadFile(path) // #nosec G304 - path comes from the user's configuration
+	if err != nil {
+		return nil, NewConfigFileError(path, "read", "failed to read file template", err)
+	}
+	tmpl, err := template.New(filepath.Base(path)).Option("missingkey=error").Parse(string(raw))
+	if err != nil {
+		return nil, NewConfigFileError(path, "parse", "failed to parse file template", err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, NewConfigFileError(path, "render", fmt.Sprintf("failed to render template for %s", data.Name), err)
+	}
+	return buf.Bytes(), nil
+}
+
+// SyncFiles renders and writes each configured file into every non-archived repository.
+// Relative source paths are resolved against baseDir, typically the directory of the configuration file.
+// If dryRun is true, it reads each destination and logs what a real run would do without writing.
+// An invalid files entry fails before any repository is touched; failed repositories are logged and
+// reported with ErrRepositoriesFailed.
+func SyncFiles(settings *PermissionsSettings, client *GitHubClient, baseDir string, dryRun bool) error {
+	if len(settings.Files) == 0 {
+		log.Info().Msg("no files configured, nothing to synchronize")
+		return nil
+	}
+	for _, f := range settings.Files {
+		if err := ValidateFileSync(f); err != nil {
+			log.Err(err).Msg("file sync validation failed")
+			return err
+		}
+	}
+
+	var failures repositoryFailures
+	for _, repo := range settings.Repositories {
+		// Skip archived repositories - they are read-only
+		if repo.Archived != nil && *repo.Archived {
+			log.Debug().
+				Str("repository", *repo.Name).
+				Msg("Skipping archived repository (read-only)")
+			continue
+		}
+
+		data := newFileTemplateData(settings, repo)
+		var errs []error
+		for _, f := range settings.Files {
+			source := f.Source
+			if !filepath.IsAbs(source) {
+				source = filepath.Join(baseDir, source)
+			}
+			content, err := RenderFileTemplate(source, data)
+			if err != nil {
+				log.Err(err).
+					Str("repository", *repo.Name).
+					Str("source", f.Source).
+					Msg("rendering file template")
+				errs = append(errs, err)
+				continue
+			}
+			if dryRun {
+				err = client.logFileSyncPlan(*settings.Organization, *repo.Name, f, content)
+			} else {
+				err = client.SyncFile(*settings.Organization, *repo.Name, getDefaultBranch(repo), f, content)
+			}
+			if err != nil {
+				log.Err(err).
+					Str("repository", *repo.Name).
+					Str("destination", f.Destination).
+					Str("mode", string(f.GetMode())).
+					Msg("synchronizing file")
+				errs = append(errs, err)
+			}
+		}
+		failures.record(*repo.Name, errs...)
+	}
+	return failures.err()
+}
+
+// fileSyncAction is what SyncFile does with a file, given the destination on the default branch.
+type fileSyncAction int
+
+const (
+	fileUpToDate fileSyncAction = iota
+	// fileSkipExisting leaves an existing file alone in create_only mode.
+	fileSkipExisting
+	fileCreate
+	fileOverwrite
+	filePullRequest
+)
+
+// fileSyncDryRunMessages describes each action in dry-run output.
+var fileSyncDryRunMessages = map[fileSyncAction]string{
+	fileUpToDate:     "File already up to date",
+	fileSkipExisting: "File exists, would skip (create_only)",
+	fileCreate:       "Would create file",
+	fileOverwrite:    "Would overwrite file",
+	filePullRequest:  "Would open pull request for file",
+}
+
+// planFileSync reads the destination of f in org/repo and returns what SyncFile would do with
+// content, along with the destination's blob SHA, which is empty when the file does not exist.
+func (c *GitHubClient) planFileSync(org, repo string, f *FileSync, content []byte) (fileSyncAction, string, error) {
+	existing, sha, err := c.getFileContent(org, repo, f.Destination, "")
+	if err != nil {
+		return 0, "", err
+	}
+	exists := sha != ""
+	if exists && bytes.Equal(existing, content) {
+		return fileUpToDate, sha, nil
+	}
+	switch f.GetMode() {
+	case FileSyncCreateOnly:
+		if exists {
+			return fileSkipExisting, sha, nil
+		}
+		return fileCreate, sha, nil
+	case FileSyncOverwrite:
+		if exists {
+			return fileOverwrite, sha, nil
+		}
+		return fileCreate, sha, nil
+	case FileSyncPullRequest:
+		return filePullRequest, sha, nil
+	}
+	return 0, "", fmt.Errorf("%w: %s", ErrFileSyncInvalidMode, f.Mode)
+}
+
+// logFileSyncPlan logs what SyncFile would do with content, without writing anything.
+func (c *GitHubClient) logFileSyncPlan(org, repo string, f *FileSync, content []byte) error {
+	action, _, err := c.planFileSync(org, repo, f, content)
+	if err != nil {
+		return err
+	}
+	log.Info().
+		Str("repository", fmt.Sprintf("%s/%s", org, repo)).
+		Str("destination", f.Destination).
+		Str("mode", string(f.GetMode())).
+		Int("bytes", len(content)).
+		Msg(fileSyncDryRunMessages[action])
+	return nil
+}
+
+// SyncFile writes content to the destination path of f in org/repo according to the file's mode.
+// Files whose content already matches are left untouched.
+func (c *GitHubClient) SyncFile(org, repo, defaultBranch string, f *FileSync, content []byte) error {
+	action, sha, err := c.planFileSync(org, repo, f, content)
+	if err != nil {
+		return err
+	}
+
+	logEvent := log.Info().
+		Str("repository", fmt.Sprintf("%s/%s", org, repo)).
+		Str("destination", f.Destination).
+		Str("mode", string(f.GetMode()))
+
+	switch action {
+	case fileUpToDate:
+		logEvent.Msg("file already up to date")
+		return nil
+	case fileSkipExisting:
+		logEvent.Msg("file exists, skipping (create_only)")
+		return nil
+	case fileCreate, fileOverwrite:
+		if err := c.putFile(org, repo, f, content, sha, ""); err != nil {
+			return err
+		}
+	case filePullRequest:
+		return c.syncFileViaPullRequest(org, repo, defaultBranch, f, content)
+	}
+
+	logEvent.Msg("successfully synchronized file")
+	return nil
+}
+
+// syncFileViaPullRequest commits content to a dedicated branch and opens a pull request for it.
+// An already existing branch or pull request is reused.
+func (c *GitHubClient) syncFileViaPullRequest(org, repo, defaultBranch string, f *FileSync, content []byte) error {
+	branch := fileSyncBranchName(f.Destination)
+	repoPath := fmt.Sprintf("%s/%s", org, repo)
+
+	baseRef, resp, err := c.Git.GetRef(c.Context, org, repo, "heads/"+defaultBranch)
+	if err != nil {
+		return classifyAPIError(responseStatus(resp), "get ref", repoPath,
+			fmt.Sprintf("failed to resolve default branch %s", defaultBranch), err)
+	}
+
+	_, resp, err = c.Git.CreateRef(c.Context, org, repo, &github.Reference{
+		Ref:    github.String("refs/heads/" + branch),
+		Object: &github.GitObject{SHA: baseRef.Object.SHA},
+	})
+	if err != nil && responseStatus(resp) != http.StatusUnprocessableEntity {
+		return classifyAPIError(responseStatus(resp), "create ref", repoPath,
+			fmt.Sprintf("failed to create branch %s", branch), err)
+	}
+
+	// The branch may already exist from a previous run; compare against its content instead.
+	existing, sha, err := c.getFileContent(org, repo, f.Destination, branch)
+	if err != nil {
+		return err
+	}
+	if sha == "" || !bytes.Equal(existing, content) {
+		if err := c.putFile(org, repo, f, content, sha, branch); err != nil {
+			return err
+		}
+	}
+
+	pr, resp, err := c.PullRequests.Create(c.Context, org, repo, &github.NewPullRequest{
+		Title: github.String(f.commitMessage()),
+		Head:  github.String(branch),
+		Base:  github.String(defaultBranch),
+		Body:  github.String(fmt.Sprintf("Synchronizes `%s` from the ownershit file templates.", f.Destination)),
+	})
+	if err != nil {
+		if responseStatus(resp) == http.StatusUnprocessableEntity {
+			log.Info().
+				Str("repository", repoPath).
+				Str("branch", branch).
+				Msg("pull request already open for file")
+			return nil
+		}
+		return classifyAPIError(responseStatus(resp), "create pull request", repoPath,
+			fmt.Sprintf("failed to open pull request for %s", f.Destination), err)
+	}
+
+	log.Info().
+		Str("repository", repoPath).
+		Str("destination", f.Destination).
+		Str("url", pr.GetHTMLURL()).
+		Msg("opened pull request for file")
+	return nil
+}
+
+// getFileContent returns the decoded content and blob SHA of path on ref (the default branch when empty).
+// A missing file is reported as an empty SHA rather than an error.
+func (c *GitHubClient) getFileContent(org, repo, path, ref string) ([]byte, string, error) {
+	var opts *github.RepositoryContentGetOptions
+	if ref != "" {
+		opts = &github.RepositoryContentGetOptions{Ref: ref}
+	}
+	file, _, resp, err := c.Repositories.GetContents(c.Context, org, repo, path, opts)
+	if err != nil {
+		if responseStatus(resp) == http.StatusNotFound {
+			return nil, "", nil
+		}
+		return nil, "", classifyAPIError(responseStatus(resp), "get contents", fmt.Sprintf("%s/%s", org, repo),
+			fmt.Sprintf("failed to read %s", path), err)
+	}
+	if file == nil {
+		return nil, "", NewGitHubAPIError(0, "get contents", fmt.Sprintf("%s/%s", org, repo),
+			fmt.Sprintf("%s is a directory", path), nil)
+	}
+	decoded, err := file.GetContent()
+	if err != nil {
+		return nil, "", fmt.Errorf("decoding %s in %s/%s: %w", path, org, repo, err)
+	}
+	return []byte(decoded), file.GetSHA(), nil
+}
+
+// putFile creates (empty sha) or updates the file at f.Destination on branch (the default branch when empty).
+func (c *GitHubClient) putFile(org, repo string, f *FileSync, content []byte, sha, branch string) error {
+	opts := &github.RepositoryContentFileOptions{
+		Message: github.String(f.commitMessage()),
+		Content: content,
+	}
+	if branch != "" {
+		opts.Branch = github.String(branch)
+	}
+
+	var resp *github.Response
+	var err error
+	operation := "create file"
+	if sha == "" {
+		_, resp, err = c.Repositories.CreateFile(c.Context, org, repo, f.Destination, opts)
+	} else {
+		operation = "update file"
+		opts.SHA = github.String(sha)
+		_, resp, err = c.Repositories.UpdateFile(c.Context, org, repo, f.Destination, opts)
+	}
+	if err != nil {
+		return classifyAPIError(responseStatus(resp), operation, fmt.Sprintf("%s/%s", org, repo),
+			fmt.Sprintf("failed to write %s", f.Destination), err)
+	}
+	return nil
+}
+
+func (f *FileSync) commitMessage() string {
+	if strings.TrimSpace(f.CommitMessage) != "" {
+		return f.CommitMessage
+	}
+	return fmt.Sprintf("chore: sync %s", f.Destination)
+}
+
+// fileSyncBranchName derives a stable branch name from a destination path.
+func fileSyncBranchName(destination string) string {
+	replacer := strings.NewReplacer("/", "-", " ", "-", ".", "-")
+	name := strings.Trim(replacer.Replace(strings.ToLower(destination)), "-")
+	return fileSyncBranchPrefix + name
+}
+
+// responseStatus returns the HTTP status code of resp, or 0 when no response was received.
+func responseStatus(resp *github.Response) int {
+	if resp == nil || resp.Response == nil {
+		return 0
+	}
+	return resp.StatusCode
+}
@@ -0,0 +1,254 @@
+package ownershit
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v66/github"
+	"go.uber.org/mock/gomock"
+)
+
+func notFoundResponse() *github.Response {
+	return &github.Response{Response: &http.Response{StatusCode: http.StatusNotFound}}
+}
+
+func unprocessableResponse() *github.Response {
+	return &github.Response{Response: &http.Response{StatusCode: http.StatusUnprocessableEntity}}
+}
+
+func encodedContent(content, sha string) *github.RepositoryContent {
+	return &github.RepositoryContent{
+		Encoding: github.String("base64"),
+		Content:  github.String(base64.StdEncoding.EncodeToString([]byte(content))),
+		SHA:      github.String(sha),
+	}
+}
+
+func TestValidateFileSync(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    *FileSync
+		wantErr error
+	}{
+		{name: "nil entry", file: nil, wantErr: ErrValidation},
+		{name: "valid default mode", file: &FileSync{Source: "SECURITY.md", Destination: "SECURITY.md"}},
+		{name: "valid pull_request mode", file: &FileSync{Source: "a", Destination: ".github/dependabot.yml", Mode: FileSyncPullRequest}},
+		{name: "missing source", file: &FileSync{Destination: "LICENSE"}, wantErr: ErrFileSyncSourceEmpty},
+		{name: "missing destination", file: &FileSync{Source: "LICENSE"}, wantErr: ErrFileSyncDestinationEmpty},
+		{name: "absolute destination", file: &FileSync{Source: "a", Destination: "/etc/passwd"}, wantErr: ErrValidation},
+		{name: "escaping destination", file: &FileSync{Source: "a", Destination: "../x"}, wantErr: ErrValidation},
+		{name: "nested escaping destination", file: &FileSync{Source: "a", Destination: "docs/../../x"}, wantErr: ErrValidation},
+		{name: "dots inside a name", file: &FileSync{Source: "a", Destination: "docs/release..notes.md"}},
+		{name: "unknown mode", file: &FileSync{Source: "a", Destination: "b", Mode: "append"}, wantErr: ErrFileSyncInvalidMode},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateFileSync(tt.file)
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("ValidateFileSync() unexpected error = %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ValidateFileSync() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRenderFileTemplate(t *testing.T) {
+	dir := t.TempDir()
+	good := filepath.Join(dir, "SECURITY.md")
+	if err := os.WriteFile(good, []byte("# {{ .Name }} ({{ .Organization }})\n{{ .Description }}"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	bad := filepath.Join(dir, "bad.md")
+	if err := os.WriteFile(bad, []byte("{{ .Missing }}"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	data := newFileTemplateData(&PermissionsSettings{Organization: stringPtr("acme")}, &Repository{
+		Name:        stringPtr("widgets"),
+		Description: stringPtr("Widget service"),
+	})
+
+	got, err := RenderFileTemplate(good, data)
+	if err != nil {
+		t.Fatalf("RenderFileTemplate() error = %v", err)
+	}
+	if want := "# widgets (acme)\nWidget service"; string(got) != want {
+		t.Errorf("RenderFileTemplate() = %q, want %q", got, want)
+	}
+
+	if _, err := RenderFileTemplate(bad, data); err == nil {
+		t.Error("expected error for unknown template field")
+	}
+	if _, err := RenderFileTemplate(filepath.Join(dir, "missing.md"), data); !errors.Is(err, ErrConfiguration) {
+		t.Errorf("expected ConfigFileError for missing template, got %v", err)
+	}
+}
+
+func TestSyncFileCreateOnly(t *testing.T) {
+	m := setupMocks(t)
+	f := &FileSync{Source: "LICENSE", Destination: "LICENSE", Mode: FileSyncCreateOnly}
+
+	// Existing file with different content is left alone.
+	m.repoMock.EXPECT().GetContents(gomock.Any(), "org", "repo", "LICENSE", gomock.Nil()).
+		Return(encodedContent("old", "abc"), nil, defaultGoodResponse, nil)
+	if err := m.client.SyncFile("org", "repo", "main", f, []byte("new")); err != nil {
+		t.Fatalf("SyncFile() error = %v", err)
+	}
+
+	// Missing file is created.
+	m.repoMock.EXPECT().GetContents(gomock.Any(), "org", "repo", "LICENSE", gomock.Nil()).
+		Return(nil, nil, notFoundResponse(), errors.New("404 Not Found"))
+	m.repoMock.EXPECT().CreateFile(gomock.Any(), "org", "repo", "LICENSE", gomock.Any()).
+		DoAndReturn(func(_ interface{}, _, _, _ string, opts *github.RepositoryContentFileOptions) (*github.RepositoryContentResponse, *github.Response, error) {
+			if string(opts.Content) != "new" || opts.SHA != nil || opts.Branch != nil {
+				t.Errorf("unexpected create options: %+v", opts)
+			}
+			return &github.RepositoryContentResponse{}, defaultGoodResponse, nil
+		})
+	if err := m.client.SyncFile("org", "repo", "main", f, []byte("new")); err != nil {
+		t.Fatalf("SyncFile() error = %v", err)
+	}
+}
+
+func TestSyncFileOverwrite(t *testing.T) {
+	m := setupMocks(t)
+	f := &FileSync{Source: "SECURITY.md", Destination: "SECURITY.md", Mode: FileSyncOverwrite}
+
+	// Identical content results in no write.
+	m.repoMock.EXPECT().GetContents(gomock.Any(), "org", "repo", "SECURITY.md", gomock.Nil()).
+		Return(encodedContent("same", "abc"), nil, defaultGoodResponse, nil)
+	if err := m.client.SyncFile("org", "repo", "main", f, []byte("same")); err != nil {
+		t.Fatalf("SyncFile() error = %v", err)
+	}
+
+	m.repoMock.EXPECT().GetContents(gomock.Any(), "org", "repo", "SECURITY.md", gomock.Nil()).
+		Return(encodedContent("old", "abc"), nil, defaultGoodResponse, nil)
+	m.repoMock.EXPECT().UpdateFile(gomock.Any(), "org", "repo", "SECURITY.md", gomock.Any()).
+		DoAndReturn(func(_ interface{}, _, _, _ string, opts *github.RepositoryContentFileOptions) (*github.RepositoryContentResponse, *github.Response, error) {
+			if opts.GetSHA() != "abc" {
+				t.Errorf("expected SHA abc, got %q", opts.GetSHA())
+			}
+			return &github.RepositoryContentResponse{}, defaultGoodResponse, nil
+		})
+	if err := m.client.SyncFile("org", "repo", "main", f, []byte("new")); err != nil {
+		t.Fatalf("SyncFile() error = %v", err)
+	}
+}
+
+func TestSyncFilePullRequest(t *testing.T) {
+	m := setupMocks(t)
+	f := &FileSync{Source: "d.yml", Destination: ".github/dependabot.yml", Mode: FileSyncPullRequest}
+	branch := fileSyncBranchName(f.Destination)
+
+	m.repoMock.EXPECT().GetContents(gomock.Any(), "org", "repo", f.Destination, gomock.Nil()).
+		Return(nil, nil, notFoundResponse(), errors.New("404 Not Found"))
+	m.gitMock.EXPECT().GetRef(gomock.Any(), "org", "repo", "heads/main").
+		Return(&github.Reference{Object: &github.GitObject{SHA: github.String("base")}}, defaultGoodResponse, nil)
+	m.gitMock.EXPECT().CreateRef(gomock.Any(), "org", "repo", gomock.Any()).
+		Return(nil, unprocessableResponse(), errors.New("Reference already exists"))
+	m.repoMock.EXPECT().GetContents(gomock.Any(), "org", "repo", f.Destination, &github.RepositoryContentGetOptions{Ref: branch}).
+		Return(nil, nil, notFoundResponse(), errors.New("404 Not Found"))
+	m.repoMock.EXPECT().CreateFile(gomock.Any(), "org", "repo", f.Destination, gomock.Any()).
+		DoAndReturn(func(_ interface{}, _, _, _ string, opts *github.RepositoryContentFileOptions) (*github.RepositoryContentResponse, *github.Response, error) {
+			if opts.GetBranch() != branch {
+				t.Errorf("expected branch %q, got %q", branch, opts.GetBranch())
+			}
+			return &github.RepositoryContentResponse{}, defaultGoodResponse, nil
+		})
+	m.pullsMock.EXPECT().Create(gomock.Any(), "org", "repo", gomock.Any()).
+		Return(&github.PullRequest{HTMLURL: github.String("https://example/pr/1")}, defaultGoodResponse, nil)
+
+	if err := m.client.SyncFile("org", "repo", "main", f, []byte("version: 2")); err != nil {
+		t.Fatalf("SyncFile() error = %v", err)
+	}
+}
+
+func TestPlanFileSync(t *testing.T) {
+	tests := []struct {
+		name     string
+		mode     FileSyncMode
+		existing *github.RepositoryContent
+		want     fileSyncAction
+	}{
+		{"create only, missing", FileSyncCreateOnly, nil, fileCreate},
+		{"create only, exists", FileSyncCreateOnly, encodedContent("old", "abc"), fileSkipExisting},
+		{"overwrite, exists", FileSyncOverwrite, encodedContent("old", "abc"), fileOverwrite},
+		{"overwrite, identical", FileSyncOverwrite, encodedContent("new", "abc"), fileUpToDate},
+		{"pull request", FileSyncPullRequest, encodedContent("old", "abc"), filePullRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := setupMocks(t)
+			call := m.repoMock.EXPECT().GetContents(gomock.Any(), "org", "repo", "LICENSE", gomock.Nil())
+			if tt.existing == nil {
+				call.Return(nil, nil, notFoundResponse(), errors.New("404 Not Found"))
+			} else {
+				call.Return(tt.existing, nil, defaultGoodResponse, nil)
+			}
+			got, _, err := m.client.planFileSync("org", "repo", &FileSync{Destination: "LICENSE", Mode: tt.mode}, []byte("new"))
+			if err != nil || got != tt.want {
+				t.Errorf("planFileSync() = %v, %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSyncFilesDryRunReadsDestinations(t *testing.T) {
+	m := setupMocks(t)
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "LICENSE"), []byte("new"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	settings := &PermissionsSettings{
+		Organization: github.String("org"),
+		Repositories: []*Repository{{Name: github.String("repo")}},
+		Files:        []*FileSync{{Source: "LICENSE", Destination: "LICENSE", Mode: FileSyncCreateOnly}},
+	}
+
+	// The existing file is read but nothing is written.
+	m.repoMock.EXPECT().GetContents(gomock.Any(), "org", "repo", "LICENSE", gomock.Nil()).
+		Return(encodedContent("old", "abc"), nil, defaultGoodResponse, nil)
+	if err := SyncFiles(settings, m.client, dir, true); err != nil {
+		t.Fatalf("SyncFiles() error = %v", err)
+	}
+
+	m.repoMock.EXPECT().GetContents(gomock.Any(), "org", "repo", "LICENSE", gomock.Nil()).
+		Return(nil, nil, &github.Response{Response: &http.Response{StatusCode: http.StatusForbidden}}, errors.New("forbidden"))
+	if err := SyncFiles(settings, m.client, dir, true); !errors.Is(err, ErrRepositoriesFailed) {
+		t.Errorf("SyncFiles() error = %v, want ErrRepositoriesFailed", err)
+	}
+
+	settings.Files[0].Destination = "../LICENSE"
+	if err := SyncFiles(settings, m.client, dir, true); err == nil {
+		t.Error("SyncFiles() should reject an invalid files entry")
+	}
+}
+
+func TestSyncFileContentsError(t *testing.T) {
+	m := setupMocks(t)
+	f := &FileSync{Source: "LICENSE", Destination: "LICENSE"}
+	m.repoMock.EXPECT().GetContents(gomock.Any(), "org", "repo", "LICENSE", gomock.Nil()).
+		Return(nil, nil, &github.Response{Response: &http.Response{StatusCode: http.StatusForbidden}}, errors.New("forbidden"))
+
+	err := m.client.SyncFile("org", "repo", "main", f, []byte("x"))
+	if !errors.Is(err, ErrPermissionDenied) {
+		t.Fatalf("expected permission denied error, got %v", err)
+	}
+}
+
+func TestFileSyncBranchName(t *testing.T) {
+	got := fileSyncBranchName(".github/pull_request_template.md")
+	if !strings.HasPrefix(got, fileSyncBranchPrefix) || strings.ContainsAny(strings.TrimPrefix(got, fileSyncBranchPrefix), "/. ") {
+		t.Errorf("fileSyncBranchName() = %q", got)
+	}
+}
@@ -0,0 +1,409 @@
+package ownershit
+
+import (
+	"bytes"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// legacyDefaultKeys maps the deprecated top-level default_* fields to their key in the defaults block.
+var legacyDefaultKeys = []struct{ legacy, key string }{
+	{"default_wiki", "wiki"},
+	{"default_issues", "issues"},
+	{"default_projects", "projects"},
+}
+
+// FormattedConfigFile is one configuration file and its canonical formatting.
+type FormattedConfigFile struct {
+	File      string
+	Original  []byte
+	Formatted []byte
+}
+
+// Changed reports whether formatting rewrites the file.
+func (f *FormattedConfigFile) Changed() bool {
+	return !bytes.Equal(f.Original, f.Formatted)
+}
+
+// FormatConfiguration formats every file of the configuration at path without writing anything:
+//   - keys follow the order of the configuration struct fields;
+//   - repositories are sorted by name;
+//   - repository settings equal to the value they would inherit anyway, with or without any overlay
+//     in the overlays directory, are removed;
+//   - legacy default_wiki/default_issues/default_projects fields move into the defaults block.
+//
+// Comments are preserved. The configuration must load, so inherited values can be resolved; when it
+// does not, the error is a ConfigProblemsError locating each problem, as reported by validate.
+func FormatConfiguration(path string) ([]*FormattedConfigFile, error) {
+	settings, err := loadConfiguration(path, false)
+	if err != nil {
+		return nil, locateLoadError(path, err)
+	}
+	loader := newConfigLoader()
+	if err := loader.loadAll(path); err != nil {
+		return nil, err
+	}
+
+	files := make([]*FormattedConfigFile, 0, len(loader.order))
+	docs := make([]*yaml.Node, 0, len(loader.order))
+	for _, name := range loader.order {
+		data, err := os.ReadFile(name) // #nosec G304 - configuration paths come from the user
+		if err != nil {
+			return nil, NewConfigFileError(name, "read", "failed to read configuration file", err)
+		}
+		var doc yaml.Node
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, NewConfigFileError(name, "parse", "failed to parse YAML configuration", err)
+		}
+		files = append(files, &FormattedConfigFile{File: name, Original: data, Formatted: data})
+		docs = append(docs, &doc)
+	}
+
+	moveLegacyDefaults(settings, docs)
+
+	// Inherited values are resolved against the defaults after the legacy fields are folded in, once
+	// for the base configuration and once under each overlay in the overlays directory: a setting is
+	// only inherited if it is under all of them.
+	variants := []*PermissionsSettings{settings}
+	for _, overlay := range overlayFiles(path) {
+		variant, err := loadConfiguration(path, false, overlay)
+		if err != nil {
+			return nil, locateLoadError(path, err, overlay)
+		}
+		variants = append(variants, variant)
+	}
+	inherited := make([]*PermissionsSettings, 0, len(variants))
+	organizations := make([][]*ResolvedOrganization, 0, len(variants))
+	for _, variant := range variants {
+		resolved := *variant
+		resolved.MigrateToNestedDefaults()
+		inherited = append(inherited, &resolved)
+		organizations = append(organizations, ResolveOrganizations(&resolved))
+	}
+	settingsType := reflect.TypeOf(PermissionsSettings{})
+	for i, doc := range docs {
+		if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+			continue
+		}
+		root := doc.Content[0]
+		formatRepositoryNodes(inherited, root)
+		if index := mappingIndex(root, configKeyOrganizations); index >= 0 {
+			for _, entry := range root.Content[index+1].Content {
+				if orgSettings := organizationVariants(organizations, mappingValue(entry, "name")); orgSettings != nil {
+					formatRepositoryNodes(orgSettings, entry)
+				}
+			}
+		}
+		sortConfigNode(root, settingsType)
+
+		formatted, err := encodeConfigDocument(doc)
+		if err != nil {
+			return nil, NewConfigFileError(files[i].File, "format", "failed to encode configuration", err)
+		}
+		files[i].Formatted = separateTopLevelKeys(formatted)
+	}
+	return files, nil
+}
+
+// WriteFormattedConfigFile writes a formatted file in place when it changed.
+func WriteFormattedConfigFile(f *FormattedConfigFile) error {
+	if !f.Changed() {
+		return nil
+	}
+	info, err := os.Stat(f.File)
+	if err != nil {
+		return NewConfigFileError(f.File, "write", "failed to stat configuration file", err)
+	}
+	if err := os.WriteFile(f.File, f.Formatted, info.Mode().Perm()); err != nil {
+		return NewConfigFileError(f.File, "write", "failed to write formatted configuration", err)
+	}
+	return nil
+}
+
+// separateTopLevelKeys puts a blank line between top-level keys, which the YAML encoder drops. The
+// blank line goes above any comment block that introduces the key.
+func separateTopLevelKeys(data []byte) []byte {
+	lines := strings.SplitAfter(string(data), "\n")
+	var out []string
+	seenKey := false
+	for i, line := range lines {
+		if line == "" || strings.HasPrefix(line, " ") || strings.HasPrefix(line, "#") ||
+			strings.HasPrefix(line, "-") || strings.TrimSpace(line) == "" {
+			out = append(out, line)
+			continue
+		}
+		if seenKey {
+			// Back up over the comment lines directly above this key.
+			start := len(out)
+			for start > 0 && strings.HasPrefix(out[start-1], "#") {
+				start--
+			}
+			if start > 0 && strings.TrimSpace(out[start-1]) != "" {
+				out = append(out[:start], append([]string{"\n"}, out[start:]...)...)
+			}
+		}
+		seenKey = true
+		out = append(out, lines[i])
+	}
+	return []byte(strings.Join(out, ""))
+}
+
+// moveLegacyDefaults removes the default_* fields, adding their values to the defaults block unless
+// the block already sets them (in which case the legacy value never applied). The block is written
+// to the file that already declares it, or else to the file holding the legacy fields.
+func moveLegacyDefaults(settings *PermissionsSettings, docs []*yaml.Node) {
+	var target *yaml.Node
+	for _, doc := range docs {
+		if len(doc.Content) > 0 && mappingIndex(doc.Content[0], "defaults") >= 0 {
+			target = doc.Content[0]
+			break
+		}
+	}
+
+	declared := func(key string) bool {
+		if settings.Defaults == nil {
+			return false
+		}
+		switch key {
+		case "wiki":
+			return settings.Defaults.Wiki != nil
+		case "issues":
+			return settings.Defaults.Issues != nil
+		default:
+			return settings.Defaults.Projects != nil
+		}
+	}
+
+	for _, doc := range docs {
+		if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+			continue
+		}
+		root := doc.Content[0]
+		for _, field := range legacyDefaultKeys {
+			index := mappingIndex(root, field.legacy)
+			if index < 0 {
+				continue
+			}
+			key, value := root.Content[index], root.Content[index+1]
+			root.Content = append(root.Content[:index], root.Content[index+2:]...)
+			if declared(field.key) {
+				continue
+			}
+			if target == nil {
+				target = root
+			}
+			defaults := mappingIndex(target, "defaults")
+			if defaults < 0 || target.Content[defaults+1].Kind != yaml.MappingNode {
+				if defaults >= 0 {
+					target.Content = append(target.Content[:defaults], target.Content[defaults+2:]...)
+				}
+				target.Content = append(target.Content,
+					&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "defaults"},
+					&yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"})
+				defaults = len(target.Content) - 2
+			}
+			block := target.Content[defaults+1]
+			key.Value = field.key
+			block.Content = append(block.Content, key, value)
+		}
+	}
+}
+
+// formatRepositoryNodes removes inherited settings from the repositories listed in node and sorts
+// them. A setting is only removed when it is inherited under every one of variants.
+func formatRepositoryNodes(variants []*PermissionsSettings, node *yaml.Node) {
+	index := mappingIndex(node, configKeyRepositories)
+	if index < 0 {
+		return
+	}
+	repos := node.Content[index+1]
+	for _, repo := range repos.Content {
+		removeInheritedRepositorySettings(variants, repo)
+	}
+	sortRepositoryNodes(repos)
+}
+
+// organizationVariants returns the settings of the named organization under each configuration
+// variant, or nil if any variant lacks it.
+func organizationVariants(variants [][]*ResolvedOrganization, name string) []*PermissionsSettings {
+	settings := make([]*PermissionsSettings, 0, len(variants))
+	for _, organizations := range variants {
+		org, err := FindOrganization(organizations, name)
+		if err != nil {
+			return nil
+		}
+		settings = append(settings, org.Settings)
+	}
+	return settings
+}
+
+// removeInheritedRepositorySettings drops repository settings equal to what the repository would
+// inherit under each of variants: wiki, issues, projects and delete_branch_on_merge from the
+// defaults block merged with its profile's, and security settings from the global security block.
+// The only built-in default compared is default_branch, which is removed when it is main. Settings
+// nothing above declares are kept, as their effective value depends on GitHub.
+func removeInheritedRepositorySettings(variants []*PermissionsSettings, repo *yaml.Node) {
+	if repo.Kind != yaml.MappingNode {
+		return
+	}
+	profileName := mappingValue(repo, "profile")
+	defaults := make([]*RepositoryDefaults, 0, len(variants))
+	security := make([]*SecuritySettings, 0, len(variants))
+	for _, settings := range variants {
+		d := settings.Defaults
+		if profile := settings.Profiles[profileName]; profileName != "" && profile != nil {
+			d = mergeRepositoryDefaults(d, profile.Defaults)
+		}
+		defaults = append(defaults, d)
+		security = append(security, settings.Security)
+	}
+	inheritedDefault := func(field func(*RepositoryDefaults) *bool) []*bool {
+		values := make([]*bool, len(defaults))
+		for i, d := range defaults {
+			if d != nil {
+				values[i] = field(d)
+			}
+		}
+		return values
+	}
+	inheritedSecurity := func(field func(*SecuritySettings) *bool) []*bool {
+		values := make([]*bool, len(security))
+		for i, s := range security {
+			if s != nil {
+				values[i] = field(s)
+			}
+		}
+		return values
+	}
+
+	removeInheritedBool(repo, "wiki", inheritedDefault(func(d *RepositoryDefaults) *bool { return d.Wiki }))
+	removeInheritedBool(repo, "issues", inheritedDefault(func(d *RepositoryDefaults) *bool { return d.Issues }))
+	removeInheritedBool(repo, "projects", inheritedDefault(func(d *RepositoryDefaults) *bool { return d.Projects }))
+	removeInheritedBool(repo, "delete_branch_on_merge",
+		inheritedDefault(func(d *RepositoryDefaults) *bool { return d.DeleteBranchOnMerge }))
+	if mappingValue(repo, "default_branch") == DefaultBranchName {
+		removeMappingKey(repo, "default_branch")
+	}
+
+	index := mappingIndex(repo, "security")
+	if index < 0 {
+		return
+	}
+	node := repo.Content[index+1]
+	removeInheritedBool(node, "vulnerability_alerts",
+		inheritedSecurity(func(s *SecuritySettings) *bool { return s.VulnerabilityAlerts }))
+	removeInheritedBool(node, "dependabot_security_updates",
+		inheritedSecurity(func(s *SecuritySettings) *bool { return s.DependabotSecurityUpdates }))
+	removeInheritedBool(node, "secret_scanning",
+		inheritedSecurity(func(s *SecuritySettings) *bool { return s.SecretScanning }))
+	removeInheritedBool(node, "secret_scanning_push_protection",
+		inheritedSecurity(func(s *SecuritySettings) *bool { return s.SecretScanningPushProtection }))
+	removeInheritedBool(node, "private_vulnerability_reporting",
+		inheritedSecurity(func(s *SecuritySettings) *bool { return s.PrivateVulnerabilityReporting }))
+	if node.Kind == yaml.MappingNode && len(node.Content) == 0 {
+		removeMappingKey(repo, "security")
+	}
+}
+
+// removeInheritedBool removes key from node when its boolean value equals every one of inherited.
+func removeInheritedBool(node *yaml.Node, key string, inherited []*bool) {
+	index := mappingIndex(node, key)
+	if index < 0 || len(inherited) == 0 {
+		return
+	}
+	var value bool
+	if err := node.Content[index+1].Decode(&value); err != nil {
+		return
+	}
+	for _, v := range inherited {
+		if v == nil || *v != value {
+			return
+		}
+	}
+	removeMappingKey(node, key)
+}
+
+func removeMappingKey(node *yaml.Node, key string) {
+	if index := mappingIndex(node, key); index >= 0 {
+		node.Content = append(node.Content[:index], node.Content[index+2:]...)
+	}
+}
+
+// sortRepositoryNodes orders repository entries by name, case-insensitively.
+func sortRepositoryNodes(repos *yaml.Node) {
+	if repos.Kind != yaml.SequenceNode {
+		return
+	}
+	sort.SliceStable(repos.Content, func(i, j int) bool {
+		a, b := mappingValue(repos.Content[i], "name"), mappingValue(repos.Content[j], "name")
+		if !strings.EqualFold(a, b) {
+			return strings.ToLower(a) < strings.ToLower(b)
+		}
+		return a < b
+	})
+}
+
+// sortConfigNode orders mapping keys by the declaration order of the fields of t, recursing into
+// nested structs, lists and maps. Keys unknown to t keep their relative order after the known ones.
+func sortConfigNode(node *yaml.Node, t reflect.Type) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch {
+	case node.Kind == yaml.SequenceNode && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array):
+		for _, item := range node.Content {
+			sortConfigNode(item, t.Elem())
+		}
+	case node.Kind == yaml.MappingNode && t.Kind() == reflect.Map:
+		for i := 1; i < len(node.Content); i += 2 {
+			sortConfigNode(node.Content[i], t.Elem())
+		}
+	case node.Kind == yaml.MappingNode && t.Kind() == reflect.Struct:
+		fields := yamlFields(t)
+		order := make(map[string]int, len(fields))
+		types := make(map[string]reflect.Type, len(fields))
+		for i, field := range fields {
+			order[field.Name] = i
+			types[field.Name] = field.Type
+		}
+		pairs := make([][2]*yaml.Node, 0, len(node.Content)/2)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			pairs = append(pairs, [2]*yaml.Node{node.Content[i], node.Content[i+1]})
+			if fieldType, ok := types[node.Content[i].Value]; ok {
+				sortConfigNode(node.Content[i+1], fieldType)
+			}
+		}
+		rank := func(key string) int {
+			if i, ok := order[key]; ok {
+				return i
+			}
+			return len(fields)
+		}
+		// A comment at the top of a mapping that is separated from the first key by a blank line belongs
+		// to the mapping and stays at the top; the part after the last blank line moves with its key.
+		// The parser marks a blank line after the whole comment with a trailing newline.
+		var head string
+		if len(pairs) > 0 {
+			comment := pairs[0][0].HeadComment
+			if i := strings.LastIndex(comment, "\n\n"); i >= 0 {
+				head, pairs[0][0].HeadComment = comment[:i], comment[i+2:]
+			} else if strings.HasSuffix(comment, "\n") {
+				head, pairs[0][0].HeadComment = strings.TrimSuffix(comment, "\n"), ""
+			}
+		}
+		sort.SliceStable(pairs, func(i, j int) bool {
+			return rank(pairs[i][0].Value) < rank(pairs[j][0].Value)
+		})
+		if len(pairs) > 0 && head != "" {
+			pairs[0][0].HeadComment = head + "\n\n" + pairs[0][0].HeadComment
+		}
+		node.Content = node.Content[:0]
+		for _, pair := range pairs {
+			node.Content = append(node.Content, pair[0], pair[1])
+		}
+	}
+}
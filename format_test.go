@@ -0,0 +1,244 @@
+package ownershit
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFormatConfiguration(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"repositories.yaml": `# acme settings
+
+# services we run
+repositories:
+  - name: zeta
+    wiki: false
+    security:
+      secret_scanning: true
+  - name: Alpha # primary service
+    default_branch: main
+    issues: true
+    projects: true
+# who can push
+team:
+  - level: push
+    name: devs
+default_wiki: false
+organization: acme
+security:
+  secret_scanning: true
+defaults:
+  issues: true
+`,
+	})
+	path := filepath.Join(dir, "repositories.yaml")
+
+	files, err := FormatConfiguration(path)
+	if err != nil {
+		t.Fatalf("FormatConfiguration() error = %v", err)
+	}
+	if len(files) != 1 || !files[0].Changed() {
+		t.Fatalf("expected one changed file, got %+v", files)
+	}
+	want := `# acme settings
+
+organization: acme
+
+defaults:
+  wiki: false
+  issues: true
+
+# who can push
+team:
+  - name: devs
+    level: push
+
+security:
+  secret_scanning: true
+
+# services we run
+repositories:
+  - name: Alpha # primary service
+    projects: true
+  - name: zeta
+`
+	if got := string(files[0].Formatted); got != want {
+		t.Errorf("formatted configuration:\n%s\nwant:\n%s", got, want)
+	}
+
+	if err := WriteFormattedConfigFile(files[0]); err != nil {
+		t.Fatalf("WriteFormattedConfigFile() error = %v", err)
+	}
+	files, err = FormatConfiguration(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if files[0].Changed() {
+		t.Errorf("formatting should be idempotent, second pass produced:\n%s", files[0].Formatted)
+	}
+}
+
+func TestFormatConfigurationKeepsCommentsWithKeys(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"repositories.yaml": `# Repos we manage
+repositories:
+  - name: api
+organization: acme
+branches:
+  # protection for every repository
+
+  # Pull request requirements
+  require_pull_request_reviews: true
+  require_approving_count: 1
+  require_code_owners: false
+`,
+	})
+
+	files, err := FormatConfiguration(filepath.Join(dir, "repositories.yaml"))
+	if err != nil {
+		t.Fatalf("FormatConfiguration() error = %v", err)
+	}
+	want := `organization: acme
+
+branches:
+  # protection for every repository
+
+  require_code_owners: false
+  require_approving_count: 1
+  # Pull request requirements
+  require_pull_request_reviews: true
+
+# Repos we manage
+repositories:
+  - name: api
+`
+	if got := string(files[0].Formatted); got != want {
+		t.Errorf("formatted configuration:\n%s\nwant:\n%s", got, want)
+	}
+
+	if err := WriteFormattedConfigFile(files[0]); err != nil {
+		t.Fatalf("WriteFormattedConfigFile() error = %v", err)
+	}
+	if files, err = FormatConfiguration(filepath.Join(dir, "repositories.yaml")); err != nil || files[0].Changed() {
+		t.Errorf("formatting should be idempotent, second pass produced:\n%s (%v)", files[0].Formatted, err)
+	}
+}
+
+func TestFormatConfigurationLocatesProblems(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"repositories.yaml": "organization: acme\nrepositories:\n  - name: api\n    wikki: true\n",
+	})
+
+	_, err := FormatConfiguration(filepath.Join(dir, "repositories.yaml"))
+	var problems *ConfigProblemsError
+	if !errors.As(err, &problems) || len(problems.Problems) != 1 {
+		t.Fatalf("FormatConfiguration() error = %v, want one located problem", err)
+	}
+	if got := problems.Problems[0]; got.Line != 4 || got.Column != 5 || !strings.Contains(err.Error(), "repositories[0].wikki") {
+		t.Errorf("problem = %+v, want repositories[0].wikki at 4:5", got)
+	}
+}
+
+func TestFormatConfigurationKeepsExplicitLegacyOverride(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"00-base.yaml":  "organization: acme\ndefaults:\n  wiki: true\n",
+		"10-repos.yaml": "default_wiki: false\ndefault_issues: false\nrepositories:\n  - name: api\n    issues: false\n",
+	})
+
+	files, err := FormatConfiguration(dir)
+	if err != nil {
+		t.Fatalf("FormatConfiguration() error = %v", err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("expected 2 files, got %d", len(files))
+	}
+	// The legacy wiki value never applied because defaults sets it; issues moves to the existing block.
+	if got, want := string(files[0].Formatted), "organization: acme\n\ndefaults:\n  wiki: true\n  issues: false\n"; got != want {
+		t.Errorf("base file = %q, want %q", got, want)
+	}
+	if got, want := string(files[1].Formatted), "repositories:\n  - name: api\n"; got != want {
+		t.Errorf("repository file = %q, want %q", got, want)
+	}
+}
+
+func TestFormatConfigurationKeepsSettingsOverlaysChange(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"config.yaml": "organization: acme\ndefaults:\n  wiki: true\n  issues: true\n" +
+			"repositories:\n  - name: api\n    wiki: true\n    issues: true\n",
+		"overlays/prod.yaml": "defaults:\n  wiki: false\n",
+	})
+
+	files, err := FormatConfiguration(dir)
+	if err != nil {
+		t.Fatalf("FormatConfiguration() error = %v", err)
+	}
+	if len(files) != 1 {
+		t.Fatalf("expected 1 file, got %d", len(files))
+	}
+	// wiki is only inherited without the prod overlay, so removing it would change prod.
+	want := "organization: acme\n\ndefaults:\n  wiki: true\n  issues: true\n\nrepositories:\n  - name: api\n    wiki: true\n"
+	if got := string(files[0].Formatted); got != want {
+		t.Errorf("formatted = %q, want %q", got, want)
+	}
+}
+
+func TestFormatConfigurationProfileDefaults(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"repositories.yaml": `organization: acme
+defaults:
+  wiki: false
+profiles:
+  docs:
+    defaults:
+      wiki: true
+repositories:
+  - name: handbook
+    profile: docs
+    wiki: true
+  - name: api
+    wiki: true
+`,
+	})
+	path := filepath.Join(dir, "repositories.yaml")
+	files, err := FormatConfiguration(path)
+	if err != nil {
+		t.Fatalf("FormatConfiguration() error = %v", err)
+	}
+	if err := WriteFormattedConfigFile(files[0]); err != nil {
+		t.Fatal(err)
+	}
+	written, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `organization: acme
+
+defaults:
+  wiki: false
+
+profiles:
+  docs:
+    defaults:
+      wiki: true
+
+repositories:
+  - name: api
+    wiki: true
+  - name: handbook
+    profile: docs
+`
+	if string(written) != want {
+		t.Errorf("formatted file:\n%s\nwant:\n%s", written, want)
+	}
+}
+
+func TestSeparateTopLevelKeys(t *testing.T) {
+	in := "# head\na: 1\n# about b\nb:\n  - x\n\nc: 2\n"
+	want := "# head\na: 1\n\n# about b\nb:\n  - x\n\nc: 2\n"
+	if got := string(separateTopLevelKeys([]byte(in))); got != want {
+		t.Errorf("separateTopLevelKeys() = %q, want %q", got, want)
+	}
+}
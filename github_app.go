@@ -0,0 +1,261 @@
+package ownershit
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v66/github"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/oauth2"
+)
+
+// Environment variables configuring GitHub App authentication.
+const (
+	EnvGitHubAppID             = "GITHUB_APP_ID"
+	EnvGitHubAppPrivateKey     = "GITHUB_APP_PRIVATE_KEY"
+	EnvGitHubAppPrivateKeyFile = "GITHUB_APP_PRIVATE_KEY_FILE"
+)
+
+const (
+	// appJWTLifetime is the lifetime of app JWTs; GitHub rejects JWTs valid for over ten minutes.
+	appJWTLifetime = 9 * time.Minute
+	// appJWTClockSkew backdates JWTs to tolerate clock drift between the host and GitHub.
+	appJWTClockSkew = time.Minute
+	// installationTokenRefreshMargin is how long before expiry an installation token is replaced.
+	installationTokenRefreshMargin = 5 * time.Minute
+)
+
+// Sentinel errors for GitHub App authentication.
+var (
+	ErrGitHubAppID           = errors.New("invalid GitHub App ID")
+	ErrGitHubAppPrivateKey   = errors.New("invalid GitHub App private key")
+	ErrGitHubAppInstallation = errors.New("GitHub App installation not found")
+)
+
+// GitHubApp authenticates as a GitHub App, minting installation tokens for the organizations the
+// app is installed in. Installation tokens are issued by GitHub, so they bypass
+// ValidateGitHubToken.
+type GitHubApp struct {
+	ID        int64
+	key       *rsa.PrivateKey
+	endpoints GitHubEndpoints
+	// now is the clock used for JWTs, replaced in tests.
+	now func() time.Time
+}
+
+// NewGitHubApp creates a GitHub App from its ID and PEM-encoded private key, for the GitHub
+// instance at baseURL; see ResolveGitHubEndpoints.
+func NewGitHubApp(appID int64, privateKeyPEM []byte, baseURL string) (*GitHubApp, error) {
+	if appID <= 0 {
+		return nil, fmt.Errorf("%w: %d", ErrGitHubAppID, appID)
+	}
+	key, err := parseAppPrivateKey(privateKeyPEM)
+	if err != nil {
+		return nil, err
+	}
+	endpoints, err := ResolveGitHubEndpoints(baseURL)
+	if err != nil {
+		return nil, err
+	}
+	return &GitHubApp{ID: appID, key: key, endpoints: endpoints, now: time.Now}, nil
+}
+
+// LoadGitHubApp creates a GitHub App from an app ID and the private key in keyFile or, when
+// keyFile is empty, in GITHUB_APP_PRIVATE_KEY. It returns nil without an error when appID is
+// empty, meaning token authentication is used.
+func LoadGitHubApp(appID, keyFile, baseURL string) (*GitHubApp, error) {
+	if strings.TrimSpace(appID) == "" {
+		return nil, nil
+	}
+	id, err := strconv.ParseInt(strings.TrimSpace(appID), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w %q: %w", ErrGitHubAppID, appID, err)
+	}
+
+	var keyPEM []byte
+	switch {
+	case keyFile != "":
+		if keyPEM, err = os.ReadFile(keyFile); err != nil { // #nosec G304 - path is supplied by the user
+			return nil, fmt.Errorf("%w: reading %s: %w", ErrGitHubAppPrivateKey, keyFile, err)
+		}
+	case os.Getenv(EnvGitHubAppPrivateKey) != "":
+		keyPEM = []byte(os.Getenv(EnvGitHubAppPrivateKey))
+	default:
+		return nil, fmt.Errorf("%w: set %s or %s", ErrGitHubAppPrivateKey, EnvGitHubAppPrivateKeyFile, EnvGitHubAppPrivateKey)
+	}
+	return NewGitHubApp(id, keyPEM, baseURL)
+}
+
+// parseAppPrivateKey decodes a PKCS#1 or PKCS#8 RSA private key. Keys passed through environment
+// variables often have their newlines escaped as \n, which is undone first.
+func parseAppPrivateKey(data []byte) (*rsa.PrivateKey, error) {
+	text := strings.TrimSpace(string(data))
+	if !strings.Contains(text, "\n") {
+		text = strings.ReplaceAll(text, `\n`, "\n")
+	}
+	block, _ := pem.Decode([]byte(text))
+	if block == nil {
+		return nil, fmt.Errorf("%w: no PEM block found", ErrGitHubAppPrivateKey)
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrGitHubAppPrivateKey, err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%w: not an RSA key", ErrGitHubAppPrivateKey)
+	}
+	return key, nil
+}
+
+// JWT returns a signed JSON Web Token authenticating as the app itself.
+func (a *GitHubApp) JWT() (string, error) {
+	now := a.now()
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RS256","typ":"JWT"}`))
+	claims, err := json.Marshal(map[string]int64{
+		"iat": now.Add(-appJWTClockSkew).Unix(),
+		"exp": now.Add(appJWTLifetime).Unix(),
+		"iss": a.ID,
+	})
+	if err != nil {
+		return "", fmt.Errorf("encoding GitHub App JWT claims: %w", err)
+	}
+	unsigned := header + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, a.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("signing GitHub App JWT: %w", err)
+	}
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// RoundTrip authenticates requests made as the app with a fresh JWT.
+func (a *GitHubApp) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := a.JWT()
+	if err != nil {
+		return nil, err
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+token)
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// InstallationTokenSource returns a token source minting installation tokens for the app's
+// installation in org. Tokens are reused until shortly before they expire. An empty org selects
+// the app's only installation.
+func (a *GitHubApp) InstallationTokenSource(ctx context.Context, org string) (oauth2.TokenSource, error) {
+	cfg, err := RetryConfigFromEnv()
+	if err != nil {
+		return nil, fmt.Errorf("configuring GitHub client retries: %w", err)
+	}
+	client, err := newRESTClient(StandardRetryClient(NewRetryClient(cfg, a)), a.endpoints)
+	if err != nil {
+		return nil, err
+	}
+	source := &installationTokenSource{ctx: ctx, apps: client.Apps, org: org}
+	return oauth2.ReuseTokenSourceWithExpiry(nil, source, installationTokenRefreshMargin), nil
+}
+
+// installationTokenSource exchanges the app JWT for installation tokens.
+type installationTokenSource struct {
+	ctx  context.Context
+	apps *github.AppsService
+	org  string
+
+	mu             sync.Mutex
+	installationID int64
+}
+
+// Token mints a new installation token, looking the installation up on first use.
+func (s *installationTokenSource) Token() (*oauth2.Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.installationID == 0 {
+		id, err := s.findInstallation()
+		if err != nil {
+			return nil, err
+		}
+		s.installationID = id
+	}
+	token, resp, err := s.apps.CreateInstallationToken(s.ctx, s.installationID, nil)
+	if err != nil {
+		return nil, classifyAPIError(responseStatus(resp), "create installation token", s.org,
+			"failed to mint a GitHub App installation token", err)
+	}
+	log.Debug().
+		Str("organization", s.org).
+		Int64("installationID", s.installationID).
+		Time("expiresAt", token.GetExpiresAt().Time).
+		Msg("minted GitHub App installation token")
+	return &oauth2.Token{AccessToken: token.GetToken(), Expiry: token.GetExpiresAt().Time}, nil
+}
+
+func (s *installationTokenSource) findInstallation() (int64, error) {
+	if s.org != "" {
+		installation, resp, err := s.apps.FindOrganizationInstallation(s.ctx, s.org)
+		if err != nil {
+			if responseStatus(resp) == http.StatusNotFound {
+				return 0, fmt.Errorf("%w for organization %s", ErrGitHubAppInstallation, s.org)
+			}
+			return 0, classifyAPIError(responseStatus(resp), "find app installation", s.org,
+				"failed to look up the GitHub App installation", err)
+		}
+		return installation.GetID(), nil
+	}
+
+	installations, resp, err := s.apps.ListInstallations(s.ctx, &github.ListOptions{PerPage: 2})
+	if err != nil {
+		return 0, classifyAPIError(responseStatus(resp), "list app installations", "",
+			"failed to list GitHub App installations", err)
+	}
+	if len(installations) != 1 {
+		return 0, fmt.Errorf("%w: the app has %d installations; name the organization", ErrGitHubAppInstallation, len(installations))
+	}
+	return installations[0].GetID(), nil
+}
+
+// NewGitHubAppClient creates a GitHub client authenticated as the app's installation in org. The
+// first installation token is minted immediately so configuration problems surface early.
+func NewGitHubAppClient(ctx context.Context, app *GitHubApp, org string) (*GitHubClient, error) {
+	source, err := app.InstallationTokenSource(ctx, org)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := source.Token(); err != nil {
+		return nil, fmt.Errorf("authenticating as GitHub App %d: %w", app.ID, err)
+	}
+	// Installation tokens rotate hourly; the cache is keyed on the installation instead.
+	tc, err := newTokenHTTPClient(source, CacheScope("app", strconv.FormatInt(app.ID, 10), org))
+	if err != nil {
+		return nil, fmt.Errorf("configuring GitHub client retries: %w", err)
+	}
+	client, err := newGitHubClientForEndpoints(ctx, tc, app.endpoints)
+	if err != nil {
+		return nil, err
+	}
+	client.tokenKind = TokenKindApp
+	return client, nil
+}
+
+// Endpoints returns the endpoints of the GitHub instance the app belongs to.
+func (a *GitHubApp) Endpoints() GitHubEndpoints {
+	return a.endpoints
+}
@@ -0,0 +1,206 @@
+package ownershit
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func testAppKey(t *testing.T) (*rsa.PrivateKey, []byte) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+}
+
+// verifyAppJWT checks the signature of token and returns its claims.
+func verifyAppJWT(t *testing.T, key *rsa.PrivateKey, token string) map[string]int64 {
+	t.Helper()
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		t.Fatalf("malformed JWT %q", token)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], signature); err != nil {
+		t.Fatalf("JWT signature: %v", err)
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var claims map[string]int64
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		t.Fatal(err)
+	}
+	return claims
+}
+
+// fakeAppServer serves the GitHub App endpoints of a GitHub Enterprise Server instance, issuing
+// installation tokens that expire after lifetime.
+type fakeAppServer struct {
+	*httptest.Server
+	t        *testing.T
+	key      *rsa.PrivateKey
+	lifetime time.Duration
+
+	mu     sync.Mutex
+	minted int
+	auth   []string
+}
+
+func newFakeAppServer(t *testing.T, key *rsa.PrivateKey, lifetime time.Duration) *fakeAppServer {
+	t.Helper()
+	f := &fakeAppServer{t: t, key: key, lifetime: lifetime}
+	f.Server = httptest.NewServer(http.HandlerFunc(f.serve))
+	t.Cleanup(f.Close)
+	return f
+}
+
+func (f *fakeAppServer) serve(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	authorization := r.Header.Get("Authorization")
+	switch {
+	case r.URL.Path == "/api/v3/orgs/acme/installation":
+		verifyAppJWT(f.t, f.key, strings.TrimPrefix(authorization, "Bearer "))
+		_, _ = w.Write([]byte(`{"id":42}`))
+	case r.URL.Path == "/api/v3/orgs/other/installation":
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"message":"Not Found"}`))
+	case r.URL.Path == "/api/v3/app/installations/42/access_tokens" && r.Method == http.MethodPost:
+		verifyAppJWT(f.t, f.key, strings.TrimPrefix(authorization, "Bearer "))
+		f.minted++
+		expires := time.Now().Add(f.lifetime).UTC().Format(time.RFC3339)
+		fmt.Fprintf(w, `{"token":"ghs_installation%d","expires_at":%q}`, f.minted, expires)
+	default:
+		f.auth = append(f.auth, authorization)
+		_, _ = w.Write([]byte(`{"login":"acme"}`))
+	}
+}
+
+func TestGitHubAppJWT(t *testing.T) {
+	key, keyPEM := testAppKey(t)
+	app, err := NewGitHubApp(1234, keyPEM, "")
+	if err != nil {
+		t.Fatalf("NewGitHubApp() error = %v", err)
+	}
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	app.now = func() time.Time { return now }
+
+	token, err := app.JWT()
+	if err != nil {
+		t.Fatalf("JWT() error = %v", err)
+	}
+	claims := verifyAppJWT(t, key, token)
+	if claims["iss"] != 1234 || claims["iat"] != now.Add(-time.Minute).Unix() || claims["exp"] != now.Add(9*time.Minute).Unix() {
+		t.Errorf("claims = %v", claims)
+	}
+}
+
+func TestNewGitHubAppClient(t *testing.T) {
+	key, keyPEM := testAppKey(t)
+	server := newFakeAppServer(t, key, time.Hour)
+	app, err := NewGitHubApp(1234, keyPEM, server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := NewGitHubAppClient(context.Background(), app, "acme")
+	if err != nil {
+		t.Fatalf("NewGitHubAppClient() error = %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, _, err := client.Organizations.Get(context.Background(), "acme"); err != nil {
+			t.Fatalf("Organizations.Get() error = %v", err)
+		}
+	}
+	// The installation token is not a valid personal access token, and is used without validation.
+	if server.minted != 1 || server.auth[0] != "Bearer ghs_installation1" || server.auth[1] != server.auth[0] {
+		t.Errorf("minted %d tokens, requests authorized with %v", server.minted, server.auth)
+	}
+
+	if _, err := NewGitHubAppClient(context.Background(), app, "other"); !errors.Is(err, ErrGitHubAppInstallation) {
+		t.Errorf("expected ErrGitHubAppInstallation, got %v", err)
+	}
+}
+
+func TestGitHubAppInstallationTokenRefresh(t *testing.T) {
+	key, keyPEM := testAppKey(t)
+	// Tokens expiring within the refresh margin are replaced on every use.
+	server := newFakeAppServer(t, key, 2*time.Minute)
+	app, err := NewGitHubApp(1234, keyPEM, server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	source, err := app.InstallationTokenSource(context.Background(), "acme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, err := source.Token()
+	if err != nil {
+		t.Fatalf("Token() error = %v", err)
+	}
+	second, err := source.Token()
+	if err != nil {
+		t.Fatalf("Token() error = %v", err)
+	}
+	if first.AccessToken == second.AccessToken || server.minted != 2 {
+		t.Errorf("expected a refreshed token, got %q then %q", first.AccessToken, second.AccessToken)
+	}
+}
+
+func TestLoadGitHubApp(t *testing.T) {
+	_, keyPEM := testAppKey(t)
+	t.Setenv(EnvGitHubAppPrivateKey, "")
+
+	if app, err := LoadGitHubApp("", "", ""); app != nil || err != nil {
+		t.Errorf("LoadGitHubApp() without an app ID = %v, %v", app, err)
+	}
+	if _, err := LoadGitHubApp("abc", "", ""); !errors.Is(err, ErrGitHubAppID) {
+		t.Errorf("expected ErrGitHubAppID, got %v", err)
+	}
+	if _, err := LoadGitHubApp("1234", "", ""); !errors.Is(err, ErrGitHubAppPrivateKey) {
+		t.Errorf("expected ErrGitHubAppPrivateKey without a key, got %v", err)
+	}
+
+	keyFile := filepath.Join(t.TempDir(), "app.pem")
+	if err := os.WriteFile(keyFile, keyPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if app, err := LoadGitHubApp("1234", keyFile, ""); err != nil || app.ID != 1234 {
+		t.Errorf("LoadGitHubApp() from file = %v, %v", app, err)
+	}
+
+	// Keys in environment variables often carry escaped newlines.
+	t.Setenv(EnvGitHubAppPrivateKey, strings.ReplaceAll(string(keyPEM), "\n", `\n`))
+	if _, err := LoadGitHubApp("1234", "", ""); err != nil {
+		t.Errorf("LoadGitHubApp() from environment error = %v", err)
+	}
+	t.Setenv(EnvGitHubAppPrivateKey, "not a key")
+	if _, err := LoadGitHubApp("1234", "", ""); !errors.Is(err, ErrGitHubAppPrivateKey) {
+		t.Errorf("expected ErrGitHubAppPrivateKey, got %v", err)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
 	"net/http/httputil"
 	"sort"
 	"strings"
@@ -19,19 +20,31 @@ import (
 // GitHubClient is a wrapper client to both the V3 REST API and V4 GraphQL API, with support for a subset of GitHub services,
 // mainly around repository management itself.
 type GitHubClient struct {
-	Teams        TeamsService
-	Repositories RepositoriesService
-	Issues       IssuesService
-	Graph        GraphQLClient
-	v3           *github.Client
-	v4           *githubv4.Client
-	Context      context.Context
+	Teams         TeamsService
+	Repositories  RepositoriesService
+	Issues        IssuesService
+	Git           GitService
+	Organizations OrganizationsService
+	PullRequests  PullRequestsService
+	Graph         GraphQLClient
+	v3            *github.Client
+	v4            *githubv4.Client
+	Context       context.Context
+	// server is set for GitHub Enterprise Server, whose version determines the supported features.
+	server *serverInfo
+	// tokenKind is the kind of token the client authenticates with; see TokenKind.
+	tokenKind string
 }
 
 // TeamsService is a wrapper interface for the GitHub V3 API to support mocking and testing for the Teams API endpoints.
 type TeamsService interface {
 	GetTeamBySlug(ctx context.Context, org, slug string) (*github.Team, *github.Response, error)
 	AddTeamRepoBySlug(ctx context.Context, org, slug, owner, repo string, opts *github.TeamAddTeamRepoOptions) (*github.Response, error)
+	CreateTeam(ctx context.Context, org string, team github.NewTeam) (*github.Team, *github.Response, error)
+	EditTeamBySlug(ctx context.Context, org, slug string, team github.NewTeam, removeParent bool) (*github.Team, *github.Response, error)
+	ListTeamMembersBySlug(ctx context.Context, org, slug string, opts *github.TeamListTeamMembersOptions) ([]*github.User, *github.Response, error)
+	AddTeamMembershipBySlug(ctx context.Context, org, slug, user string, opts *github.TeamAddTeamMembershipOptions) (*github.Membership, *github.Response, error)
+	RemoveTeamMembershipBySlug(ctx context.Context, org, slug, user string) (*github.Response, error)
 }
 
 // IssuesService is a wrapper interface for the GitHub V3 REST API for Issues management.  This interface is used for
@@ -45,12 +58,50 @@ type IssuesService interface {
 
 // RepositoriesService is a wrapper interface for the GitHub V3 API to support mocking and testing for the Repository API endpoints.
 type RepositoriesService interface {
+	ListByOrg(ctx context.Context, org string, opts *github.RepositoryListByOrgOptions) ([]*github.Repository, *github.Response, error)
 	Edit(ctx context.Context, org, repo string, repository *github.Repository) (*github.Repository, *github.Response, error)
 	Get(ctx context.Context, owner, repo string) (*github.Repository, *github.Response, error)
 	ListTeams(ctx context.Context, owner string, repo string, opts *github.ListOptions) ([]*github.Team, *github.Response, error)
 	GetBranchProtection(ctx context.Context, owner, repo, branch string) (*github.Protection, *github.Response, error)
 	ListAllTopics(ctx context.Context, owner, repo string) ([]string, *github.Response, error)
 	ReplaceAllTopics(ctx context.Context, owner, repo string, topics []string) ([]string, *github.Response, error)
+	GetContents(ctx context.Context, owner, repo, path string, opts *github.RepositoryContentGetOptions) (*github.RepositoryContent, []*github.RepositoryContent, *github.Response, error)
+	CreateFile(ctx context.Context, owner, repo, path string, opts *github.RepositoryContentFileOptions) (*github.RepositoryContentResponse, *github.Response, error)
+	UpdateFile(ctx context.Context, owner, repo, path string, opts *github.RepositoryContentFileOptions) (*github.RepositoryContentResponse, *github.Response, error)
+	GetVulnerabilityAlerts(ctx context.Context, owner, repo string) (bool, *github.Response, error)
+	EnableVulnerabilityAlerts(ctx context.Context, owner, repo string) (*github.Response, error)
+	DisableVulnerabilityAlerts(ctx context.Context, owner, repo string) (*github.Response, error)
+	GetAutomatedSecurityFixes(ctx context.Context, owner, repo string) (*github.AutomatedSecurityFixes, *github.Response, error)
+	EnableAutomatedSecurityFixes(ctx context.Context, owner, repo string) (*github.Response, error)
+	DisableAutomatedSecurityFixes(ctx context.Context, owner, repo string) (*github.Response, error)
+	IsPrivateReportingEnabled(ctx context.Context, owner, repo string) (bool, *github.Response, error)
+	EnablePrivateReporting(ctx context.Context, owner, repo string) (*github.Response, error)
+	DisablePrivateReporting(ctx context.Context, owner, repo string) (*github.Response, error)
+	ListAutolinks(ctx context.Context, owner, repo string, opts *github.ListOptions) ([]*github.Autolink, *github.Response, error)
+	AddAutolink(ctx context.Context, owner, repo string, opts *github.AutolinkOptions) (*github.Autolink, *github.Response, error)
+	DeleteAutolink(ctx context.Context, owner, repo string, id int64) (*github.Response, error)
+	GetAllCustomPropertyValues(ctx context.Context, org, repo string) ([]*github.CustomPropertyValue, *github.Response, error)
+	CreateOrUpdateCustomProperties(ctx context.Context, org, repo string, values []*github.CustomPropertyValue) (*github.Response, error)
+}
+
+// OrganizationsService is a wrapper interface for the GitHub V3 API to support mocking and testing for the Organizations API endpoints.
+type OrganizationsService interface {
+	Get(ctx context.Context, org string) (*github.Organization, *github.Response, error)
+	Edit(ctx context.Context, name string, org *github.Organization) (*github.Organization, *github.Response, error)
+	GetAllCustomProperties(ctx context.Context, org string) ([]*github.CustomProperty, *github.Response, error)
+	CreateOrUpdateCustomProperties(ctx context.Context, org string, properties []*github.CustomProperty) ([]*github.CustomProperty, *github.Response, error)
+	ListCustomPropertyValues(ctx context.Context, org string, opts *github.ListOptions) ([]*github.RepoCustomPropertyValue, *github.Response, error)
+}
+
+// GitService is a wrapper interface for the GitHub V3 Git Database API endpoints used to manage branch references.
+type GitService interface {
+	GetRef(ctx context.Context, owner, repo, ref string) (*github.Reference, *github.Response, error)
+	CreateRef(ctx context.Context, owner, repo string, ref *github.Reference) (*github.Reference, *github.Response, error)
+}
+
+// PullRequestsService is a wrapper interface for the GitHub V3 Pull Requests API endpoints.
+type PullRequestsService interface {
+	Create(ctx context.Context, owner, repo string, pull *github.NewPullRequest) (*github.PullRequest, *github.Response, error)
 }
 
 // NewGitHubClient creates a new GitHub context using OAuth2.
@@ -59,47 +110,105 @@ type RepositoriesService interface {
 func NewGitHubClient(ctx context.Context, staticToken string) *GitHubClient {
 	ts := oauth2.StaticTokenSource(
 		&oauth2.Token{AccessToken: staticToken})
-	tc := oauth2.NewClient(ctx, ts)
-
-	client := github.NewClient(tc)
-	clientV4 := githubv4.NewClient(tc)
-
-	return &GitHubClient{
-		Teams:        client.Teams,
-		Repositories: client.Repositories,
-		Issues:       client.Issues,
-		v3:           client,
-		v4:           clientV4,
-		Graph:        clientV4,
-		Context:      ctx,
+	cfg, err := RetryConfigFromEnv()
+	if err != nil {
+		log.Warn().Err(err).Msg("invalid retry configuration; using defaults")
+		cfg = DefaultRetryConfig()
 	}
+	tc := newTokenHTTPClientWithConfig(ts, CacheScope("token", staticToken), cfg)
+
+	client, _ := newGitHubClientForEndpoints(ctx, tc, defaultGitHubEndpoints())
+	return client
 }
 
 // NewSecureGitHubClient creates a new GitHub client with validated token from environment.
 func NewSecureGitHubClient(ctx context.Context) (*GitHubClient, error) {
-	token, err := GetValidatedGitHubToken()
+	return NewSecureGitHubClientFromEnv(ctx, DefaultTokenEnv)
+}
+
+// NewSecureGitHubClientFromEnv creates a new GitHub client with the validated token held in the
+// named environment variable, for the GitHub instance named by GITHUB_API_URL.
+func NewSecureGitHubClientFromEnv(ctx context.Context, tokenEnv string) (*GitHubClient, error) {
+	return NewSecureGitHubClientForURL(ctx, tokenEnv, GitHubURLFromEnv())
+}
+
+// NewSecureGitHubClientForURL creates a new GitHub client with the validated token held in the
+// named environment variable, for the GitHub instance at baseURL; see ResolveGitHubEndpoints.
+func NewSecureGitHubClientForURL(ctx context.Context, tokenEnv, baseURL string) (*GitHubClient, error) {
+	return NewSecureGitHubClientFromSource(ctx, EnvTokenSource{Name: tokenEnv}, baseURL)
+}
+
+// NewSecureGitHubClientFromSource creates a new GitHub client with the validated token from
+// source, for the GitHub instance at baseURL.
+func NewSecureGitHubClientFromSource(ctx context.Context, source TokenSource, baseURL string) (*GitHubClient, error) {
+	endpoints, err := ResolveGitHubEndpoints(baseURL)
+	if err != nil {
+		return nil, err
+	}
+	token, err := ValidatedToken(ctx, source)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get validated GitHub token: %w", err)
 	}
 
 	ts := oauth2.StaticTokenSource(
 		&oauth2.Token{AccessToken: token})
-	tc := oauth2.NewClient(ctx, ts)
+	tc, err := newTokenHTTPClient(ts, CacheScope("token", token))
+	if err != nil {
+		return nil, fmt.Errorf("configuring GitHub client retries: %w", err)
+	}
+
+	client, err := newGitHubClientForEndpoints(ctx, tc, endpoints)
+	if err != nil {
+		return nil, err
+	}
+	client.tokenKind = TokenKind(token)
+	return client, nil
+}
 
-	client := github.NewClient(tc)
-	clientV4 := githubv4.NewClient(tc)
+// newGitHubClientForEndpoints wires the REST and GraphQL clients to the given endpoints. Both share
+// tc, whose transport handles authentication, retries and rate limits.
+func newGitHubClientForEndpoints(ctx context.Context, tc *http.Client, endpoints GitHubEndpoints) (*GitHubClient, error) {
+	client, err := newRESTClient(tc, endpoints)
+	if err != nil {
+		return nil, err
+	}
+	// The GraphQL client drops the type and status of errors, so its responses are recorded first.
+	gc := &http.Client{Transport: &graphQLErrorTransport{base: tc.Transport}, Timeout: tc.Timeout}
+	clientV4 := githubv4.NewClient(gc)
+	var server *serverInfo
+	if endpoints.Enterprise {
+		clientV4 = githubv4.NewEnterpriseClient(endpoints.GraphQL, gc)
+		server = &serverInfo{}
+	}
 
 	return &GitHubClient{
-		Teams:        client.Teams,
-		Repositories: client.Repositories,
-		Issues:       client.Issues,
-		v3:           client,
-		v4:           clientV4,
-		Graph:        clientV4,
-		Context:      ctx,
+		Teams:         client.Teams,
+		Repositories:  client.Repositories,
+		Issues:        client.Issues,
+		Git:           client.Git,
+		Organizations: client.Organizations,
+		PullRequests:  client.PullRequests,
+		v3:            client,
+		v4:            clientV4,
+		Graph:         &graphQLClient{client: clientV4},
+		Context:       ctx,
+		server:        server,
 	}, nil
 }
 
+// newRESTClient creates a go-github client for the REST endpoints.
+func newRESTClient(httpClient *http.Client, endpoints GitHubEndpoints) (*github.Client, error) {
+	client := github.NewClient(httpClient)
+	if !endpoints.Enterprise {
+		return client, nil
+	}
+	client, err := client.WithEnterpriseURLs(endpoints.REST, endpoints.Upload)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidGitHubURL, err)
+	}
+	return client, nil
+}
+
 // AddPermissions adds a given team level repository permission.
 func (c *GitHubClient) AddPermissions(organization, repo string, perm *Permissions) error {
 	if perm == nil || perm.Team == nil || perm.Level == nil {
@@ -128,7 +237,8 @@ func (c *GitHubClient) AddPermissions(organization, repo string, perm *Permissio
 			}
 		}
 		logEvent.Msg("error adding team as collaborator to repo")
-		return fmt.Errorf("adding team as collaborator to repo: %w", err)
+		return classifyAPIError(responseStatus(resp), "add team permissions", fmt.Sprintf("%s/%s", organization, repo),
+			fmt.Sprintf("failed to add team %s as collaborator", *perm.Team), err)
 	}
 	log.Info().Int("status-code", resp.StatusCode).Msg("Successfully set repo")
 	return nil
@@ -165,7 +275,7 @@ func (c *GitHubClient) UpdateBranchPermissions(org, repo string, perms *BranchPe
 			}
 		}
 		logEvent.Msg("Error updating repository settings")
-		return NewGitHubAPIError(statusCode, "update repository settings",
+		return classifyAPIError(statusCode, "update repository settings",
 			fmt.Sprintf("%s/%s", org, repo), "failed to update repository settings", err)
 	}
 
@@ -204,7 +314,7 @@ func (c *GitHubClient) SetRepositoryAdvancedSettings(org, repo string, deleteBra
 			}
 		}
 		logEvent.Msg("Error setting advanced repository settings")
-		return NewGitHubAPIError(statusCode, "set advanced repository settings",
+		return classifyAPIError(statusCode, "set advanced repository settings",
 			fmt.Sprintf("%s/%s", org, repo), "failed to set advanced repository settings", err)
 	}
 
@@ -248,7 +358,7 @@ func (c *GitHubClient) SetBranchProtectionFallback(org, repo, branch string, per
 			Int("statusCode", statusCode).
 			Str("operation", "updateBranchProtection").
 			Msg("Error updating branch protection via REST API")
-		return NewGitHubAPIError(statusCode, "update branch protection",
+		return classifyAPIError(statusCode, "update branch protection",
 			fmt.Sprintf("%s/%s", org, repo), "failed to set branch protection via REST API", err)
 	}
 
@@ -349,7 +459,7 @@ func (c *GitHubClient) SyncLabels(org, repo string, labels []RepoLabel) error {
 		PerPage: 100,
 	})
 	if err != nil {
-		return fmt.Errorf("listing labels for %v/%v: %w", org, repo, err)
+		return classifyAPIError(responseStatus(resp), "list repository labels", fmt.Sprintf("%s/%s", org, repo), "failed to list labels", err)
 	}
 	if resp != nil && resp.Response != nil && log.Debug().Enabled() {
 		dumpedResp, _ := httputil.DumpResponse(resp.Response, false)
@@ -382,7 +492,7 @@ func (c *GitHubClient) SyncLabels(org, repo string, labels []RepoLabel) error {
 			Description: &create.Description,
 		})
 		if err != nil {
-			return NewGitHubAPIError(0, "create label", fmt.Sprintf("%s/%s", org, repo), fmt.Sprintf("failed to create label %s", create.Name), err)
+			return classifyAPIError(0, "create label", fmt.Sprintf("%s/%s", org, repo), fmt.Sprintf("failed to create label %s", create.Name), err)
 		}
 		if resp != nil && resp.Response != nil && log.Debug().Enabled() {
 			dumpedResp, _ := httputil.DumpResponse(resp.Response, false)
@@ -400,7 +510,7 @@ func (c *GitHubClient) SyncLabels(org, repo string, labels []RepoLabel) error {
 			Description: &edit.label.Description,
 		})
 		if err != nil {
-			return NewGitHubAPIError(0, "edit label", fmt.Sprintf("%s/%s", org, repo), fmt.Sprintf("failed to edit label %s", edit.label.Name), err)
+			return classifyAPIError(0, "edit label", fmt.Sprintf("%s/%s", org, repo), fmt.Sprintf("failed to edit label %s", edit.label.Name), err)
 		}
 		if resp != nil && resp.Response != nil && log.Debug().Enabled() {
 			dumpedResp, _ := httputil.DumpResponse(resp.Response, false)
@@ -433,7 +543,7 @@ func (c *GitHubClient) SyncTopics(org, repo string, topics []string, additive bo
 		// Get existing topics using ListAllTopics (Repositories.Get does not return topics)
 		existingTopics, resp, err := c.Repositories.ListAllTopics(c.Context, org, repo)
 		if err != nil {
-			return fmt.Errorf("listing topics for %v/%v: %w", org, repo, err)
+			return classifyAPIError(responseStatus(resp), "list topics", fmt.Sprintf("%s/%s", org, repo), "failed to list topics", err)
 		}
 		if resp != nil && resp.Response != nil && log.Debug().Enabled() {
 			dumpedResp, _ := httputil.DumpResponse(resp.Response, false)
@@ -477,7 +587,7 @@ func (c *GitHubClient) SyncTopics(org, repo string, topics []string, additive bo
 	// Update repository topics
 	_, resp, err := c.Repositories.ReplaceAllTopics(c.Context, org, repo, finalTopics)
 	if err != nil {
-		return NewGitHubAPIError(0, "replace topics", fmt.Sprintf("%s/%s", org, repo), "failed to update repository topics", err)
+		return classifyAPIError(0, "replace topics", fmt.Sprintf("%s/%s", org, repo), "failed to update repository topics", err)
 	}
 	if resp != nil && resp.Response != nil && log.Debug().Enabled() {
 		dumpedResp, _ := httputil.DumpResponse(resp.Response, false)
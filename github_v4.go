@@ -4,6 +4,7 @@ package ownershit
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -20,18 +21,14 @@ type GraphQLClient interface {
 
 // SetRepository updates repository-level feature flags (wiki, issues, projects,
 // discussions, sponsorships) for the given repository ID using the GraphQL API.
-// Only non-nil fields are applied.
+// Only non-nil fields are applied, and fields the GitHub Enterprise Server version lacks are skipped.
 func (c *GitHubClient) SetRepository(id githubv4.ID, wiki, issues, project, discussions, sponsorships *bool) error {
 	var mutation struct {
 		UpdateRepository struct {
 			ClientMutationID githubv4.ID
-			Repository       struct {
-				ID                     githubv4.ID
-				HasWikiEnabled         githubv4.Boolean
-				HasIssuesEnabled       githubv4.Boolean
-				HasProjectsEnabled     githubv4.Boolean
-				HasDiscussionsEnabled  githubv4.Boolean
-				HasSponsorshipsEnabled githubv4.Boolean
+			// Only the ID is selected: older GitHub Enterprise Server schemas lack the feature fields.
+			Repository struct {
+				ID githubv4.ID
 			}
 		} `graphql:"updateRepository(input: $input)"`
 	}
@@ -50,11 +47,12 @@ func (c *GitHubClient) SetRepository(id githubv4.ID, wiki, issues, project, disc
 		inputRepo.HasProjectsEnabled = githubv4.NewBoolean(githubv4.Boolean(*project))
 	}
 
-	// Only set optional fields if they are not nil
-	if discussions != nil {
+	// Only set optional fields if they are not nil and the server supports them
+	target := fmt.Sprint(id)
+	if discussions != nil && c.supportsSetting(FeatureDiscussions, "discussions_enabled", target) {
 		inputRepo.HasDiscussionsEnabled = githubv4.NewBoolean(githubv4.Boolean(*discussions))
 	}
-	if sponsorships != nil {
+	if sponsorships != nil && c.supportsSetting(FeatureSponsorships, "sponsorships_enabled", target) {
 		inputRepo.HasSponsorshipsEnabled = githubv4.NewBoolean(githubv4.Boolean(*sponsorships))
 	}
 	log.Debug().
@@ -67,7 +65,7 @@ func (c *GitHubClient) SetRepository(id githubv4.ID, wiki, issues, project, disc
 			Str("operation", "updateRepository").
 			Interface("repositoryID", id).
 			Msg("updating repository")
-		return NewGitHubAPIError(0, "update repository", "", "failed to update repository settings", err)
+		return classifyAPIError(0, "update repository", "", "failed to update repository settings", err)
 	}
 	return nil
 }
@@ -141,7 +139,7 @@ func (c *GitHubClient) SetEnhancedBranchProtection(id githubv4.ID, branchPattern
 				Str("pattern", branchPattern).
 				Str("reason", "branch protection rule already exists").
 				Msg("GraphQL createBranchProtectionRule failed - will use REST API fallback")
-			return NewGitHubAPIError(0, "create branch protection rule", "",
+			return classifyAPIError(0, "create branch protection rule", "",
 				fmt.Sprintf("branch protection rule already exists for pattern %s, falling back to REST API", branchPattern), err)
 		}
 
@@ -153,7 +151,7 @@ func (c *GitHubClient) SetEnhancedBranchProtection(id githubv4.ID, branchPattern
 			Interface("input", input).
 			Interface("mutationStruct", mutation).
 			Msg("setting enhanced branch protection")
-		return NewGitHubAPIError(0, "create branch protection rule", "",
+		return classifyAPIError(0, "create branch protection rule", "",
 			fmt.Sprintf("failed to create enhanced branch protection rule for pattern %s", branchPattern), err)
 	}
 
@@ -267,6 +265,13 @@ func (c *GitHubClient) GetRepository(name, owner *string) (githubv4.ID, error) {
 			Str("name", *name).
 			Str("operation", "getRepository").
 			Msg("error retrieving repository")
+		// Errors that say nothing about the repository itself are returned as classified.
+		classified := classifyAPIError(0, "get repository", fmt.Sprintf("%s/%s", *owner, *name), "failed to retrieve repository", err)
+		var notFound *RepositoryNotFoundError
+		if errors.As(classified, &notFound) || errors.Is(classified, ErrRateLimit) || errors.Is(classified, ErrAuthentication) ||
+			errors.Is(classified, ErrPermissionDenied) || errors.Is(classified, ErrNetwork) {
+			return nil, classified
+		}
 		return nil, NewRepositoryNotFoundError(*owner, *name, fmt.Errorf("failed to retrieve repository %s/%s: %w", *owner, *name, err))
 	}
 	log.Info().
@@ -283,11 +288,25 @@ type GetRateLimitQuery struct {
 	Viewer struct {
 		Login githubv4.String
 	}
-	RateLimit struct {
-		Limit     githubv4.Int
-		Cost      githubv4.Int
-		Remaining githubv4.Int
-		ResetAt   githubv4.DateTime
+	RateLimit rateLimitFields
+}
+
+// rateLimitFields selects the GraphQL rate limit status, including the cost of the query it is
+// part of.
+type rateLimitFields struct {
+	Limit     githubv4.Int
+	Cost      githubv4.Int
+	Remaining githubv4.Int
+	ResetAt   githubv4.DateTime
+}
+
+func (r rateLimitFields) result(login string) RateLimitResult {
+	return RateLimitResult{
+		Login:     login,
+		Limit:     int(r.Limit),
+		Cost:      int(r.Cost),
+		Remaining: int(r.Remaining),
+		ResetAt:   r.ResetAt.Time,
 	}
 }
 
@@ -307,11 +326,5 @@ func (c *GitHubClient) GetRateLimit() (RateLimitResult, error) {
 	if err := c.Graph.Query(c.Context, query, nil); err != nil {
 		return RateLimitResult{}, fmt.Errorf("error retrieving rate limit: %w", err)
 	}
-	return RateLimitResult{
-		Login:     string(query.Viewer.Login),
-		Limit:     int(query.RateLimit.Limit),
-		Cost:      int(query.RateLimit.Cost),
-		Remaining: int(query.RateLimit.Remaining),
-		ResetAt:   query.RateLimit.ResetAt.Time,
-	}, nil
+	return query.RateLimit.result(string(query.Viewer.Login)), nil
 }
@@ -53,6 +53,12 @@ func ImportRepositoryConfig(owner, repo string, client *GitHubClient, relaxTeamE
 		return nil, fmt.Errorf("failed to get branch protection rules: %w", err)
 	}
 
+	// Get security feature states (best effort; missing permissions leave fields unset)
+	security := getSecuritySettings(client, owner, repo, repoDetails.SecurityAndAnalysis)
+
+	// Get custom property values (best effort)
+	properties := getCustomPropertyValues(client, owner, repo)
+
 	// Get repository labels
 	repoLabels, err := getRepositoryLabels(client, owner, repo)
 	if err != nil {
@@ -78,6 +84,8 @@ func ImportRepositoryConfig(owner, repo string, client *GitHubClient, relaxTeamE
 				Homepage:              repoDetails.Homepage,
 				DeleteBranchOnMerge:   repoDetails.DeleteBranchOnMerge,
 				HasDiscussionsEnabled: repoDetails.HasDiscussionsEnabled,
+				Security:              security,
+				Properties:            properties,
 			},
 		},
 		DefaultLabels: repoLabels,
@@ -99,6 +107,7 @@ type repositoryDetails struct {
 	Homepage              *string
 	DeleteBranchOnMerge   *bool
 	HasDiscussionsEnabled *bool
+	SecurityAndAnalysis   *github.SecurityAndAnalysis
 }
 
 // getRepositoryDetails retrieves basic repository settings via GitHub v3 API.
@@ -110,7 +119,7 @@ func getRepositoryDetails(client *GitHubClient, owner, repo string) (*repository
 
 	repoInfo, _, err := client.Repositories.Get(client.Context, owner, repo)
 	if err != nil {
-		return nil, NewGitHubAPIError(0, "get repository", repo, "failed to fetch repository details", err)
+		return nil, classifyAPIError(0, "get repository", repo, "failed to fetch repository details", err)
 	}
 
 	details := &repositoryDetails{
@@ -125,6 +134,7 @@ func getRepositoryDetails(client *GitHubClient, owner, repo string) (*repository
 		Homepage:              repoInfo.Homepage,
 		DeleteBranchOnMerge:   repoInfo.DeleteBranchOnMerge,
 		HasDiscussionsEnabled: repoInfo.HasDiscussions,
+		SecurityAndAnalysis:   repoInfo.SecurityAndAnalysis,
 	}
 
 	log.Debug().
@@ -143,7 +153,7 @@ func getTeamPermissions(client *GitHubClient, owner, repo string) ([]*Permission
 
 	teams, _, err := client.Repositories.ListTeams(client.Context, owner, repo, nil)
 	if err != nil {
-		return nil, NewGitHubAPIError(0, "list teams", repo, "failed to fetch team permissions", err)
+		return nil, classifyAPIError(0, "list teams", repo, "failed to fetch team permissions", err)
 	}
 
 	var permissions []*Permissions
@@ -341,7 +351,7 @@ func getRepositoryLabels(client *GitHubClient, owner, repo string) ([]RepoLabel,
 	for {
 		labels, resp, err := client.Issues.ListLabels(client.Context, owner, repo, opt)
 		if err != nil {
-			return nil, NewGitHubAPIError(0, "list labels", repo, "failed to fetch repository labels", err)
+			return nil, classifyAPIError(0, "list labels", repo, "failed to fetch repository labels", err)
 		}
 
 		// Convert GitHub labels to RepoLabel format
@@ -555,6 +555,26 @@ func TestImportRepositoryConfig(t *testing.T) {
 		Return(nil, nil, fmt.Errorf("no protection found")).
 		Times(1)
 
+	// For getSecuritySettings
+	mockRepo.EXPECT().
+		GetVulnerabilityAlerts(gomock.Any(), "testowner", "testrepo").
+		Return(true, nil, nil).
+		Times(1)
+	mockRepo.EXPECT().
+		GetAutomatedSecurityFixes(gomock.Any(), "testowner", "testrepo").
+		Return(nil, nil, fmt.Errorf("forbidden")).
+		Times(1)
+	mockRepo.EXPECT().
+		IsPrivateReportingEnabled(gomock.Any(), "testowner", "testrepo").
+		Return(false, nil, nil).
+		Times(1)
+
+	// For getCustomPropertyValues
+	mockRepo.EXPECT().
+		GetAllCustomPropertyValues(gomock.Any(), "testowner", "testrepo").
+		Return([]*github.CustomPropertyValue{{PropertyName: "tier", Value: "critical"}}, nil, nil).
+		Times(1)
+
 	// For getRepositoryLabels
 	mockIssues.EXPECT().
 		ListLabels(gomock.Any(), "testowner", "testrepo", gomock.Any()).
@@ -602,6 +622,23 @@ func TestImportRepositoryConfig(t *testing.T) {
 	if repo.Homepage == nil || *repo.Homepage != "https://example.com" {
 		t.Errorf("expected Homepage to be 'https://example.com', got %v", getStringPointerValue(repo.Homepage))
 	}
+
+	if repo.Security == nil {
+		t.Fatal("expected security settings to be imported")
+	}
+	if getBoolPointerValue(repo.Security.VulnerabilityAlerts) != true {
+		t.Errorf("expected VulnerabilityAlerts to be true, got %v", getBoolPointerValue(repo.Security.VulnerabilityAlerts))
+	}
+	if repo.Security.DependabotSecurityUpdates != nil {
+		t.Errorf("expected DependabotSecurityUpdates to be unset when unreadable")
+	}
+	if getBoolPointerValue(repo.Security.PrivateVulnerabilityReporting) != false {
+		t.Errorf("expected PrivateVulnerabilityReporting to be false")
+	}
+
+	if got := repo.Properties["tier"]; len(got) != 1 || got[0] != "critical" {
+		t.Errorf("expected tier property to be imported, got %v", got)
+	}
 }
 
 func TestImportRepositoryConfig_TeamPermissionsStrictError(t *testing.T) {
@@ -0,0 +1,228 @@
+package ownershit
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Sentinel errors for configuration interpolation.
+var (
+	ErrInterpolationSyntax        = errors.New("invalid interpolation reference")
+	ErrInterpolationUnknownSource = errors.New("unknown interpolation source")
+	ErrInterpolationEnvUnset      = errors.New("environment variable is not set")
+	ErrInterpolationFileRead      = errors.New("failed to read interpolated file")
+)
+
+// Interpolation sources accepted in ${source:argument} references.
+const (
+	interpolationSourceEnv    = "env"
+	interpolationSourceFile   = "file"
+	interpolationSourceSecret = "secret"
+)
+
+// RedactedValue replaces secret values in output.
+const RedactedValue = "[REDACTED]"
+
+// minRedactedLength is the shortest secret value that is redacted. Shorter values cannot be
+// credentials, and masking them would garble unrelated output.
+const minRedactedLength = 4
+
+var envVarName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// interpolateString resolves the ${env:NAME}, ${secret:NAME} and ${file:path} references in s.
+// Relative file paths are resolved against baseDir, and a trailing newline is dropped from file
+// contents. $${ produces a literal ${. The values of ${secret:NAME} references are returned so they
+// can be redacted; other values, such as a branch name, are not secret and stay visible.
+func interpolateString(s, baseDir string) (string, []string, error) {
+	var out strings.Builder
+	var values []string
+	for {
+		start := strings.Index(s, "${")
+		if start < 0 {
+			out.WriteString(s)
+			return out.String(), values, nil
+		}
+		if start > 0 && s[start-1] == '$' {
+			out.WriteString(s[:start-1])
+			out.WriteString("${")
+			s = s[start+2:]
+			continue
+		}
+		out.WriteString(s[:start])
+		end := strings.IndexByte(s[start:], '}')
+		if end < 0 {
+			return "", nil, fmt.Errorf("%w: %q is not terminated by }", ErrInterpolationSyntax, s[start:])
+		}
+		reference := s[start+2 : start+end]
+		value, secret, err := resolveReference(reference, baseDir)
+		if err != nil {
+			return "", nil, err
+		}
+		out.WriteString(value)
+		if secret {
+			values = append(values, value)
+		}
+		s = s[start+end+1:]
+	}
+}
+
+// resolveReference returns the value of a single source:argument reference, and whether it is a
+// secret.
+func resolveReference(reference, baseDir string) (string, bool, error) {
+	source, argument, ok := strings.Cut(reference, ":")
+	if !ok || argument == "" {
+		return "", false, fmt.Errorf("%w: ${%s}; expected ${env:NAME}, ${secret:NAME} or ${file:path}",
+			ErrInterpolationSyntax, reference)
+	}
+	switch source {
+	case interpolationSourceEnv, interpolationSourceSecret:
+		if !envVarName.MatchString(argument) {
+			return "", false, fmt.Errorf("%w: %q is not a valid environment variable name", ErrInterpolationSyntax, argument)
+		}
+		value, ok := os.LookupEnv(argument)
+		if !ok {
+			return "", false, fmt.Errorf("%w: %s", ErrInterpolationEnvUnset, argument)
+		}
+		return value, source == interpolationSourceSecret, nil
+	case interpolationSourceFile:
+		path := argument
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(baseDir, path)
+		}
+		data, err := os.ReadFile(path) // #nosec G304 - paths come from the user's configuration
+		if err != nil {
+			return "", false, fmt.Errorf("%w %s: %w", ErrInterpolationFileRead, argument, err)
+		}
+		return strings.TrimRight(string(data), "\r\n"), false, nil
+	default:
+		return "", false, fmt.Errorf("%w %q in ${%s}", ErrInterpolationUnknownSource, source, reference)
+	}
+}
+
+// interpolate resolves references in every string value below node, registering the values of
+// ${secret:NAME} references for redaction. Relative file references resolve against the directory of the file that
+// defines the value; base is used for nodes whose file is unknown. report is called for each
+// value that cannot be resolved, with the error already carrying the file and line.
+func (l *configLoader) interpolate(node *yaml.Node, base string, report func(*yaml.Node, error)) {
+	switch node.Kind {
+	case yaml.DocumentNode, yaml.SequenceNode:
+		for _, child := range node.Content {
+			l.interpolate(child, base, report)
+		}
+	case yaml.MappingNode:
+		for i := 1; i < len(node.Content); i += 2 {
+			l.interpolate(node.Content[i], base, report)
+		}
+	case yaml.ScalarNode:
+		if node.ShortTag() != "!!str" || !strings.Contains(node.Value, "${") {
+			return
+		}
+		file, baseDir := base, base
+		if source, ok := l.files[node]; ok {
+			file, baseDir = source, filepath.Dir(source)
+		}
+		value, secrets, err := interpolateString(node.Value, baseDir)
+		if err != nil {
+			report(node, NewConfigFileError(file, "interpolate", fmt.Sprintf("line %d: %v", node.Line, err), err))
+			return
+		}
+		for _, secret := range secrets {
+			registerSecret(secret)
+		}
+		node.Value = value
+		node.Style = 0
+	case yaml.AliasNode:
+		// Anchored values are interpolated where they are defined.
+	}
+}
+
+// secretRegistry holds secret values that must not appear in output.
+var secretRegistry struct {
+	sync.RWMutex
+	values   map[string]bool
+	replacer *strings.Replacer
+}
+
+// registerSecret marks value for redaction, along with its JSON-escaped form used in log output.
+func registerSecret(value string) {
+	if len(strings.TrimSpace(value)) < minRedactedLength {
+		return
+	}
+	forms := []string{value}
+	if quoted, err := json.Marshal(value); err == nil {
+		if escaped := string(quoted[1 : len(quoted)-1]); escaped != value {
+			forms = append(forms, escaped)
+		}
+	}
+
+	secretRegistry.Lock()
+	defer secretRegistry.Unlock()
+	if secretRegistry.values == nil {
+		secretRegistry.values = make(map[string]bool)
+	}
+	for _, form := range forms {
+		secretRegistry.values[form] = true
+	}
+	// Longer secrets go first so a secret containing another is replaced whole.
+	secrets := make([]string, 0, len(secretRegistry.values))
+	for secret := range secretRegistry.values {
+		secrets = append(secrets, secret)
+	}
+	sort.Slice(secrets, func(i, j int) bool {
+		if len(secrets[i]) != len(secrets[j]) {
+			return len(secrets[i]) > len(secrets[j])
+		}
+		return secrets[i] < secrets[j]
+	})
+	pairs := make([]string, 0, 2*len(secrets))
+	for _, secret := range secrets {
+		pairs = append(pairs, secret, RedactedValue)
+	}
+	secretRegistry.replacer = strings.NewReplacer(pairs...)
+}
+
+// resetSecrets forgets every registered secret.
+func resetSecrets() {
+	secretRegistry.Lock()
+	defer secretRegistry.Unlock()
+	secretRegistry.values = nil
+	secretRegistry.replacer = nil
+}
+
+// RedactSecrets replaces every ${secret:NAME} configuration value in s with RedactedValue.
+func RedactSecrets(s string) string {
+	secretRegistry.RLock()
+	defer secretRegistry.RUnlock()
+	if secretRegistry.replacer == nil {
+		return s
+	}
+	return secretRegistry.replacer.Replace(s)
+}
+
+type redactingWriter struct {
+	w io.Writer
+}
+
+// NewRedactingWriter returns a writer that redacts secret configuration values before
+// writing to w. Each Write is redacted on its own, so a value split across writes is not caught;
+// log events and encoded documents are written whole.
+func NewRedactingWriter(w io.Writer) io.Writer {
+	return &redactingWriter{w: w}
+}
+
+func (r *redactingWriter) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(r.w, RedactSecrets(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
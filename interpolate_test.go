@@ -0,0 +1,141 @@
+package ownershit
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInterpolateString(t *testing.T) {
+	t.Setenv("OWNERSHIT_TEST_TOKEN", "s3cr3t-token")
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "key.pub"), []byte("ssh-ed25519 AAAA\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		in      string
+		want    string
+		wantErr error
+	}{
+		{"plain", "no references", "no references", nil},
+		{"env", "Bearer ${env:OWNERSHIT_TEST_TOKEN}", "Bearer s3cr3t-token", nil},
+		{"file", "${file:key.pub}", "ssh-ed25519 AAAA", nil},
+		{"secret", "Bearer ${secret:OWNERSHIT_TEST_TOKEN}", "Bearer s3cr3t-token", nil},
+		{"escaped", "cost: $${env:HOME} and $5", "cost: ${env:HOME} and $5", nil},
+		{"unset", "${env:OWNERSHIT_TEST_UNSET}", "", ErrInterpolationEnvUnset},
+		{"missing file", "${file:missing.txt}", "", ErrInterpolationFileRead},
+		{"unknown source", "${vault:secret/x}", "", ErrInterpolationUnknownSource},
+		{"unterminated", "${env:OWNERSHIT_TEST_TOKEN", "", ErrInterpolationSyntax},
+		{"no source", "${OWNERSHIT_TEST_TOKEN}", "", ErrInterpolationSyntax},
+		{"bad name", "${env:1BAD}", "", ErrInterpolationSyntax},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, err := interpolateString(tt.in, dir)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("interpolateString(%q) error = %v, want %v", tt.in, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("interpolateString(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadConfigurationInterpolation(t *testing.T) {
+	t.Cleanup(resetSecrets)
+	t.Setenv("OWNERSHIT_TEST_ORG", "acme-org")
+	dir := writeConfigFiles(t, map[string]string{
+		"repositories.yaml":     "organization: ${env:OWNERSHIT_TEST_ORG}\ninclude:\n  - teams/repos.yaml\n",
+		"teams/repos.yaml":      "repositories:\n  - name: api\n    description: ${file:description.txt}\n",
+		"teams/description.txt": "internal API\n",
+	})
+	path := filepath.Join(dir, "repositories.yaml")
+
+	settings, err := LoadConfiguration(path)
+	if err != nil {
+		t.Fatalf("LoadConfiguration() error = %v", err)
+	}
+	if got := stringValue(settings.Organization); got != "acme-org" {
+		t.Errorf("organization = %q", got)
+	}
+	// File references resolve relative to the file that contains them.
+	if got := stringValue(settings.Repositories[0].Description); got != "internal API" {
+		t.Errorf("description = %q", got)
+	}
+
+	rendered, err := RenderConfiguration(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(rendered), "${env:OWNERSHIT_TEST_ORG}") {
+		t.Errorf("render should keep references:\n%s", rendered)
+	}
+}
+
+func TestLoadConfigurationRedactsOnlySecrets(t *testing.T) {
+	t.Cleanup(resetSecrets)
+	t.Setenv("OWNERSHIT_TEST_BRANCH", "main")
+	t.Setenv("OWNERSHIT_TEST_HOOK_TOKEN", "hunter2-password")
+	dir := writeConfigFiles(t, map[string]string{
+		"repositories.yaml": "organization: acme\nrepositories:\n  - name: api\n    default_branch: ${env:OWNERSHIT_TEST_BRANCH}\n" +
+			"    homepage: https://example.com/?token=${secret:OWNERSHIT_TEST_HOOK_TOKEN}\n",
+	})
+
+	settings, err := LoadConfiguration(filepath.Join(dir, "repositories.yaml"))
+	if err != nil {
+		t.Fatalf("LoadConfiguration() error = %v", err)
+	}
+	if got := stringValue(settings.Repositories[0].DefaultBranch); got != "main" {
+		t.Errorf("default_branch = %q", got)
+	}
+	got := RedactSecrets("maintainers of main: hunter2-password")
+	if want := "maintainers of main: " + RedactedValue; got != want {
+		t.Errorf("RedactSecrets() = %q, want %q", got, want)
+	}
+}
+
+func TestLoadConfigurationInterpolationErrors(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"repositories.yaml": "organization: acme\nrepositories:\n  - name: api\n    homepage: ${env:OWNERSHIT_TEST_MISSING}\n",
+	})
+	path := filepath.Join(dir, "repositories.yaml")
+
+	_, err := LoadConfiguration(path)
+	if !errors.Is(err, ErrInterpolationEnvUnset) || !strings.Contains(err.Error(), "line 4") {
+		t.Errorf("expected an unset-variable error on line 4, got %v", err)
+	}
+
+	problems, err := ValidateConfiguration(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(problems) != 1 || problems[0].Line != 4 || !strings.Contains(problems[0].Message, "OWNERSHIT_TEST_MISSING") {
+		t.Errorf("unexpected problems: %v", problems)
+	}
+}
+
+func TestRedactingWriter(t *testing.T) {
+	t.Cleanup(resetSecrets)
+	registerSecret("hunter2-password")
+	registerSecret("line one\nline two")
+	registerSecret("on") // too short to redact
+
+	var buf bytes.Buffer
+	w := NewRedactingWriter(&buf)
+	if _, err := w.Write([]byte(`token=hunter2-password note="line one\nline two" enabled=on`)); err != nil {
+		t.Fatal(err)
+	}
+	want := `token=[REDACTED] note="[REDACTED]" enabled=on`
+	if buf.String() != want {
+		t.Errorf("redacted output = %q, want %q", buf.String(), want)
+	}
+	if got := RedactSecrets("line one\nline two"); got != RedactedValue {
+		t.Errorf("RedactSecrets() = %q", got)
+	}
+}
@@ -0,0 +1,294 @@
+package ownershit
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"gopkg.in/yaml.v3"
+)
+
+// ErrSchemaVersionTooNew is returned for configurations written for a newer version of ownershit.
+var ErrSchemaVersionTooNew = errors.New("configuration schema version is newer than this version of ownershit supports")
+
+// ErrNoMigrationPath is returned when no registered migration starts at a configuration's version.
+var ErrNoMigrationPath = errors.New("no migration registered for schema version")
+
+// ConfigMigration upgrades a configuration from one schema version to the next. Migrate works on
+// the YAML node tree of a single file so comments and formatting survive; it must tolerate files
+// that only hold part of the configuration.
+type ConfigMigration struct {
+	From        string
+	To          string
+	Description string
+	Migrate     func(root *yaml.Node) error
+}
+
+// configMigrations is the chain of registered migrations, each starting where the previous one ends.
+// Add an entry here, and bump CurrentSchemaVersion, whenever the configuration format changes.
+var configMigrations = []ConfigMigration{
+	{
+		From:        LegacySchemaVersion,
+		To:          "1.0",
+		Description: "record the schema version; no structural changes",
+		Migrate:     func(*yaml.Node) error { return nil },
+	},
+}
+
+// compareSchemaVersions compares two "major.minor" versions, reporting false if either is malformed.
+func compareSchemaVersions(a, b string) (int, bool) {
+	parse := func(v string) ([2]int, bool) {
+		var out [2]int
+		parts := strings.Split(strings.TrimSpace(v), ".")
+		if len(parts) != len(out) {
+			return out, false
+		}
+		for i, part := range parts {
+			n, err := strconv.Atoi(part)
+			if err != nil || n < 0 {
+				return out, false
+			}
+			out[i] = n
+		}
+		return out, true
+	}
+	av, okA := parse(a)
+	bv, okB := parse(b)
+	if !okA || !okB {
+		return 0, false
+	}
+	for i := range av {
+		if av[i] != bv[i] {
+			if av[i] < bv[i] {
+				return -1, true
+			}
+			return 1, true
+		}
+	}
+	return 0, true
+}
+
+// isNewerSchemaVersion reports whether version is a well-formed version beyond CurrentSchemaVersion.
+func isNewerSchemaVersion(version string) bool {
+	cmp, ok := compareSchemaVersions(version, CurrentSchemaVersion)
+	return ok && cmp > 0
+}
+
+// configMigrationPath returns the migrations that take a configuration from version to CurrentSchemaVersion.
+func configMigrationPath(version string) ([]ConfigMigration, error) {
+	var path []ConfigMigration
+	for version != CurrentSchemaVersion {
+		found := false
+		for _, m := range configMigrations {
+			if m.From == version {
+				path = append(path, m)
+				version = m.To
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("%w %s", ErrNoMigrationPath, version)
+		}
+	}
+	return path, nil
+}
+
+// supportedSchemaVersions lists the current schema version followed by every version that can be migrated.
+func supportedSchemaVersions() []string {
+	versions := []string{CurrentSchemaVersion}
+	for i := len(configMigrations) - 1; i >= 0; i-- {
+		versions = append(versions, configMigrations[i].From)
+	}
+	return versions
+}
+
+// configDocumentVersion returns the version declared in a configuration mapping, or "".
+func configDocumentVersion(root *yaml.Node) string {
+	return strings.TrimSpace(mappingValue(root, "version"))
+}
+
+// setConfigDocumentVersion sets the version key of a configuration mapping, adding it first if missing.
+func setConfigDocumentVersion(root *yaml.Node, version string) {
+	if index := mappingIndex(root, "version"); index >= 0 {
+		root.Content[index+1].Value = version
+		root.Content[index+1].Tag = "!!str"
+		root.Content[index+1].Style = yaml.DoubleQuotedStyle
+		return
+	}
+	key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "version"}
+	if len(root.Content) > 0 {
+		// Keep a comment at the top of the file above the new first key.
+		key.HeadComment, root.Content[0].HeadComment = root.Content[0].HeadComment, ""
+	}
+	root.Content = append([]*yaml.Node{
+		key, {Kind: yaml.ScalarNode, Tag: "!!str", Value: version, Style: yaml.DoubleQuotedStyle},
+	}, root.Content...)
+}
+
+// MigrateConfigDocument upgrades a merged configuration mapping to CurrentSchemaVersion in place,
+// returning the version it started from and the migrations applied. Configurations without a
+// version are treated as LegacySchemaVersion; configurations newer than this binary are refused.
+func MigrateConfigDocument(root *yaml.Node) (string, []ConfigMigration, error) {
+	from := configDocumentVersion(root)
+	if from == "" {
+		from = LegacySchemaVersion
+	}
+	if err := ValidateSchemaVersion(&from); err != nil {
+		return from, nil, err
+	}
+	steps, err := configMigrationPath(from)
+	if err != nil {
+		return from, nil, err
+	}
+	if len(steps) == 0 {
+		return from, nil, nil
+	}
+	for _, step := range steps {
+		if err := step.Migrate(root); err != nil {
+			return from, nil, fmt.Errorf("migrating schema %s to %s: %w", step.From, step.To, err)
+		}
+	}
+	setConfigDocumentVersion(root, CurrentSchemaVersion)
+	log.Debug().
+		Str("fromVersion", from).
+		Str("toVersion", CurrentSchemaVersion).
+		Msg("migrated configuration schema in memory")
+	return from, steps, nil
+}
+
+// ConfigFileMigration is the result of migrating one configuration file.
+type ConfigFileMigration struct {
+	File    string
+	From    string
+	To      string
+	Applied []ConfigMigration
+	// Content is the migrated file; it is only set when the file changes.
+	Content []byte
+}
+
+// Changed reports whether the migration rewrites the file.
+func (m *ConfigFileMigration) Changed() bool {
+	return m.Content != nil
+}
+
+// MigrateConfigurationFiles plans the migration of every file that makes up the configuration at
+// path, including directories and includes, without writing anything. The configuration version is
+// taken from the file that declares it, and that file (or the first file, if none does) receives the
+// new version.
+func MigrateConfigurationFiles(path string) ([]*ConfigFileMigration, error) {
+	loader := newConfigLoader()
+	loader.lenient = true
+	if err := loader.loadAll(path); err != nil {
+		return nil, err
+	}
+
+	type parsedFile struct {
+		name string
+		data []byte
+		doc  yaml.Node
+	}
+	files := make([]*parsedFile, 0, len(loader.order))
+	from, versionFile := "", 0
+	for i, name := range loader.order {
+		data, err := os.ReadFile(name) // #nosec G304 - configuration paths come from the user
+		if err != nil {
+			return nil, NewConfigFileError(name, "read", "failed to read configuration file", err)
+		}
+		file := &parsedFile{name: name, data: data}
+		if err := yaml.Unmarshal(data, &file.doc); err != nil {
+			return nil, NewConfigFileError(name, "parse", "failed to parse YAML configuration", err)
+		}
+		if len(file.doc.Content) > 0 && from == "" {
+			if version := configDocumentVersion(file.doc.Content[0]); version != "" {
+				from, versionFile = version, i
+			}
+		}
+		files = append(files, file)
+	}
+	if from == "" {
+		from = LegacySchemaVersion
+	}
+	if err := ValidateSchemaVersion(&from); err != nil {
+		return nil, NewConfigFileError(files[versionFile].name, "migrate", err.Error(), err)
+	}
+	steps, err := configMigrationPath(from)
+	if err != nil {
+		return nil, NewConfigFileError(files[versionFile].name, "migrate", err.Error(), err)
+	}
+
+	results := make([]*ConfigFileMigration, 0, len(files))
+	for i, file := range files {
+		result := &ConfigFileMigration{File: file.name, From: from, To: CurrentSchemaVersion, Applied: steps}
+		results = append(results, result)
+		if len(steps) == 0 || len(file.doc.Content) == 0 {
+			continue
+		}
+		// Files are compared as encoded before and after the steps, so a file no step touches keeps
+		// its formatting even if re-encoding would change it.
+		before, err := encodeConfigDocument(&file.doc)
+		if err != nil {
+			return nil, NewConfigFileError(file.name, "migrate", "failed to encode configuration", err)
+		}
+		root := file.doc.Content[0]
+		for _, step := range steps {
+			if err := step.Migrate(root); err != nil {
+				return nil, NewConfigFileError(file.name, "migrate",
+					fmt.Sprintf("migration %s to %s failed", step.From, step.To), err)
+			}
+		}
+		if i == versionFile {
+			setConfigDocumentVersion(root, CurrentSchemaVersion)
+		}
+		content, err := encodeConfigDocument(&file.doc)
+		if err != nil {
+			return nil, NewConfigFileError(file.name, "migrate", "failed to encode migrated configuration", err)
+		}
+		if (i == versionFile || !bytes.Equal(content, before)) && !bytes.Equal(content, file.data) {
+			result.Content = content
+		}
+	}
+	return results, nil
+}
+
+// WriteConfigFileMigration writes a migrated file in place, keeping the original next to it as
+// <file>.bak. It returns the backup path, or "" when the file did not change.
+func WriteConfigFileMigration(m *ConfigFileMigration) (string, error) {
+	if !m.Changed() {
+		return "", nil
+	}
+	info, err := os.Stat(m.File)
+	if err != nil {
+		return "", NewConfigFileError(m.File, "write", "failed to stat configuration file", err)
+	}
+	original, err := os.ReadFile(m.File)
+	if err != nil {
+		return "", NewConfigFileError(m.File, "write", "failed to read configuration file", err)
+	}
+	backup := m.File + ".bak"
+	if err := os.WriteFile(backup, original, info.Mode().Perm()); err != nil {
+		return "", NewConfigFileError(backup, "write", "failed to write backup", err)
+	}
+	if err := os.WriteFile(m.File, m.Content, info.Mode().Perm()); err != nil {
+		return backup, NewConfigFileError(m.File, "write", "failed to write migrated configuration", err)
+	}
+	return backup, nil
+}
+
+// encodeConfigDocument writes a YAML document with the two-space indentation used by ownershit configs.
+func encodeConfigDocument(doc *yaml.Node) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(doc); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
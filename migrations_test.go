@@ -0,0 +1,151 @@
+package ownershit
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestCompareSchemaVersions(t *testing.T) {
+	tests := []struct {
+		a, b   string
+		want   int
+		wantOK bool
+	}{
+		{"1.0", "1.0", 0, true},
+		{"0.9", "1.0", -1, true},
+		{"1.10", "1.9", 1, true},
+		{"2.0", "1.0", 1, true},
+		{"invalid", "1.0", 0, false},
+		{"1", "1.0", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := compareSchemaVersions(tt.a, tt.b)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("compareSchemaVersions(%q, %q) = %d, %v; want %d, %v", tt.a, tt.b, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+// withConfigMigrations replaces the migration chain for the duration of a test.
+func withConfigMigrations(t *testing.T, migrations []ConfigMigration) {
+	t.Helper()
+	original := configMigrations
+	configMigrations = migrations
+	t.Cleanup(func() { configMigrations = original })
+}
+
+// renameTeamKey is a sample structural migration: `team_permissions` became `team`.
+func renameTeamKey(root *yaml.Node) error {
+	if index := mappingIndex(root, "team_permissions"); index >= 0 {
+		root.Content[index].Value = "team"
+	}
+	return nil
+}
+
+func TestMigrateConfigDocument(t *testing.T) {
+	withConfigMigrations(t, []ConfigMigration{
+		{From: "0.8", To: LegacySchemaVersion, Description: "rename team_permissions", Migrate: renameTeamKey},
+		configMigrations[0],
+	})
+
+	var doc yaml.Node
+	src := "# acme\nversion: \"0.8\"\norganization: acme # the org\nteam_permissions:\n  - name: devs\n    level: push\n"
+	if err := yaml.Unmarshal([]byte(src), &doc); err != nil {
+		t.Fatal(err)
+	}
+	from, applied, err := MigrateConfigDocument(doc.Content[0])
+	if err != nil {
+		t.Fatalf("MigrateConfigDocument() error = %v", err)
+	}
+	if from != "0.8" || len(applied) != 2 {
+		t.Errorf("from = %s, applied = %d migrations", from, len(applied))
+	}
+	out, err := encodeConfigDocument(&doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"# acme\nversion: \"1.0\"", "organization: acme # the org", "team:\n"} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("migrated document missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestMigrateConfigDocumentRefusesNewerVersion(t *testing.T) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte("version: \"9.0\"\norganization: acme\n"), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := MigrateConfigDocument(doc.Content[0]); !errors.Is(err, ErrSchemaVersionTooNew) {
+		t.Errorf("expected ErrSchemaVersionTooNew, got %v", err)
+	}
+
+	dir := writeConfigFiles(t, map[string]string{
+		"repositories.yaml": "version: \"9.0\"\norganization: acme\nrepositories:\n  - name: api\n",
+	})
+	if _, err := LoadConfiguration(filepath.Join(dir, "repositories.yaml")); !errors.Is(err, ErrSchemaVersionTooNew) {
+		t.Errorf("LoadConfiguration() should refuse newer configurations, got %v", err)
+	}
+}
+
+func TestMigrateConfigurationFiles(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"00-base.yaml":   "# shared settings\norganization: acme\n",
+		"10-repos.yaml":  "repositories:\n    -   name: api # primary service\n",
+		"current/x.yaml": "",
+	})
+
+	migrations, err := MigrateConfigurationFiles(dir)
+	if err != nil {
+		t.Fatalf("MigrateConfigurationFiles() error = %v", err)
+	}
+	if len(migrations) != 3 {
+		t.Fatalf("expected 3 files, got %d", len(migrations))
+	}
+	base := migrations[0]
+	if !base.Changed() || base.From != LegacySchemaVersion || base.To != CurrentSchemaVersion {
+		t.Errorf("unexpected base migration: %+v", base)
+	}
+	if migrations[1].Changed() || migrations[2].Changed() {
+		t.Error("only the file that receives the version should change")
+	}
+
+	backup, err := WriteConfigFileMigration(base)
+	if err != nil {
+		t.Fatalf("WriteConfigFileMigration() error = %v", err)
+	}
+	original, err := os.ReadFile(backup)
+	if err != nil || string(original) != "# shared settings\norganization: acme\n" {
+		t.Errorf("backup = %q, %v", original, err)
+	}
+	written, err := os.ReadFile(base.File)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(written) != "# shared settings\nversion: \"1.0\"\norganization: acme\n" {
+		t.Errorf("migrated file = %q", written)
+	}
+
+	migrations, err = MigrateConfigurationFiles(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations[0].Applied) != 0 || migrations[0].Changed() {
+		t.Error("a migrated configuration should need no further migrations")
+	}
+}
+
+func TestValidateSchemaVersionTooNew(t *testing.T) {
+	err := ValidateSchemaVersion(stringPtr("1.1"))
+	if !errors.Is(err, ErrSchemaVersionTooNew) || !strings.Contains(err.Error(), "upgrade ownershit") {
+		t.Errorf("expected a newer-version error, got %v", err)
+	}
+	if err := ValidateSchemaVersion(stringPtr("0.5")); errors.Is(err, ErrSchemaVersionTooNew) || err == nil {
+		t.Errorf("expected an unsupported-version error, got %v", err)
+	}
+}
@@ -47,6 +47,9 @@ type testMocks struct {
 	repoMock   *mocks.MockRepositoriesService
 	graphMock  *mocks.MockGraphQLClient
 	issuesMock *mocks.MockIssuesService
+	gitMock    *mocks.MockGitService
+	pullsMock  *mocks.MockPullRequestsService
+	orgsMock   *mocks.MockOrganizationsService
 }
 
 func setupMocks(t *testing.T) *testMocks {
@@ -55,19 +58,25 @@ func setupMocks(t *testing.T) *testMocks {
 	graph := mocks.NewMockGraphQLClient(ctrl)
 	repo := mocks.NewMockRepositoriesService(ctrl)
 	issues := mocks.NewMockIssuesService(ctrl)
+	git := mocks.NewMockGitService(ctrl)
+	pulls := mocks.NewMockPullRequestsService(ctrl)
+	orgs := mocks.NewMockOrganizationsService(ctrl)
 
 	// Create a real GitHub client for v3 operations that can't be mocked easily
 	// Use an empty token since we're mocking the service layer
 	realClient := defaultGitHubClient()
 
 	ghClient := &GitHubClient{
-		Teams:        teams,
-		Context:      context.TODO(),
-		Graph:        graph,
-		Repositories: repo,
-		Issues:       issues,
-		v3:           realClient.v3, // Set the v3 client to avoid nil pointer
-		v4:           realClient.v4, // Set the v4 client as well for completeness
+		Teams:         teams,
+		Context:       context.TODO(),
+		Graph:         graph,
+		Repositories:  repo,
+		Issues:        issues,
+		Git:           git,
+		PullRequests:  pulls,
+		Organizations: orgs,
+		v3:            realClient.v3, // Set the v3 client to avoid nil pointer
+		v4:            realClient.v4, // Set the v4 client as well for completeness
 	}
 	return &testMocks{
 		ctrl:       ctrl,
@@ -76,5 +85,8 @@ func setupMocks(t *testing.T) *testMocks {
 		repoMock:   repo,
 		teamMock:   teams,
 		issuesMock: issues,
+		gitMock:    git,
+		pullsMock:  pulls,
+		orgsMock:   orgs,
 	}
 }
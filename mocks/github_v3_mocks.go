@@ -41,6 +41,22 @@ func (m *MockTeamsService) EXPECT() *MockTeamsServiceMockRecorder {
 	return m.recorder
 }
 
+// AddTeamMembershipBySlug mocks base method.
+func (m *MockTeamsService) AddTeamMembershipBySlug(ctx context.Context, org, slug, user string, opts *github.TeamAddTeamMembershipOptions) (*github.Membership, *github.Response, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddTeamMembershipBySlug", ctx, org, slug, user, opts)
+	ret0, _ := ret[0].(*github.Membership)
+	ret1, _ := ret[1].(*github.Response)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// AddTeamMembershipBySlug indicates an expected call of AddTeamMembershipBySlug.
+func (mr *MockTeamsServiceMockRecorder) AddTeamMembershipBySlug(ctx, org, slug, user, opts any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddTeamMembershipBySlug", reflect.TypeOf((*MockTeamsService)(nil).AddTeamMembershipBySlug), ctx, org, slug, user, opts)
+}
+
 // AddTeamRepoBySlug mocks base method.
 func (m *MockTeamsService) AddTeamRepoBySlug(ctx context.Context, org, slug, owner, repo string, opts *github.TeamAddTeamRepoOptions) (*github.Response, error) {
 	m.ctrl.T.Helper()
@@ -56,6 +72,38 @@ func (mr *MockTeamsServiceMockRecorder) AddTeamRepoBySlug(ctx, org, slug, owner,
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddTeamRepoBySlug", reflect.TypeOf((*MockTeamsService)(nil).AddTeamRepoBySlug), ctx, org, slug, owner, repo, opts)
 }
 
+// CreateTeam mocks base method.
+func (m *MockTeamsService) CreateTeam(ctx context.Context, org string, team github.NewTeam) (*github.Team, *github.Response, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateTeam", ctx, org, team)
+	ret0, _ := ret[0].(*github.Team)
+	ret1, _ := ret[1].(*github.Response)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// CreateTeam indicates an expected call of CreateTeam.
+func (mr *MockTeamsServiceMockRecorder) CreateTeam(ctx, org, team any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTeam", reflect.TypeOf((*MockTeamsService)(nil).CreateTeam), ctx, org, team)
+}
+
+// EditTeamBySlug mocks base method.
+func (m *MockTeamsService) EditTeamBySlug(ctx context.Context, org, slug string, team github.NewTeam, removeParent bool) (*github.Team, *github.Response, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EditTeamBySlug", ctx, org, slug, team, removeParent)
+	ret0, _ := ret[0].(*github.Team)
+	ret1, _ := ret[1].(*github.Response)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// EditTeamBySlug indicates an expected call of EditTeamBySlug.
+func (mr *MockTeamsServiceMockRecorder) EditTeamBySlug(ctx, org, slug, team, removeParent any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EditTeamBySlug", reflect.TypeOf((*MockTeamsService)(nil).EditTeamBySlug), ctx, org, slug, team, removeParent)
+}
+
 // GetTeamBySlug mocks base method.
 func (m *MockTeamsService) GetTeamBySlug(ctx context.Context, org, slug string) (*github.Team, *github.Response, error) {
 	m.ctrl.T.Helper()
@@ -72,6 +120,37 @@ func (mr *MockTeamsServiceMockRecorder) GetTeamBySlug(ctx, org, slug any) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTeamBySlug", reflect.TypeOf((*MockTeamsService)(nil).GetTeamBySlug), ctx, org, slug)
 }
 
+// ListTeamMembersBySlug mocks base method.
+func (m *MockTeamsService) ListTeamMembersBySlug(ctx context.Context, org, slug string, opts *github.TeamListTeamMembersOptions) ([]*github.User, *github.Response, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTeamMembersBySlug", ctx, org, slug, opts)
+	ret0, _ := ret[0].([]*github.User)
+	ret1, _ := ret[1].(*github.Response)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ListTeamMembersBySlug indicates an expected call of ListTeamMembersBySlug.
+func (mr *MockTeamsServiceMockRecorder) ListTeamMembersBySlug(ctx, org, slug, opts any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTeamMembersBySlug", reflect.TypeOf((*MockTeamsService)(nil).ListTeamMembersBySlug), ctx, org, slug, opts)
+}
+
+// RemoveTeamMembershipBySlug mocks base method.
+func (m *MockTeamsService) RemoveTeamMembershipBySlug(ctx context.Context, org, slug, user string) (*github.Response, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveTeamMembershipBySlug", ctx, org, slug, user)
+	ret0, _ := ret[0].(*github.Response)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RemoveTeamMembershipBySlug indicates an expected call of RemoveTeamMembershipBySlug.
+func (mr *MockTeamsServiceMockRecorder) RemoveTeamMembershipBySlug(ctx, org, slug, user any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveTeamMembershipBySlug", reflect.TypeOf((*MockTeamsService)(nil).RemoveTeamMembershipBySlug), ctx, org, slug, user)
+}
+
 // MockIssuesService is a mock of IssuesService interface.
 type MockIssuesService struct {
 	ctrl     *gomock.Controller
@@ -168,6 +247,113 @@ func (m *MockRepositoriesService) EXPECT() *MockRepositoriesServiceMockRecorder
 	return m.recorder
 }
 
+// AddAutolink mocks base method.
+func (m *MockRepositoriesService) AddAutolink(ctx context.Context, owner, repo string, opts *github.AutolinkOptions) (*github.Autolink, *github.Response, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddAutolink", ctx, owner, repo, opts)
+	ret0, _ := ret[0].(*github.Autolink)
+	ret1, _ := ret[1].(*github.Response)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// AddAutolink indicates an expected call of AddAutolink.
+func (mr *MockRepositoriesServiceMockRecorder) AddAutolink(ctx, owner, repo, opts any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddAutolink", reflect.TypeOf((*MockRepositoriesService)(nil).AddAutolink), ctx, owner, repo, opts)
+}
+
+// CreateFile mocks base method.
+func (m *MockRepositoriesService) CreateFile(ctx context.Context, owner, repo, path string, opts *github.RepositoryContentFileOptions) (*github.RepositoryContentResponse, *github.Response, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateFile", ctx, owner, repo, path, opts)
+	ret0, _ := ret[0].(*github.RepositoryContentResponse)
+	ret1, _ := ret[1].(*github.Response)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// CreateFile indicates an expected call of CreateFile.
+func (mr *MockRepositoriesServiceMockRecorder) CreateFile(ctx, owner, repo, path, opts any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateFile", reflect.TypeOf((*MockRepositoriesService)(nil).CreateFile), ctx, owner, repo, path, opts)
+}
+
+// CreateOrUpdateCustomProperties mocks base method.
+func (m *MockRepositoriesService) CreateOrUpdateCustomProperties(ctx context.Context, org, repo string, values []*github.CustomPropertyValue) (*github.Response, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateOrUpdateCustomProperties", ctx, org, repo, values)
+	ret0, _ := ret[0].(*github.Response)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateOrUpdateCustomProperties indicates an expected call of CreateOrUpdateCustomProperties.
+func (mr *MockRepositoriesServiceMockRecorder) CreateOrUpdateCustomProperties(ctx, org, repo, values any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOrUpdateCustomProperties", reflect.TypeOf((*MockRepositoriesService)(nil).CreateOrUpdateCustomProperties), ctx, org, repo, values)
+}
+
+// DeleteAutolink mocks base method.
+func (m *MockRepositoriesService) DeleteAutolink(ctx context.Context, owner, repo string, id int64) (*github.Response, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAutolink", ctx, owner, repo, id)
+	ret0, _ := ret[0].(*github.Response)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteAutolink indicates an expected call of DeleteAutolink.
+func (mr *MockRepositoriesServiceMockRecorder) DeleteAutolink(ctx, owner, repo, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAutolink", reflect.TypeOf((*MockRepositoriesService)(nil).DeleteAutolink), ctx, owner, repo, id)
+}
+
+// DisableAutomatedSecurityFixes mocks base method.
+func (m *MockRepositoriesService) DisableAutomatedSecurityFixes(ctx context.Context, owner, repo string) (*github.Response, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DisableAutomatedSecurityFixes", ctx, owner, repo)
+	ret0, _ := ret[0].(*github.Response)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DisableAutomatedSecurityFixes indicates an expected call of DisableAutomatedSecurityFixes.
+func (mr *MockRepositoriesServiceMockRecorder) DisableAutomatedSecurityFixes(ctx, owner, repo any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DisableAutomatedSecurityFixes", reflect.TypeOf((*MockRepositoriesService)(nil).DisableAutomatedSecurityFixes), ctx, owner, repo)
+}
+
+// DisablePrivateReporting mocks base method.
+func (m *MockRepositoriesService) DisablePrivateReporting(ctx context.Context, owner, repo string) (*github.Response, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DisablePrivateReporting", ctx, owner, repo)
+	ret0, _ := ret[0].(*github.Response)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DisablePrivateReporting indicates an expected call of DisablePrivateReporting.
+func (mr *MockRepositoriesServiceMockRecorder) DisablePrivateReporting(ctx, owner, repo any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DisablePrivateReporting", reflect.TypeOf((*MockRepositoriesService)(nil).DisablePrivateReporting), ctx, owner, repo)
+}
+
+// DisableVulnerabilityAlerts mocks base method.
+func (m *MockRepositoriesService) DisableVulnerabilityAlerts(ctx context.Context, owner, repo string) (*github.Response, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DisableVulnerabilityAlerts", ctx, owner, repo)
+	ret0, _ := ret[0].(*github.Response)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DisableVulnerabilityAlerts indicates an expected call of DisableVulnerabilityAlerts.
+func (mr *MockRepositoriesServiceMockRecorder) DisableVulnerabilityAlerts(ctx, owner, repo any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DisableVulnerabilityAlerts", reflect.TypeOf((*MockRepositoriesService)(nil).DisableVulnerabilityAlerts), ctx, owner, repo)
+}
+
 // Edit mocks base method.
 func (m *MockRepositoriesService) Edit(ctx context.Context, org, repo string, repository *github.Repository) (*github.Repository, *github.Response, error) {
 	m.ctrl.T.Helper()
@@ -184,6 +370,51 @@ func (mr *MockRepositoriesServiceMockRecorder) Edit(ctx, org, repo, repository a
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Edit", reflect.TypeOf((*MockRepositoriesService)(nil).Edit), ctx, org, repo, repository)
 }
 
+// EnableAutomatedSecurityFixes mocks base method.
+func (m *MockRepositoriesService) EnableAutomatedSecurityFixes(ctx context.Context, owner, repo string) (*github.Response, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnableAutomatedSecurityFixes", ctx, owner, repo)
+	ret0, _ := ret[0].(*github.Response)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EnableAutomatedSecurityFixes indicates an expected call of EnableAutomatedSecurityFixes.
+func (mr *MockRepositoriesServiceMockRecorder) EnableAutomatedSecurityFixes(ctx, owner, repo any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnableAutomatedSecurityFixes", reflect.TypeOf((*MockRepositoriesService)(nil).EnableAutomatedSecurityFixes), ctx, owner, repo)
+}
+
+// EnablePrivateReporting mocks base method.
+func (m *MockRepositoriesService) EnablePrivateReporting(ctx context.Context, owner, repo string) (*github.Response, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnablePrivateReporting", ctx, owner, repo)
+	ret0, _ := ret[0].(*github.Response)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EnablePrivateReporting indicates an expected call of EnablePrivateReporting.
+func (mr *MockRepositoriesServiceMockRecorder) EnablePrivateReporting(ctx, owner, repo any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnablePrivateReporting", reflect.TypeOf((*MockRepositoriesService)(nil).EnablePrivateReporting), ctx, owner, repo)
+}
+
+// EnableVulnerabilityAlerts mocks base method.
+func (m *MockRepositoriesService) EnableVulnerabilityAlerts(ctx context.Context, owner, repo string) (*github.Response, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnableVulnerabilityAlerts", ctx, owner, repo)
+	ret0, _ := ret[0].(*github.Response)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EnableVulnerabilityAlerts indicates an expected call of EnableVulnerabilityAlerts.
+func (mr *MockRepositoriesServiceMockRecorder) EnableVulnerabilityAlerts(ctx, owner, repo any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnableVulnerabilityAlerts", reflect.TypeOf((*MockRepositoriesService)(nil).EnableVulnerabilityAlerts), ctx, owner, repo)
+}
+
 // Get mocks base method.
 func (m *MockRepositoriesService) Get(ctx context.Context, owner, repo string) (*github.Repository, *github.Response, error) {
 	m.ctrl.T.Helper()
@@ -200,6 +431,38 @@ func (mr *MockRepositoriesServiceMockRecorder) Get(ctx, owner, repo any) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockRepositoriesService)(nil).Get), ctx, owner, repo)
 }
 
+// GetAllCustomPropertyValues mocks base method.
+func (m *MockRepositoriesService) GetAllCustomPropertyValues(ctx context.Context, org, repo string) ([]*github.CustomPropertyValue, *github.Response, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAllCustomPropertyValues", ctx, org, repo)
+	ret0, _ := ret[0].([]*github.CustomPropertyValue)
+	ret1, _ := ret[1].(*github.Response)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetAllCustomPropertyValues indicates an expected call of GetAllCustomPropertyValues.
+func (mr *MockRepositoriesServiceMockRecorder) GetAllCustomPropertyValues(ctx, org, repo any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllCustomPropertyValues", reflect.TypeOf((*MockRepositoriesService)(nil).GetAllCustomPropertyValues), ctx, org, repo)
+}
+
+// GetAutomatedSecurityFixes mocks base method.
+func (m *MockRepositoriesService) GetAutomatedSecurityFixes(ctx context.Context, owner, repo string) (*github.AutomatedSecurityFixes, *github.Response, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAutomatedSecurityFixes", ctx, owner, repo)
+	ret0, _ := ret[0].(*github.AutomatedSecurityFixes)
+	ret1, _ := ret[1].(*github.Response)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetAutomatedSecurityFixes indicates an expected call of GetAutomatedSecurityFixes.
+func (mr *MockRepositoriesServiceMockRecorder) GetAutomatedSecurityFixes(ctx, owner, repo any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAutomatedSecurityFixes", reflect.TypeOf((*MockRepositoriesService)(nil).GetAutomatedSecurityFixes), ctx, owner, repo)
+}
+
 // GetBranchProtection mocks base method.
 func (m *MockRepositoriesService) GetBranchProtection(ctx context.Context, owner, repo, branch string) (*github.Protection, *github.Response, error) {
 	m.ctrl.T.Helper()
@@ -216,6 +479,55 @@ func (mr *MockRepositoriesServiceMockRecorder) GetBranchProtection(ctx, owner, r
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBranchProtection", reflect.TypeOf((*MockRepositoriesService)(nil).GetBranchProtection), ctx, owner, repo, branch)
 }
 
+// GetContents mocks base method.
+func (m *MockRepositoriesService) GetContents(ctx context.Context, owner, repo, path string, opts *github.RepositoryContentGetOptions) (*github.RepositoryContent, []*github.RepositoryContent, *github.Response, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetContents", ctx, owner, repo, path, opts)
+	ret0, _ := ret[0].(*github.RepositoryContent)
+	ret1, _ := ret[1].([]*github.RepositoryContent)
+	ret2, _ := ret[2].(*github.Response)
+	ret3, _ := ret[3].(error)
+	return ret0, ret1, ret2, ret3
+}
+
+// GetContents indicates an expected call of GetContents.
+func (mr *MockRepositoriesServiceMockRecorder) GetContents(ctx, owner, repo, path, opts any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetContents", reflect.TypeOf((*MockRepositoriesService)(nil).GetContents), ctx, owner, repo, path, opts)
+}
+
+// GetVulnerabilityAlerts mocks base method.
+func (m *MockRepositoriesService) GetVulnerabilityAlerts(ctx context.Context, owner, repo string) (bool, *github.Response, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetVulnerabilityAlerts", ctx, owner, repo)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(*github.Response)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetVulnerabilityAlerts indicates an expected call of GetVulnerabilityAlerts.
+func (mr *MockRepositoriesServiceMockRecorder) GetVulnerabilityAlerts(ctx, owner, repo any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetVulnerabilityAlerts", reflect.TypeOf((*MockRepositoriesService)(nil).GetVulnerabilityAlerts), ctx, owner, repo)
+}
+
+// IsPrivateReportingEnabled mocks base method.
+func (m *MockRepositoriesService) IsPrivateReportingEnabled(ctx context.Context, owner, repo string) (bool, *github.Response, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsPrivateReportingEnabled", ctx, owner, repo)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(*github.Response)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// IsPrivateReportingEnabled indicates an expected call of IsPrivateReportingEnabled.
+func (mr *MockRepositoriesServiceMockRecorder) IsPrivateReportingEnabled(ctx, owner, repo any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsPrivateReportingEnabled", reflect.TypeOf((*MockRepositoriesService)(nil).IsPrivateReportingEnabled), ctx, owner, repo)
+}
+
 // ListAllTopics mocks base method.
 func (m *MockRepositoriesService) ListAllTopics(ctx context.Context, owner, repo string) ([]string, *github.Response, error) {
 	m.ctrl.T.Helper()
@@ -232,6 +544,38 @@ func (mr *MockRepositoriesServiceMockRecorder) ListAllTopics(ctx, owner, repo an
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAllTopics", reflect.TypeOf((*MockRepositoriesService)(nil).ListAllTopics), ctx, owner, repo)
 }
 
+// ListAutolinks mocks base method.
+func (m *MockRepositoriesService) ListAutolinks(ctx context.Context, owner, repo string, opts *github.ListOptions) ([]*github.Autolink, *github.Response, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAutolinks", ctx, owner, repo, opts)
+	ret0, _ := ret[0].([]*github.Autolink)
+	ret1, _ := ret[1].(*github.Response)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ListAutolinks indicates an expected call of ListAutolinks.
+func (mr *MockRepositoriesServiceMockRecorder) ListAutolinks(ctx, owner, repo, opts any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAutolinks", reflect.TypeOf((*MockRepositoriesService)(nil).ListAutolinks), ctx, owner, repo, opts)
+}
+
+// ListByOrg mocks base method.
+func (m *MockRepositoriesService) ListByOrg(ctx context.Context, org string, opts *github.RepositoryListByOrgOptions) ([]*github.Repository, *github.Response, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListByOrg", ctx, org, opts)
+	ret0, _ := ret[0].([]*github.Repository)
+	ret1, _ := ret[1].(*github.Response)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ListByOrg indicates an expected call of ListByOrg.
+func (mr *MockRepositoriesServiceMockRecorder) ListByOrg(ctx, org, opts any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByOrg", reflect.TypeOf((*MockRepositoriesService)(nil).ListByOrg), ctx, org, opts)
+}
+
 // ListTeams mocks base method.
 func (m *MockRepositoriesService) ListTeams(ctx context.Context, owner, repo string, opts *github.ListOptions) ([]*github.Team, *github.Response, error) {
 	m.ctrl.T.Helper()
@@ -263,3 +607,219 @@ func (mr *MockRepositoriesServiceMockRecorder) ReplaceAllTopics(ctx, owner, repo
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplaceAllTopics", reflect.TypeOf((*MockRepositoriesService)(nil).ReplaceAllTopics), ctx, owner, repo, topics)
 }
+
+// UpdateFile mocks base method.
+func (m *MockRepositoriesService) UpdateFile(ctx context.Context, owner, repo, path string, opts *github.RepositoryContentFileOptions) (*github.RepositoryContentResponse, *github.Response, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateFile", ctx, owner, repo, path, opts)
+	ret0, _ := ret[0].(*github.RepositoryContentResponse)
+	ret1, _ := ret[1].(*github.Response)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// UpdateFile indicates an expected call of UpdateFile.
+func (mr *MockRepositoriesServiceMockRecorder) UpdateFile(ctx, owner, repo, path, opts any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateFile", reflect.TypeOf((*MockRepositoriesService)(nil).UpdateFile), ctx, owner, repo, path, opts)
+}
+
+// MockOrganizationsService is a mock of OrganizationsService interface.
+type MockOrganizationsService struct {
+	ctrl     *gomock.Controller
+	recorder *MockOrganizationsServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockOrganizationsServiceMockRecorder is the mock recorder for MockOrganizationsService.
+type MockOrganizationsServiceMockRecorder struct {
+	mock *MockOrganizationsService
+}
+
+// NewMockOrganizationsService creates a new mock instance.
+func NewMockOrganizationsService(ctrl *gomock.Controller) *MockOrganizationsService {
+	mock := &MockOrganizationsService{ctrl: ctrl}
+	mock.recorder = &MockOrganizationsServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOrganizationsService) EXPECT() *MockOrganizationsServiceMockRecorder {
+	return m.recorder
+}
+
+// CreateOrUpdateCustomProperties mocks base method.
+func (m *MockOrganizationsService) CreateOrUpdateCustomProperties(ctx context.Context, org string, properties []*github.CustomProperty) ([]*github.CustomProperty, *github.Response, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateOrUpdateCustomProperties", ctx, org, properties)
+	ret0, _ := ret[0].([]*github.CustomProperty)
+	ret1, _ := ret[1].(*github.Response)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// CreateOrUpdateCustomProperties indicates an expected call of CreateOrUpdateCustomProperties.
+func (mr *MockOrganizationsServiceMockRecorder) CreateOrUpdateCustomProperties(ctx, org, properties any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOrUpdateCustomProperties", reflect.TypeOf((*MockOrganizationsService)(nil).CreateOrUpdateCustomProperties), ctx, org, properties)
+}
+
+// Edit mocks base method.
+func (m *MockOrganizationsService) Edit(ctx context.Context, name string, org *github.Organization) (*github.Organization, *github.Response, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Edit", ctx, name, org)
+	ret0, _ := ret[0].(*github.Organization)
+	ret1, _ := ret[1].(*github.Response)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Edit indicates an expected call of Edit.
+func (mr *MockOrganizationsServiceMockRecorder) Edit(ctx, name, org any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Edit", reflect.TypeOf((*MockOrganizationsService)(nil).Edit), ctx, name, org)
+}
+
+// Get mocks base method.
+func (m *MockOrganizationsService) Get(ctx context.Context, org string) (*github.Organization, *github.Response, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, org)
+	ret0, _ := ret[0].(*github.Organization)
+	ret1, _ := ret[1].(*github.Response)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockOrganizationsServiceMockRecorder) Get(ctx, org any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockOrganizationsService)(nil).Get), ctx, org)
+}
+
+// GetAllCustomProperties mocks base method.
+func (m *MockOrganizationsService) GetAllCustomProperties(ctx context.Context, org string) ([]*github.CustomProperty, *github.Response, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAllCustomProperties", ctx, org)
+	ret0, _ := ret[0].([]*github.CustomProperty)
+	ret1, _ := ret[1].(*github.Response)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetAllCustomProperties indicates an expected call of GetAllCustomProperties.
+func (mr *MockOrganizationsServiceMockRecorder) GetAllCustomProperties(ctx, org any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllCustomProperties", reflect.TypeOf((*MockOrganizationsService)(nil).GetAllCustomProperties), ctx, org)
+}
+
+// ListCustomPropertyValues mocks base method.
+func (m *MockOrganizationsService) ListCustomPropertyValues(ctx context.Context, org string, opts *github.ListOptions) ([]*github.RepoCustomPropertyValue, *github.Response, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListCustomPropertyValues", ctx, org, opts)
+	ret0, _ := ret[0].([]*github.RepoCustomPropertyValue)
+	ret1, _ := ret[1].(*github.Response)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ListCustomPropertyValues indicates an expected call of ListCustomPropertyValues.
+func (mr *MockOrganizationsServiceMockRecorder) ListCustomPropertyValues(ctx, org, opts any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListCustomPropertyValues", reflect.TypeOf((*MockOrganizationsService)(nil).ListCustomPropertyValues), ctx, org, opts)
+}
+
+// MockGitService is a mock of GitService interface.
+type MockGitService struct {
+	ctrl     *gomock.Controller
+	recorder *MockGitServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockGitServiceMockRecorder is the mock recorder for MockGitService.
+type MockGitServiceMockRecorder struct {
+	mock *MockGitService
+}
+
+// NewMockGitService creates a new mock instance.
+func NewMockGitService(ctrl *gomock.Controller) *MockGitService {
+	mock := &MockGitService{ctrl: ctrl}
+	mock.recorder = &MockGitServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockGitService) EXPECT() *MockGitServiceMockRecorder {
+	return m.recorder
+}
+
+// CreateRef mocks base method.
+func (m *MockGitService) CreateRef(ctx context.Context, owner, repo string, ref *github.Reference) (*github.Reference, *github.Response, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateRef", ctx, owner, repo, ref)
+	ret0, _ := ret[0].(*github.Reference)
+	ret1, _ := ret[1].(*github.Response)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// CreateRef indicates an expected call of CreateRef.
+func (mr *MockGitServiceMockRecorder) CreateRef(ctx, owner, repo, ref any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRef", reflect.TypeOf((*MockGitService)(nil).CreateRef), ctx, owner, repo, ref)
+}
+
+// GetRef mocks base method.
+func (m *MockGitService) GetRef(ctx context.Context, owner, repo, ref string) (*github.Reference, *github.Response, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRef", ctx, owner, repo, ref)
+	ret0, _ := ret[0].(*github.Reference)
+	ret1, _ := ret[1].(*github.Response)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetRef indicates an expected call of GetRef.
+func (mr *MockGitServiceMockRecorder) GetRef(ctx, owner, repo, ref any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRef", reflect.TypeOf((*MockGitService)(nil).GetRef), ctx, owner, repo, ref)
+}
+
+// MockPullRequestsService is a mock of PullRequestsService interface.
+type MockPullRequestsService struct {
+	ctrl     *gomock.Controller
+	recorder *MockPullRequestsServiceMockRecorder
+	isgomock struct{}
+}
+
+// MockPullRequestsServiceMockRecorder is the mock recorder for MockPullRequestsService.
+type MockPullRequestsServiceMockRecorder struct {
+	mock *MockPullRequestsService
+}
+
+// NewMockPullRequestsService creates a new mock instance.
+func NewMockPullRequestsService(ctrl *gomock.Controller) *MockPullRequestsService {
+	mock := &MockPullRequestsService{ctrl: ctrl}
+	mock.recorder = &MockPullRequestsServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPullRequestsService) EXPECT() *MockPullRequestsServiceMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockPullRequestsService) Create(ctx context.Context, owner, repo string, pull *github.NewPullRequest) (*github.PullRequest, *github.Response, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, owner, repo, pull)
+	ret0, _ := ret[0].(*github.PullRequest)
+	ret1, _ := ret[1].(*github.Response)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockPullRequestsServiceMockRecorder) Create(ctx, owner, repo, pull any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockPullRequestsService)(nil).Create), ctx, owner, repo, pull)
+}
@@ -0,0 +1,236 @@
+package ownershit
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/google/go-github/v66/github"
+	"github.com/rs/zerolog/log"
+)
+
+// scopeAdminOrg is the classic token scope required to edit organization settings.
+const scopeAdminOrg = "admin:org"
+
+// Valid base repository permissions for organization members.
+var validBasePermissions = []string{"read", "write", "admin", "none"}
+
+var validBranchName = regexp.MustCompile(`^[A-Za-z0-9._/-]+$`)
+
+// OrganizationSettings configures organization-wide settings. Nil fields leave the current value untouched.
+type OrganizationSettings struct {
+	BaseRepositoryPermission            *string `yaml:"base_repository_permission,omitempty"`
+	MembersCanCreatePublicRepositories  *bool   `yaml:"members_can_create_public_repositories,omitempty"`
+	MembersCanCreatePrivateRepositories *bool   `yaml:"members_can_create_private_repositories,omitempty"`
+	WebCommitSignoffRequired            *bool   `yaml:"web_commit_signoff_required,omitempty"`
+	// DefaultBranchName cannot be set through the GitHub API; it is validated and reported only.
+	DefaultBranchName *string `yaml:"default_branch_name,omitempty"`
+
+	// Security defaults for newly created repositories.
+	DependencyGraphForNewRepositories              *bool `yaml:"dependency_graph_for_new_repositories,omitempty"`
+	DependabotAlertsForNewRepositories             *bool `yaml:"dependabot_alerts_for_new_repositories,omitempty"`
+	DependabotSecurityUpdatesForNewRepositories    *bool `yaml:"dependabot_security_updates_for_new_repositories,omitempty"`
+	AdvancedSecurityForNewRepositories             *bool `yaml:"advanced_security_for_new_repositories,omitempty"`
+	SecretScanningForNewRepositories               *bool `yaml:"secret_scanning_for_new_repositories,omitempty"`
+	SecretScanningPushProtectionForNewRepositories *bool `yaml:"secret_scanning_push_protection_for_new_repositories,omitempty"`
+}
+
+// OrganizationSettingChange is a single organization setting whose current value differs from the configuration.
+type OrganizationSettingChange struct {
+	Setting string
+	Current interface{}
+	Desired interface{}
+}
+
+// ValidateOrganizationSettings checks allowed values and dependencies between security defaults.
+func ValidateOrganizationSettings(s *OrganizationSettings) error {
+	if s == nil {
+		return nil
+	}
+	const field = "organization_settings"
+	if s.BaseRepositoryPermission != nil && !containsString(validBasePermissions, *s.BaseRepositoryPermission) {
+		return NewConfigValidationError(field+".base_repository_permission", *s.BaseRepositoryPermission,
+			fmt.Sprintf("base repository permission must be one of %s", strings.Join(validBasePermissions, ", ")), nil)
+	}
+	if s.DefaultBranchName != nil {
+		name := *s.DefaultBranchName
+		if !validBranchName.MatchString(name) || strings.HasPrefix(name, "/") || strings.HasSuffix(name, "/") ||
+			strings.Contains(name, "..") {
+			return NewConfigValidationError(field+".default_branch_name", name, "invalid branch name", nil)
+		}
+	}
+	if isTrue(s.DependabotAlertsForNewRepositories) && isFalse(s.DependencyGraphForNewRepositories) {
+		return NewConfigValidationError(field+".dependabot_alerts_for_new_repositories", true,
+			"Dependabot alerts require the dependency graph", nil)
+	}
+	if isTrue(s.DependabotSecurityUpdatesForNewRepositories) && isFalse(s.DependabotAlertsForNewRepositories) {
+		return NewConfigValidationError(field+".dependabot_security_updates_for_new_repositories", true,
+			"Dependabot security updates require Dependabot alerts", nil)
+	}
+	if isTrue(s.SecretScanningPushProtectionForNewRepositories) && isFalse(s.SecretScanningForNewRepositories) {
+		return NewConfigValidationError(field+".secret_scanning_push_protection_for_new_repositories", true,
+			"push protection requires secret scanning", nil)
+	}
+	return nil
+}
+
+func isTrue(b *bool) bool  { return b != nil && *b }
+func isFalse(b *bool) bool { return b != nil && !*b }
+
+// toGitHubOrganization builds the organization edit payload with only the configured fields.
+func (s *OrganizationSettings) toGitHubOrganization() *github.Organization {
+	return &github.Organization{
+		DefaultRepoPermission:                          s.BaseRepositoryPermission,
+		MembersCanCreatePublicRepos:                    s.MembersCanCreatePublicRepositories,
+		MembersCanCreatePrivateRepos:                   s.MembersCanCreatePrivateRepositories,
+		WebCommitSignoffRequired:                       s.WebCommitSignoffRequired,
+		DependencyGraphEnabledForNewRepos:              s.DependencyGraphForNewRepositories,
+		DependabotAlertsEnabledForNewRepos:             s.DependabotAlertsForNewRepositories,
+		DependabotSecurityUpdatesEnabledForNewRepos:    s.DependabotSecurityUpdatesForNewRepositories,
+		AdvancedSecurityEnabledForNewRepos:             s.AdvancedSecurityForNewRepositories,
+		SecretScanningEnabledForNewRepos:               s.SecretScanningForNewRepositories,
+		SecretScanningPushProtectionEnabledForNewRepos: s.SecretScanningPushProtectionForNewRepositories,
+	}
+}
+
+// DiffOrganizationSettings returns the configured settings whose values differ from current.
+func DiffOrganizationSettings(s *OrganizationSettings, current *github.Organization) []OrganizationSettingChange {
+	var changes []OrganizationSettingChange
+	if s.BaseRepositoryPermission != nil && *s.BaseRepositoryPermission != current.GetDefaultRepoPermission() {
+		changes = append(changes, OrganizationSettingChange{
+			Setting: "base_repository_permission",
+			Current: current.GetDefaultRepoPermission(),
+			Desired: *s.BaseRepositoryPermission,
+		})
+	}
+	for _, b := range []struct {
+		setting string
+		desired *bool
+		current *bool
+	}{
+		{"members_can_create_public_repositories", s.MembersCanCreatePublicRepositories, current.MembersCanCreatePublicRepos},
+		{"members_can_create_private_repositories", s.MembersCanCreatePrivateRepositories, current.MembersCanCreatePrivateRepos},
+		{"web_commit_signoff_required", s.WebCommitSignoffRequired, current.WebCommitSignoffRequired},
+		{"dependency_graph_for_new_repositories", s.DependencyGraphForNewRepositories, current.DependencyGraphEnabledForNewRepos},
+		{"dependabot_alerts_for_new_repositories", s.DependabotAlertsForNewRepositories, current.DependabotAlertsEnabledForNewRepos},
+		{
+			"dependabot_security_updates_for_new_repositories",
+			s.DependabotSecurityUpdatesForNewRepositories, current.DependabotSecurityUpdatesEnabledForNewRepos,
+		},
+		{"advanced_security_for_new_repositories", s.AdvancedSecurityForNewRepositories, current.AdvancedSecurityEnabledForNewRepos},
+		{"secret_scanning_for_new_repositories", s.SecretScanningForNewRepositories, current.SecretScanningEnabledForNewRepos},
+		{
+			"secret_scanning_push_protection_for_new_repositories",
+			s.SecretScanningPushProtectionForNewRepositories, current.SecretScanningPushProtectionEnabledForNewRepos,
+		},
+	} {
+		if b.desired == nil || (b.current != nil && *b.current == *b.desired) {
+			continue
+		}
+		var cur interface{}
+		if b.current != nil {
+			cur = *b.current
+		}
+		changes = append(changes, OrganizationSettingChange{Setting: b.setting, Current: cur, Desired: *b.desired})
+	}
+	return changes
+}
+
+// hasScope reports whether the comma-separated X-OAuth-Scopes header grants scope.
+func hasScope(header, scope string) bool {
+	for _, s := range strings.Split(header, ",") {
+		if strings.TrimSpace(s) == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// ApplyOrganizationSettings updates organization-wide settings after checking the token can edit them.
+// If dryRun is true, it logs each setting that would change.
+func ApplyOrganizationSettings(settings *PermissionsSettings, client *GitHubClient, dryRun bool) error {
+	orgSettings := settings.OrganizationSettings
+	if orgSettings == nil {
+		return nil
+	}
+	org := *settings.Organization
+
+	current, scopes, err := client.GetOrganization(org)
+	if err != nil {
+		log.Err(err).Str("organization", org).Msg("getting organization settings")
+		return err
+	}
+	if err := checkOrganizationAdminScope(org, scopes); err != nil {
+		log.Err(err).Str("organization", org).Msg("cannot manage organization settings")
+		return err
+	}
+
+	if orgSettings.DefaultBranchName != nil {
+		log.Warn().
+			Str("organization", org).
+			Str("default_branch_name", *orgSettings.DefaultBranchName).
+			Msg("the GitHub API cannot set the default branch name for new repositories; set it in the organization's repository settings")
+	}
+
+	changes := DiffOrganizationSettings(orgSettings, current)
+	if len(changes) == 0 {
+		log.Info().Str("organization", org).Msg("organization settings are up to date")
+		return nil
+	}
+	if dryRun {
+		for _, change := range changes {
+			log.Info().
+				Str("organization", org).
+				Str("setting", change.Setting).
+				Interface("current", change.Current).
+				Interface("desired", change.Desired).
+				Msg("Would update organization setting")
+		}
+		return nil
+	}
+	if err := client.EditOrganizationSettings(org, orgSettings); err != nil {
+		log.Err(err).Str("organization", org).Msg("updating organization settings")
+		return err
+	}
+	return nil
+}
+
+// checkOrganizationAdminScope returns a PermissionDeniedError when a classic token's scopes
+// lack admin:org. Fine-grained and app tokens do not report scopes and are not checked.
+func checkOrganizationAdminScope(org, scopes string) error {
+	if scopes == "" {
+		log.Debug().Str("organization", org).Msg("token scopes not reported; skipping admin:org check")
+		return nil
+	}
+	if !hasScope(scopes, scopeAdminOrg) {
+		return NewPermissionDeniedError("update organization settings", org, scopeAdminOrg,
+			fmt.Sprintf("token scopes are %q", scopes), nil)
+	}
+	return nil
+}
+
+// GetOrganization returns the organization and the token's X-OAuth-Scopes header, which is
+// empty for tokens that do not report classic scopes.
+func (c *GitHubClient) GetOrganization(org string) (*github.Organization, string, error) {
+	organization, resp, err := c.Organizations.Get(c.Context, org)
+	if err != nil {
+		return nil, "", classifyAPIError(responseStatus(resp), "get organization", org,
+			"failed to get organization", err)
+	}
+	var scopes string
+	if resp != nil && resp.Response != nil {
+		scopes = resp.Header.Get("X-OAuth-Scopes")
+	}
+	return organization, scopes, nil
+}
+
+// EditOrganizationSettings applies the configured organization settings.
+func (c *GitHubClient) EditOrganizationSettings(org string, s *OrganizationSettings) error {
+	_, resp, err := c.Organizations.Edit(c.Context, org, s.toGitHubOrganization())
+	if err != nil {
+		return classifyAPIError(responseStatus(resp), "edit organization", org,
+			"failed to update organization settings", err)
+	}
+	log.Info().Str("organization", org).Msg("Successfully updated organization settings")
+	return nil
+}
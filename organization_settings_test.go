@@ -0,0 +1,126 @@
+package ownershit
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-github/v66/github"
+	"go.uber.org/mock/gomock"
+)
+
+func TestValidateOrganizationSettings(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings *OrganizationSettings
+		wantErr  bool
+	}{
+		{name: "nil"},
+		{name: "valid", settings: &OrganizationSettings{
+			BaseRepositoryPermission:          stringPtr("read"),
+			DefaultBranchName:                 stringPtr("main"),
+			DependencyGraphForNewRepositories: boolPtr(true),
+		}},
+		{name: "bad base permission", settings: &OrganizationSettings{BaseRepositoryPermission: stringPtr("push")}, wantErr: true},
+		{name: "bad branch name", settings: &OrganizationSettings{DefaultBranchName: stringPtr("feature..x")}, wantErr: true},
+		{name: "alerts without graph", settings: &OrganizationSettings{
+			DependabotAlertsForNewRepositories: boolPtr(true),
+			DependencyGraphForNewRepositories:  boolPtr(false),
+		}, wantErr: true},
+		{name: "push protection without scanning", settings: &OrganizationSettings{
+			SecretScanningPushProtectionForNewRepositories: boolPtr(true),
+			SecretScanningForNewRepositories:               boolPtr(false),
+		}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateOrganizationSettings(tt.settings); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateOrganizationSettings() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDiffOrganizationSettings(t *testing.T) {
+	s := &OrganizationSettings{
+		BaseRepositoryPermission:           stringPtr("none"),
+		MembersCanCreatePublicRepositories: boolPtr(false),
+		WebCommitSignoffRequired:           boolPtr(true),
+	}
+	current := &github.Organization{
+		DefaultRepoPermission:       github.String("read"),
+		MembersCanCreatePublicRepos: github.Bool(false),
+	}
+	changes := DiffOrganizationSettings(s, current)
+	if len(changes) != 2 {
+		t.Fatalf("expected 2 changes, got %+v", changes)
+	}
+	if changes[0].Setting != "base_repository_permission" || changes[0].Desired != "none" {
+		t.Errorf("unexpected change %+v", changes[0])
+	}
+	if changes[1].Setting != "web_commit_signoff_required" || changes[1].Current != nil {
+		t.Errorf("unexpected change %+v", changes[1])
+	}
+}
+
+func TestCheckOrganizationAdminScope(t *testing.T) {
+	if err := checkOrganizationAdminScope("org", ""); err != nil {
+		t.Errorf("expected no error without scopes header, got %v", err)
+	}
+	if err := checkOrganizationAdminScope("org", "repo, admin:org, user"); err != nil {
+		t.Errorf("expected no error with admin:org, got %v", err)
+	}
+	err := checkOrganizationAdminScope("org", "repo, read:org")
+	if !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("expected permission denied, got %v", err)
+	}
+}
+
+func orgResponse(scopes string) *github.Response {
+	header := http.Header{}
+	header.Set("X-OAuth-Scopes", scopes)
+	return &github.Response{Response: &http.Response{StatusCode: http.StatusOK, Header: header}}
+}
+
+func TestApplyOrganizationSettings(t *testing.T) {
+	m := setupMocks(t)
+	settings := &PermissionsSettings{
+		Organization: stringPtr("org"),
+		OrganizationSettings: &OrganizationSettings{
+			BaseRepositoryPermission: stringPtr("none"),
+			WebCommitSignoffRequired: boolPtr(true),
+		},
+	}
+	m.orgsMock.EXPECT().Get(gomock.Any(), "org").
+		Return(&github.Organization{DefaultRepoPermission: github.String("read")}, orgResponse("repo, admin:org"), nil)
+	m.orgsMock.EXPECT().Edit(gomock.Any(), "org", &github.Organization{
+		DefaultRepoPermission:    github.String("none"),
+		WebCommitSignoffRequired: github.Bool(true),
+	}).Return(&github.Organization{}, defaultGoodResponse, nil)
+
+	ApplyOrganizationSettings(settings, m.client, false)
+}
+
+func TestApplyOrganizationSettingsSkipsWithoutScope(t *testing.T) {
+	m := setupMocks(t)
+	settings := &PermissionsSettings{
+		Organization:         stringPtr("org"),
+		OrganizationSettings: &OrganizationSettings{BaseRepositoryPermission: stringPtr("none")},
+	}
+	m.orgsMock.EXPECT().Get(gomock.Any(), "org").
+		Return(&github.Organization{}, orgResponse("repo"), nil)
+	// Edit must not be called when the token lacks admin:org.
+	ApplyOrganizationSettings(settings, m.client, false)
+}
+
+func TestApplyOrganizationSettingsDryRun(t *testing.T) {
+	m := setupMocks(t)
+	settings := &PermissionsSettings{
+		Organization:         stringPtr("org"),
+		OrganizationSettings: &OrganizationSettings{MembersCanCreatePrivateRepositories: boolPtr(false)},
+	}
+	m.orgsMock.EXPECT().Get(gomock.Any(), "org").
+		Return(&github.Organization{MembersCanCreatePrivateRepos: github.Bool(true)}, defaultGoodResponse, nil)
+	// Dry run must not call Edit.
+	ApplyOrganizationSettings(settings, m.client, true)
+}
@@ -0,0 +1,270 @@
+package ownershit
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// DefaultTokenEnv is the environment variable holding the GitHub token.
+const DefaultTokenEnv = "GITHUB_TOKEN"
+
+// OrganizationConfig is one entry of the organizations list. Its settings are layered over the
+// top-level settings, which are shared by every organization: branches, defaults and security are
+// merged field by field, team, default_labels, default_topics and profiles are merged by name, and
+// every other list replaces the shared one when set.
+type OrganizationConfig struct {
+	Name string `yaml:"name"`
+	// TokenEnv names the environment variable holding the token for this organization; GITHUB_TOKEN
+	// is used when it is empty.
+	TokenEnv string `yaml:"token_env,omitempty"`
+	// GitHubURL is the GitHub Enterprise Server hosting this organization; the top-level github_url
+	// is used when it is empty.
+	GitHubURL            string                      `yaml:"github_url,omitempty"`
+	OrganizationSettings *OrganizationSettings       `yaml:"organization_settings,omitempty"`
+	Defaults             *RepositoryDefaults         `yaml:"defaults,omitempty"`
+	BranchPermissions    *BranchPermissions          `yaml:"branches,omitempty"`
+	TeamPermissions      []*Permissions              `yaml:"team,omitempty"`
+	Teams                []*TeamDefinition           `yaml:"teams,omitempty"`
+	Security             *SecuritySettings           `yaml:"security,omitempty"`
+	Autolinks            []Autolink                  `yaml:"autolinks,omitempty"`
+	DefaultLabels        []RepoLabel                 `yaml:"default_labels,omitempty"`
+	DefaultTopics        []string                    `yaml:"default_topics,omitempty"`
+	Files                []*FileSync                 `yaml:"files,omitempty"`
+	CustomProperties     []*CustomPropertyDefinition `yaml:"custom_properties,omitempty"`
+	Profiles             map[string]*Profile         `yaml:"profiles,omitempty"`
+	Selectors            []*RepositorySelector       `yaml:"selectors,omitempty"`
+	Repositories         []*Repository               `yaml:"repositories,omitempty"`
+}
+
+// ResolvedOrganization is the complete configuration of one organization.
+type ResolvedOrganization struct {
+	Name     string
+	TokenEnv string
+	// GitHubURL is the configured GitHub URL, empty for the default.
+	GitHubURL string
+	Settings  *PermissionsSettings
+}
+
+// ErrOrganizationNotFound is returned when a command names an organization that is not configured.
+var ErrOrganizationNotFound = errors.New("organization is not configured")
+
+// ResolveOrganizations returns the settings of every configured organization, in order. A
+// configuration without an organizations list describes a single organization and is returned as is.
+func ResolveOrganizations(settings *PermissionsSettings) []*ResolvedOrganization {
+	if len(settings.Organizations) == 0 {
+		return []*ResolvedOrganization{{
+			Name:      stringValue(settings.Organization),
+			TokenEnv:  DefaultTokenEnv,
+			GitHubURL: stringValue(settings.GitHubURL),
+			Settings:  settings,
+		}}
+	}
+
+	// Fold the legacy default_* fields into a private copy of the shared defaults once.
+	shared := *settings
+	if shared.Defaults != nil {
+		defaults := *shared.Defaults
+		shared.Defaults = &defaults
+	}
+	shared.MigrateToNestedDefaults()
+	shared.DefaultWiki, shared.DefaultIssues, shared.DefaultProjects = nil, nil, nil
+
+	resolved := make([]*ResolvedOrganization, 0, len(settings.Organizations))
+	for _, org := range settings.Organizations {
+		if org == nil {
+			continue
+		}
+		tokenEnv := org.TokenEnv
+		if tokenEnv == "" {
+			tokenEnv = DefaultTokenEnv
+		}
+		effective := shared.forOrganization(org)
+		resolved = append(resolved, &ResolvedOrganization{
+			Name:      org.Name,
+			TokenEnv:  tokenEnv,
+			GitHubURL: stringValue(effective.GitHubURL),
+			Settings:  effective,
+		})
+	}
+	return resolved
+}
+
+// FindOrganization returns the resolved organization called name, ignoring case.
+func FindOrganization(organizations []*ResolvedOrganization, name string) (*ResolvedOrganization, error) {
+	for _, org := range organizations {
+		if strings.EqualFold(org.Name, name) {
+			return org, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrOrganizationNotFound, name)
+}
+
+// forOrganization layers an organizations entry over the shared settings s.
+func (s *PermissionsSettings) forOrganization(org *OrganizationConfig) *PermissionsSettings {
+	effective := *s
+	effective.Organizations = nil
+	name := org.Name
+	effective.Organization = &name
+	effective.Repositories = org.Repositories
+	effective.Selectors = org.Selectors
+
+	if org.BranchPermissions != nil {
+		effective.BranchPermissions = mergeBranchPermissions(s.BranchPermissions, org.BranchPermissions)
+	}
+	effective.Defaults = mergeRepositoryDefaults(s.Defaults, org.Defaults)
+	if org.Security != nil {
+		effective.Security = ResolveSecuritySettings(s.Security, org.Security)
+	}
+	effective.TeamPermissions = mergeTeamPermissions(s.TeamPermissions, org.TeamPermissions)
+	effective.DefaultLabels = mergeLabels(s.DefaultLabels, org.DefaultLabels)
+	effective.DefaultTopics = mergeTopics(s.DefaultTopics, org.DefaultTopics)
+	if len(org.Profiles) > 0 {
+		effective.Profiles = make(map[string]*Profile, len(s.Profiles)+len(org.Profiles))
+		for name, profile := range s.Profiles {
+			effective.Profiles[name] = profile
+		}
+		for name, profile := range org.Profiles {
+			effective.Profiles[name] = profile
+		}
+	}
+
+	if org.GitHubURL != "" {
+		githubURL := org.GitHubURL
+		effective.GitHubURL = &githubURL
+	}
+	if org.OrganizationSettings != nil {
+		effective.OrganizationSettings = org.OrganizationSettings
+	}
+	if org.Teams != nil {
+		effective.Teams = org.Teams
+	}
+	if org.Autolinks != nil {
+		effective.Autolinks = org.Autolinks
+	}
+	if org.Files != nil {
+		effective.Files = org.Files
+	}
+	if org.CustomProperties != nil {
+		effective.CustomProperties = org.CustomProperties
+	}
+	return &effective
+}
+
+// organizationsErrors validates the organizations list and the resolved settings of each entry.
+// Errors in settings an entry defines itself are reported under organizations[i]; errors in shared
+// settings are reported once, at their top-level path.
+func organizationsErrors(settings *PermissionsSettings) []error {
+	var errs []error
+	if settings.Organization != nil && *settings.Organization != "" {
+		errs = append(errs, NewConfigValidationError("organization", *settings.Organization,
+			"organization cannot be combined with organizations; move it into an organizations entry", nil))
+	}
+	if len(settings.Repositories) > 0 {
+		errs = append(errs, NewConfigValidationError("repositories", nil,
+			"repositories must be listed under their organization when organizations is used", nil))
+	}
+	if len(settings.Selectors) > 0 {
+		errs = append(errs, NewConfigValidationError("selectors", nil,
+			"selectors must be listed under their organization when organizations is used", nil))
+	}
+
+	names := make(map[string]bool)
+	for i, org := range settings.Organizations {
+		field := fmt.Sprintf("organizations[%d]", i)
+		if org == nil {
+			errs = append(errs, NewConfigValidationError(field, nil, "organization entry cannot be empty", nil))
+			continue
+		}
+		name := strings.ToLower(strings.TrimSpace(org.Name))
+		if names[name] && name != "" {
+			errs = append(errs, NewConfigValidationError(field+".name", org.Name, "duplicate organization", nil))
+		}
+		names[name] = true
+		if org.TokenEnv != "" && !envVarName.MatchString(org.TokenEnv) {
+			errs = append(errs, NewConfigValidationError(field+".token_env", org.TokenEnv,
+				"token_env must be an environment variable name", nil))
+		}
+	}
+
+	shared := make(map[string]bool)
+	resolved := ResolveOrganizations(settings)
+	for i, entry := range settings.Organizations {
+		if entry == nil {
+			continue
+		}
+		org := resolved[0]
+		resolved = resolved[1:]
+		for _, err := range PermissionsSettingsErrors(org.Settings) {
+			if scoped, ok := scopeOrganizationError(err, fmt.Sprintf("organizations[%d]", i), entry); ok {
+				errs = append(errs, scoped)
+				continue
+			}
+			if !shared[err.Error()] {
+				shared[err.Error()] = true
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errs
+}
+
+// scopeOrganizationError moves an error under the organizations entry when the entry sets the
+// top-level field the error refers to, reporting false when the error concerns shared settings.
+func scopeOrganizationError(err error, prefix string, org *OrganizationConfig) (error, bool) {
+	var validationErr *ConfigValidationError
+	field := ""
+	if errors.As(err, &validationErr) {
+		field = validationErr.Field
+	} else if match := filesErrorPrefix.FindStringSubmatch(err.Error()); match != nil {
+		field = match[1]
+	}
+	top := strings.SplitN(strings.SplitN(field, ".", 2)[0], "[", 2)[0]
+	switch {
+	case top == "organization":
+		top = "name"
+	case org.BranchPermissions != nil && isBranchPermissionsField(top):
+		// Branch protection errors name the field without its block.
+		field, top = "branches."+field, "branches"
+	}
+	if !organizationSetsField(org, top) {
+		return nil, false
+	}
+	if validationErr == nil {
+		return fmt.Errorf("%s.%w", prefix, err), true
+	}
+	scopedField := prefix + "." + field
+	if top == "name" {
+		scopedField = prefix + ".name"
+	}
+	return NewConfigValidationError(scopedField, validationErr.Value, validationErr.Message, validationErr.Err), true
+}
+
+func isBranchPermissionsField(name string) bool {
+	for _, field := range yamlFields(reflect.TypeOf(BranchPermissions{})) {
+		if field.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// organizationSetsField reports whether the entry sets the field with the given YAML name.
+// Repository-level fields always belong to the entry.
+func organizationSetsField(org *OrganizationConfig, name string) bool {
+	switch name {
+	case "name", configKeyRepositories, "selectors":
+		return true
+	case "":
+		return false
+	}
+	value := reflect.ValueOf(org).Elem()
+	t := value.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if strings.Split(t.Field(i).Tag.Get("yaml"), ",")[0] == name {
+			return !value.Field(i).IsZero()
+		}
+	}
+	return false
+}
@@ -0,0 +1,238 @@
+package ownershit
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const multiOrgConfig = `defaults:
+  wiki: false
+  issues: true
+branches:
+  require_approving_count: 1
+team:
+  - name: developers
+    level: push
+default_labels:
+  - name: bug
+    color: ff0000
+organizations:
+  - name: acme
+    repositories:
+      - name: api
+  - name: acme-labs
+    token_env: LABS_GITHUB_TOKEN
+    defaults:
+      wiki: true
+    branches:
+      require_approving_count: 2
+    team:
+      - name: developers
+        level: admin
+    default_labels:
+      - name: experiment
+        color: 00ff00
+    repositories:
+      - name: api
+      - name: sandbox
+`
+
+func TestResolveOrganizations(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{"repositories.yaml": multiOrgConfig})
+	settings, err := LoadConfiguration(filepath.Join(dir, "repositories.yaml"))
+	if err != nil {
+		t.Fatalf("LoadConfiguration() error = %v", err)
+	}
+	if err := ValidatePermissionsSettings(settings); err != nil {
+		t.Fatalf("ValidatePermissionsSettings() error = %v", err)
+	}
+
+	orgs := ResolveOrganizations(settings)
+	if len(orgs) != 2 {
+		t.Fatalf("expected 2 organizations, got %d", len(orgs))
+	}
+	acme, labs := orgs[0], orgs[1]
+	if acme.TokenEnv != DefaultTokenEnv || labs.TokenEnv != "LABS_GITHUB_TOKEN" {
+		t.Errorf("token envs = %q, %q", acme.TokenEnv, labs.TokenEnv)
+	}
+	if stringValue(acme.Settings.Organization) != "acme" || len(acme.Settings.Repositories) != 1 {
+		t.Errorf("unexpected acme settings: %+v", acme.Settings)
+	}
+	if acme.Settings.Organizations != nil {
+		t.Error("resolved settings must not list organizations")
+	}
+
+	// Shared settings apply where the entry is silent; entry settings win field by field.
+	if *acme.Settings.Defaults.Wiki || !*labs.Settings.Defaults.Wiki || !*labs.Settings.Defaults.Issues {
+		t.Errorf("defaults not merged: acme %+v, labs %+v", acme.Settings.Defaults, labs.Settings.Defaults)
+	}
+	if *acme.Settings.ApproverCount != 1 || *labs.Settings.ApproverCount != 2 {
+		t.Error("branch protection not merged per organization")
+	}
+	if got := *labs.Settings.TeamPermissions[0].Level; got != "admin" {
+		t.Errorf("labs developers level = %q, want admin", got)
+	}
+	var labels []string
+	for _, label := range labs.Settings.DefaultLabels {
+		labels = append(labels, label.Name)
+	}
+	if !reflect.DeepEqual(labels, []string{"bug", "experiment"}) {
+		t.Errorf("labs labels = %v", labels)
+	}
+	if _, err := FindOrganization(orgs, "ACME-LABS"); err != nil {
+		t.Errorf("FindOrganization() error = %v", err)
+	}
+	if _, err := FindOrganization(orgs, "other"); !errors.Is(err, ErrOrganizationNotFound) {
+		t.Errorf("expected ErrOrganizationNotFound, got %v", err)
+	}
+}
+
+func TestResolveOrganizationsSingle(t *testing.T) {
+	settings := &PermissionsSettings{Organization: stringPtr("acme")}
+	orgs := ResolveOrganizations(settings)
+	if len(orgs) != 1 || orgs[0].Name != "acme" || orgs[0].Settings != settings {
+		t.Errorf("a single-organization configuration should resolve to itself, got %+v", orgs)
+	}
+}
+
+func TestOrganizationsValidation(t *testing.T) {
+	settings := &PermissionsSettings{
+		Organization: stringPtr("acme"),
+		Organizations: []*OrganizationConfig{
+			{Name: "acme", Repositories: []*Repository{{Name: stringPtr("api")}, {Name: stringPtr("api")}}},
+			{Name: "ACME", TokenEnv: "not-a-var", Repositories: []*Repository{{Name: stringPtr("web")}}},
+			{Name: "labs", BranchPermissions: &BranchPermissions{ApproverCount: intPtr(500)}},
+		},
+	}
+	var fields []string
+	for _, err := range PermissionsSettingsErrors(settings) {
+		var validationErr *ConfigValidationError
+		if errors.As(err, &validationErr) {
+			fields = append(fields, validationErr.Field)
+		}
+	}
+	want := []string{
+		"organization",
+		"organizations[1].name",
+		"organizations[1].token_env",
+		"organizations[0].repositories[1].name",
+		"organizations[2].branches.require_approving_count",
+		"organizations[2].repositories",
+	}
+	if !reflect.DeepEqual(fields, want) {
+		t.Errorf("error fields = %v\nwant %v", fields, want)
+	}
+}
+
+func TestOrganizationsSharedErrorsReportedOnce(t *testing.T) {
+	settings := &PermissionsSettings{
+		BranchPermissions: BranchPermissions{ApproverCount: intPtr(500)},
+		Organizations: []*OrganizationConfig{
+			{Name: "acme", Repositories: []*Repository{{Name: stringPtr("api")}}},
+			{Name: "labs", Repositories: []*Repository{{Name: stringPtr("api")}}},
+		},
+	}
+	errs := PermissionsSettingsErrors(settings)
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "require_approving_count") {
+		t.Errorf("expected one shared branch error, got %v", errs)
+	}
+}
+
+func TestLoadConfigurationOrganizationsAcrossFiles(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"00-shared.yaml": "defaults:\n  wiki: false\n",
+		"acme.yaml":      "organizations:\n  - name: acme\n    repositories:\n      - name: api\n",
+		"labs.yaml":      "organizations:\n  - name: labs\n    repositories:\n      - name: api\n",
+	})
+	settings, err := LoadConfiguration(dir)
+	if err != nil {
+		t.Fatalf("LoadConfiguration() error = %v", err)
+	}
+	if len(settings.Organizations) != 2 {
+		t.Errorf("expected organizations from both files, got %d", len(settings.Organizations))
+	}
+
+	dup := writeConfigFiles(t, map[string]string{
+		"a.yaml": "organizations:\n  - name: acme\n",
+		"b.yaml": "organizations:\n  - name: ACME\n",
+	})
+	if _, err := LoadConfiguration(dup); err == nil || !strings.Contains(err.Error(), "duplicate organization") {
+		t.Errorf("expected a duplicate organization error, got %v", err)
+	}
+}
+
+func TestExplainRepositoryOrganizations(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{"repositories.yaml": multiOrgConfig})
+	settings, err := LoadConfiguration(filepath.Join(dir, "repositories.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ExplainRepository(settings, "api"); !errors.Is(err, ErrAmbiguousRepository) {
+		t.Errorf("expected ErrAmbiguousRepository, got %v", err)
+	}
+	explanation, err := ExplainRepository(settings, "acme-labs/api")
+	if err != nil {
+		t.Fatalf("ExplainRepository() error = %v", err)
+	}
+	if explanation.Organization != "acme-labs" {
+		t.Errorf("organization = %q", explanation.Organization)
+	}
+	sources := make(map[string]ExplainedValue)
+	for _, v := range explanation.Values {
+		sources[v.Field] = v
+	}
+	if v := sources["wiki"]; v.Source != SourceOrganization || v.Detail != "acme-labs.defaults.wiki" {
+		t.Errorf("wiki = %+v", v)
+	}
+	if v := sources["issues"]; v.Source != SourceDefaults {
+		t.Errorf("issues = %+v", v)
+	}
+	if v := sources["team.developers"]; v.Source != SourceOrganization || v.Value != "admin" {
+		t.Errorf("team.developers = %+v", v)
+	}
+
+	explanation, err = ExplainRepository(settings, "sandbox")
+	if err != nil || explanation.Organization != "acme-labs" {
+		t.Errorf("unqualified unique repository: %+v, %v", explanation, err)
+	}
+}
+
+func TestFormatConfigurationOrganizations(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"repositories.yaml": `defaults:
+  wiki: false
+organizations:
+  - repositories:
+      - name: web
+        wiki: false
+      - name: api
+        wiki: true
+    defaults:
+      wiki: true
+    name: labs
+`,
+	})
+	files, err := FormatConfiguration(filepath.Join(dir, "repositories.yaml"))
+	if err != nil {
+		t.Fatalf("FormatConfiguration() error = %v", err)
+	}
+	want := `organizations:
+  - name: labs
+    defaults:
+      wiki: true
+    repositories:
+      - name: api
+      - name: web
+        wiki: false
+
+defaults:
+  wiki: false
+`
+	if got := string(files[0].Formatted); got != want {
+		t.Errorf("formatted configuration:\n%s\nwant:\n%s", got, want)
+	}
+}
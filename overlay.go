@@ -0,0 +1,251 @@
+package ownershit
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Overlay patch markers. A `$patch` key in a mapping, or a leading `- $patch: <mode>` item in a
+// list, changes how the overlay is merged into the base configuration.
+const (
+	overlayPatchKey     = "$patch"
+	overlayPatchReplace = "replace"
+	overlayPatchAppend  = "append"
+	overlayPatchDelete  = "delete"
+
+	// overlayDirectory holds named overlays next to the base configuration.
+	overlayDirectory = "overlays"
+)
+
+// ResolveOverlayPath returns the file for an overlay. Names with a YAML extension or a path
+// separator are used as given; bare names such as "prod" resolve to overlays/prod.yaml (or .yml)
+// next to the configuration file, or inside the configuration directory.
+func ResolveOverlayPath(configPath, overlay string) (string, error) {
+	if isYAMLFile(overlay) || strings.ContainsRune(overlay, filepath.Separator) || strings.Contains(overlay, "/") {
+		return overlay, nil
+	}
+	baseDir := ConfigBaseDir(configPath)
+	for _, ext := range []string{".yaml", ".yml"} {
+		candidate := filepath.Join(baseDir, overlayDirectory, overlay+ext)
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate, nil
+		}
+	}
+	return "", NewConfigFileError(filepath.Join(baseDir, overlayDirectory, overlay+".yaml"), "read",
+		fmt.Sprintf("overlay %q not found", overlay), os.ErrNotExist)
+}
+
+// overlayFiles returns the overlay files in the overlays directory of the configuration at path,
+// in lexical order.
+func overlayFiles(path string) []string {
+	dir := filepath.Join(ConfigBaseDir(path), overlayDirectory)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var files []string
+	for _, entry := range entries {
+		if !entry.IsDir() && isYAMLFile(entry.Name()) {
+			files = append(files, filepath.Join(dir, entry.Name()))
+		}
+	}
+	return files
+}
+
+// applyOverlay merges the overlay in file into the base configuration document.
+func (l *configLoader) applyOverlay(doc *yaml.Node, file string) error {
+	data, err := os.ReadFile(file) // #nosec G304 - overlay paths come from the user
+	if err != nil {
+		return NewConfigFileError(file, "read", "failed to read overlay", err)
+	}
+	var overlay yaml.Node
+	if err := yaml.Unmarshal(data, &overlay); err != nil {
+		return NewConfigFileError(file, "parse", "failed to parse overlay", err)
+	}
+	if len(overlay.Content) == 0 {
+		return nil
+	}
+	if overlay.Content[0].Kind != yaml.MappingNode {
+		return NewConfigFileError(file, "parse", "overlay must be a YAML mapping", nil)
+	}
+	l.track(file, overlay.Content[0])
+	merged, err := mergeOverlayNode(doc.Content[0], overlay.Content[0])
+	if err != nil {
+		return NewConfigFileError(file, "overlay", err.Error(), nil)
+	}
+	doc.Content[0] = merged
+	return nil
+}
+
+// mergeOverlayNode merges overlay into base with strategic-merge semantics:
+//   - mappings merge key by key; `$patch: replace` replaces the whole mapping and `$patch: delete`
+//     removes it from its parent;
+//   - lists of mappings with a `name` merge item by item, appending new names; an item with
+//     `$patch: delete` removes the named item;
+//   - other lists replace the base list unless led by `- $patch: append`;
+//   - scalars replace the base value.
+//
+// base may be nil when the overlay adds a new value.
+func mergeOverlayNode(base, overlay *yaml.Node) (*yaml.Node, error) {
+	switch overlay.Kind {
+	case yaml.MappingNode:
+		return mergeOverlayMapping(base, overlay)
+	case yaml.SequenceNode:
+		return mergeOverlaySequence(base, overlay)
+	default:
+		return overlay, nil
+	}
+}
+
+func mergeOverlayMapping(base, overlay *yaml.Node) (*yaml.Node, error) {
+	patch, err := overlayPatch(overlay)
+	if err != nil {
+		return nil, err
+	}
+	switch patch {
+	case overlayPatchReplace:
+		base = nil
+	case overlayPatchDelete:
+		return nil, nil
+	case overlayPatchAppend:
+		return nil, fmt.Errorf("line %d: %s %s applies to lists only", overlay.Line, overlayPatchKey, patch)
+	}
+	if base == nil || base.Kind != yaml.MappingNode {
+		base = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Line: overlay.Line}
+	}
+
+	for i := 0; i+1 < len(overlay.Content); i += 2 {
+		key, value := overlay.Content[i], overlay.Content[i+1]
+		if key.Value == overlayPatchKey {
+			continue
+		}
+		index := mappingIndex(base, key.Value)
+		var current *yaml.Node
+		if index >= 0 {
+			current = base.Content[index+1]
+		}
+		merged, err := mergeOverlayNode(current, value)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case merged == nil && index >= 0:
+			base.Content = append(base.Content[:index], base.Content[index+2:]...)
+		case merged == nil:
+		case index >= 0:
+			base.Content[index+1] = merged
+		default:
+			base.Content = append(base.Content, key, merged)
+		}
+	}
+	return base, nil
+}
+
+func mergeOverlaySequence(base, overlay *yaml.Node) (*yaml.Node, error) {
+	items := overlay.Content
+	mode := ""
+	if len(items) > 0 && items[0].Kind == yaml.MappingNode && len(items[0].Content) == 2 &&
+		items[0].Content[0].Value == overlayPatchKey {
+		mode = items[0].Content[1].Value
+		if mode != overlayPatchReplace && mode != overlayPatchAppend {
+			return nil, fmt.Errorf("line %d: list %s must be %s or %s",
+				items[0].Line, overlayPatchKey, overlayPatchReplace, overlayPatchAppend)
+		}
+		items = items[1:]
+	}
+
+	result := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Line: overlay.Line}
+	keyed := base != nil && base.Kind == yaml.SequenceNode && mode == "" && namedItems(items)
+	switch {
+	case keyed:
+		result.Content = append(result.Content, base.Content...)
+	case mode == overlayPatchAppend && base != nil && base.Kind == yaml.SequenceNode:
+		result.Content = append(result.Content, base.Content...)
+	}
+
+	for _, item := range items {
+		if !keyed {
+			merged, err := mergeOverlayNode(nil, item)
+			if err != nil {
+				return nil, err
+			}
+			if merged != nil {
+				result.Content = append(result.Content, merged)
+			}
+			continue
+		}
+
+		name := mappingValue(item, "name")
+		index := -1
+		for i, existing := range result.Content {
+			if mappingValue(existing, "name") == name {
+				index = i
+				break
+			}
+		}
+		var current *yaml.Node
+		if index >= 0 {
+			current = result.Content[index]
+		}
+		merged, err := mergeOverlayNode(current, item)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case merged == nil && index < 0:
+			return nil, fmt.Errorf("line %d: cannot delete %q: no such entry in the base configuration", item.Line, name)
+		case merged == nil:
+			result.Content = append(result.Content[:index], result.Content[index+1:]...)
+		case index >= 0:
+			result.Content[index] = merged
+		default:
+			result.Content = append(result.Content, merged)
+		}
+	}
+	return result, nil
+}
+
+// overlayPatch returns the `$patch` marker of a mapping, validating its value.
+func overlayPatch(node *yaml.Node) (string, error) {
+	index := mappingIndex(node, overlayPatchKey)
+	if index < 0 {
+		return "", nil
+	}
+	value := node.Content[index+1]
+	switch value.Value {
+	case overlayPatchReplace, overlayPatchAppend, overlayPatchDelete:
+		return value.Value, nil
+	}
+	return "", fmt.Errorf("line %d: unknown %s %q (expected %s, %s or %s)", value.Line, overlayPatchKey, value.Value,
+		overlayPatchReplace, overlayPatchAppend, overlayPatchDelete)
+}
+
+// namedItems reports whether every list item is a mapping with a name key, so items merge by name.
+func namedItems(items []*yaml.Node) bool {
+	if len(items) == 0 {
+		return false
+	}
+	for _, item := range items {
+		if mappingValue(item, "name") == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// mappingIndex returns the index of key in a mapping node's content, or -1.
+func mappingIndex(node *yaml.Node, key string) int {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return -1
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return i
+		}
+	}
+	return -1
+}
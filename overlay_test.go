@@ -0,0 +1,181 @@
+package ownershit
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+const overlayBaseConfig = `organization: staging-org
+branches:
+  require_status_checks: true
+  status_checks: [ci/build]
+  require_code_owners: true
+team:
+  - name: developers
+    level: push
+  - name: contractors
+    level: pull
+default_topics: [managed]
+repositories:
+  - name: api
+    wiki: false
+  - name: web
+`
+
+func TestLoadConfigurationWithOverlay(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"repositories.yaml": overlayBaseConfig,
+		"overlays/prod.yaml": `organization: prod-org
+branches:
+  status_checks:
+    - $patch: append
+    - ci/security
+team:
+  - name: developers
+    level: pull
+  - name: contractors
+    $patch: delete
+  - name: sre
+    level: admin
+default_topics: [production]
+repositories:
+  - name: api
+    wiki: true
+`,
+	})
+
+	settings, err := LoadConfiguration(filepath.Join(dir, "repositories.yaml"), "prod")
+	if err != nil {
+		t.Fatalf("LoadConfiguration() error = %v", err)
+	}
+	if *settings.Organization != "prod-org" {
+		t.Errorf("organization = %s", *settings.Organization)
+	}
+	if strings.Join(settings.StatusChecks, ",") != "ci/build,ci/security" {
+		t.Errorf("status_checks = %v", settings.StatusChecks)
+	}
+	if settings.RequireCodeOwners == nil || !*settings.RequireCodeOwners {
+		t.Error("expected untouched base branch settings to be kept")
+	}
+	var teams []string
+	for _, perm := range settings.TeamPermissions {
+		teams = append(teams, *perm.Team+"="+*perm.Level)
+	}
+	if strings.Join(teams, ",") != "developers=pull,sre=admin" {
+		t.Errorf("team = %v", teams)
+	}
+	if strings.Join(settings.DefaultTopics, ",") != "production" {
+		t.Errorf("default_topics = %v", settings.DefaultTopics)
+	}
+	if len(settings.Repositories) != 2 || !*settings.Repositories[0].Wiki {
+		t.Errorf("unexpected repositories: %+v", settings.Repositories)
+	}
+}
+
+func TestMergeOverlayNodeMarkers(t *testing.T) {
+	tests := []struct {
+		name    string
+		base    string
+		overlay string
+		want    string
+		wantErr string
+	}{
+		{
+			name:    "replace mapping",
+			base:    "branches: {require_code_owners: true, enforce_admins: true}\n",
+			overlay: "branches: {$patch: replace, enforce_admins: false}\n",
+			want:    "branches: {enforce_admins: false}\n",
+		},
+		{
+			name:    "delete key",
+			base:    "defaults: {wiki: true}\norganization: org\n",
+			overlay: "defaults: {$patch: delete}\n",
+			want:    "organization: org\n",
+		},
+		{
+			name:    "replace named list",
+			base:    "team: [{name: a, level: push}]\n",
+			overlay: "team: [{$patch: replace}, {name: b, level: pull}]\n",
+			want:    "team: [{name: b, level: pull}]\n",
+		},
+		{
+			name:    "delete missing entry",
+			base:    "team: [{name: a, level: push}]\n",
+			overlay: "team: [{name: b, $patch: delete}]\n",
+			wantErr: `cannot delete "b"`,
+		},
+		{
+			name:    "unknown marker",
+			base:    "branches: {}\n",
+			overlay: "branches: {$patch: merge}\n",
+			wantErr: `unknown $patch "merge"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var base, overlay, want yaml.Node
+			for node, src := range map[*yaml.Node]string{&base: tt.base, &overlay: tt.overlay, &want: tt.want} {
+				if err := yaml.Unmarshal([]byte(src), node); err != nil {
+					t.Fatal(err)
+				}
+			}
+			merged, err := mergeOverlayNode(base.Content[0], overlay.Content[0])
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("mergeOverlayNode() error = %v", err)
+			}
+			same, err := sameYAMLValue(merged, want.Content[0])
+			if err != nil || !same {
+				out, _ := yaml.Marshal(merged)
+				t.Errorf("merged = %s, want %s", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderConfigurationOverlayErrors(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"repositories.yaml":   overlayBaseConfig,
+		"overlays/broken.yml": "team:\n  - name: ghost\n    $patch: delete\n",
+	})
+	config := filepath.Join(dir, "repositories.yaml")
+
+	_, err := RenderConfiguration(config, "missing")
+	var fileErr *ConfigFileError
+	if !errors.As(err, &fileErr) || !strings.Contains(fileErr.Filename, filepath.Join("overlays", "missing.yaml")) {
+		t.Errorf("expected missing overlay error, got %v", err)
+	}
+
+	_, err = RenderConfiguration(config, "broken")
+	if !errors.As(err, &fileErr) || filepath.Base(fileErr.Filename) != "broken.yml" ||
+		!strings.Contains(fileErr.Message, "line 2") {
+		t.Errorf("expected overlay error for broken.yml line 2, got %v", err)
+	}
+}
+
+func TestConfigurationDirectorySkipsOverlays(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"base.yaml":          overlayBaseConfig,
+		"overlays/prod.yaml": "organization: prod-org\n",
+	})
+	settings, err := LoadConfiguration(dir)
+	if err != nil {
+		t.Fatalf("LoadConfiguration() error = %v", err)
+	}
+	if *settings.Organization != "staging-org" {
+		t.Errorf("overlays must not be loaded as part of the base, got %s", *settings.Organization)
+	}
+	settings, err = LoadConfiguration(dir, "prod")
+	if err != nil || *settings.Organization != "prod-org" {
+		t.Errorf("expected prod overlay to apply, got %v %v", settings, err)
+	}
+}
@@ -0,0 +1,242 @@
+package ownershit
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Profile is a named bundle of settings that repositories opt into with `profile:`.
+// Profile values are layered over the global settings; unset fields fall back to them.
+type Profile struct {
+	BranchPermissions *BranchPermissions  `yaml:"branches,omitempty"`
+	TeamPermissions   []*Permissions      `yaml:"team,omitempty"`
+	Defaults          *RepositoryDefaults `yaml:"defaults,omitempty"`
+	// Labels are merged with default_labels; labels with the same name replace the global ones.
+	Labels []RepoLabel `yaml:"labels,omitempty"`
+	// Topics are added to default_topics.
+	Topics []string `yaml:"topics,omitempty"`
+}
+
+// ValidateProfiles checks each profile's effective branch protection and team permissions,
+// and that every repository and selector refers to a defined profile.
+func ValidateProfiles(settings *PermissionsSettings) error {
+	names := make([]string, 0, len(settings.Profiles))
+	for name := range settings.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		field := fmt.Sprintf("profiles.%s", name)
+		profile := settings.Profiles[name]
+		if strings.TrimSpace(name) == "" {
+			return NewConfigValidationError("profiles", name, "profile name cannot be empty", nil)
+		}
+		if profile == nil {
+			return NewConfigValidationError(field, nil, "profile cannot be empty", nil)
+		}
+		if profile.BranchPermissions != nil {
+			merged := mergeBranchPermissions(settings.BranchPermissions, profile.BranchPermissions)
+			if err := ValidateBranchPermissions(&merged); err != nil {
+				return fmt.Errorf("%s: %w", field, err)
+			}
+		}
+		for i, perm := range profile.TeamPermissions {
+			if perm == nil || perm.Team == nil || perm.Level == nil {
+				return NewConfigValidationError(fmt.Sprintf("%s.team[%d]", field, i), perm,
+					"team permission requires name and level", nil)
+			}
+		}
+	}
+
+	for i, repo := range settings.Repositories {
+		if repo != nil && repo.Profile != nil && settings.Profiles[*repo.Profile] == nil {
+			return NewConfigValidationError(fmt.Sprintf("repositories[%d].profile", i), *repo.Profile,
+				"profile is not defined", nil)
+		}
+	}
+	for i, sel := range settings.Selectors {
+		if sel != nil && sel.Profile != "" && settings.Profiles[sel.Profile] == nil {
+			return NewConfigValidationError(fmt.Sprintf("selectors[%d].profile", i), sel.Profile,
+				"profile is not defined", nil)
+		}
+	}
+	return nil
+}
+
+// EffectiveSettings returns the settings that apply to repo: its profile layered over the global
+// settings. Settings are returned unchanged when the repository has no profile.
+func (s *PermissionsSettings) EffectiveSettings(repo *Repository) *PermissionsSettings {
+	if repo == nil || repo.Profile == nil {
+		return s
+	}
+	profile := s.Profiles[*repo.Profile]
+	if profile == nil {
+		log.Warn().
+			Str("repository", stringValue(repo.Name)).
+			Str("profile", *repo.Profile).
+			Msg("profile is not defined; using global settings")
+		return s
+	}
+
+	effective := *s
+	if profile.BranchPermissions != nil {
+		effective.BranchPermissions = mergeBranchPermissions(s.BranchPermissions, profile.BranchPermissions)
+	}
+	effective.TeamPermissions = mergeTeamPermissions(s.TeamPermissions, profile.TeamPermissions)
+	effective.Defaults = mergeRepositoryDefaults(s.Defaults, profile.Defaults)
+	effective.DefaultLabels = mergeLabels(s.DefaultLabels, profile.Labels)
+	effective.DefaultTopics = mergeTopics(s.DefaultTopics, profile.Topics)
+	return &effective
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// mergeBranchPermissions returns base with every field set in overlay replacing it.
+func mergeBranchPermissions(base BranchPermissions, overlay *BranchPermissions) BranchPermissions {
+	merged := base
+	if overlay.RequireCodeOwners != nil {
+		merged.RequireCodeOwners = overlay.RequireCodeOwners
+	}
+	if overlay.ApproverCount != nil {
+		merged.ApproverCount = overlay.ApproverCount
+	}
+	if overlay.RequirePullRequestReviews != nil {
+		merged.RequirePullRequestReviews = overlay.RequirePullRequestReviews
+	}
+	if overlay.AllowMergeCommit != nil {
+		merged.AllowMergeCommit = overlay.AllowMergeCommit
+	}
+	if overlay.AllowSquashMerge != nil {
+		merged.AllowSquashMerge = overlay.AllowSquashMerge
+	}
+	if overlay.AllowRebaseMerge != nil {
+		merged.AllowRebaseMerge = overlay.AllowRebaseMerge
+	}
+	if overlay.RequireStatusChecks != nil {
+		merged.RequireStatusChecks = overlay.RequireStatusChecks
+	}
+	if overlay.StatusChecks != nil {
+		merged.StatusChecks = overlay.StatusChecks
+	}
+	if overlay.RequireUpToDateBranch != nil {
+		merged.RequireUpToDateBranch = overlay.RequireUpToDateBranch
+	}
+	if overlay.EnforceAdmins != nil {
+		merged.EnforceAdmins = overlay.EnforceAdmins
+	}
+	if overlay.RestrictPushes != nil {
+		merged.RestrictPushes = overlay.RestrictPushes
+	}
+	if overlay.PushAllowlist != nil {
+		merged.PushAllowlist = overlay.PushAllowlist
+	}
+	if overlay.RequireConversationResolution != nil {
+		merged.RequireConversationResolution = overlay.RequireConversationResolution
+	}
+	if overlay.RequireLinearHistory != nil {
+		merged.RequireLinearHistory = overlay.RequireLinearHistory
+	}
+	if overlay.AllowForcePushes != nil {
+		merged.AllowForcePushes = overlay.AllowForcePushes
+	}
+	if overlay.AllowDeletions != nil {
+		merged.AllowDeletions = overlay.AllowDeletions
+	}
+	return merged
+}
+
+// mergeTeamPermissions returns base with overlay entries replacing those for the same team,
+// followed by overlay teams not present in base.
+func mergeTeamPermissions(base, overlay []*Permissions) []*Permissions {
+	if len(overlay) == 0 {
+		return base
+	}
+	byTeam := make(map[string]*Permissions, len(overlay))
+	for _, perm := range overlay {
+		byTeam[stringValue(perm.Team)] = perm
+	}
+	merged := make([]*Permissions, 0, len(base)+len(overlay))
+	seen := make(map[string]bool)
+	for _, perm := range base {
+		team := stringValue(perm.Team)
+		if override, ok := byTeam[team]; ok {
+			perm = override
+		}
+		merged = append(merged, perm)
+		seen[team] = true
+	}
+	for _, perm := range overlay {
+		if !seen[stringValue(perm.Team)] {
+			merged = append(merged, perm)
+		}
+	}
+	return merged
+}
+
+// mergeRepositoryDefaults layers overlay feature defaults over base.
+func mergeRepositoryDefaults(base, overlay *RepositoryDefaults) *RepositoryDefaults {
+	if overlay == nil {
+		return base
+	}
+	if base == nil {
+		base = &RepositoryDefaults{}
+	}
+	return &RepositoryDefaults{
+		Wiki:                coalesceBoolPtr(overlay.Wiki, base.Wiki),
+		Issues:              coalesceBoolPtr(overlay.Issues, base.Issues),
+		Projects:            coalesceBoolPtr(overlay.Projects, base.Projects),
+		DeleteBranchOnMerge: coalesceBoolPtr(overlay.DeleteBranchOnMerge, base.DeleteBranchOnMerge),
+	}
+}
+
+// mergeLabels returns base with overlay labels replacing those of the same name, followed by new labels.
+func mergeLabels(base, overlay []RepoLabel) []RepoLabel {
+	if len(overlay) == 0 {
+		return base
+	}
+	byName := make(map[string]RepoLabel, len(overlay))
+	for _, label := range overlay {
+		byName[strings.ToLower(label.Name)] = label
+	}
+	merged := make([]RepoLabel, 0, len(base)+len(overlay))
+	seen := make(map[string]bool)
+	for _, label := range base {
+		key := strings.ToLower(label.Name)
+		if override, ok := byName[key]; ok {
+			label = override
+		}
+		merged = append(merged, label)
+		seen[key] = true
+	}
+	for _, label := range overlay {
+		if !seen[strings.ToLower(label.Name)] {
+			merged = append(merged, label)
+		}
+	}
+	return merged
+}
+
+// mergeTopics returns the union of base and overlay topics, keeping order.
+func mergeTopics(base, overlay []string) []string {
+	if len(overlay) == 0 {
+		return base
+	}
+	merged := make([]string, 0, len(base)+len(overlay))
+	seen := make(map[string]bool)
+	for _, topic := range append(append([]string{}, base...), overlay...) {
+		if !seen[topic] {
+			merged = append(merged, topic)
+			seen[topic] = true
+		}
+	}
+	return merged
+}
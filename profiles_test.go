@@ -0,0 +1,101 @@
+package ownershit
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEffectiveSettings(t *testing.T) {
+	settings := &PermissionsSettings{
+		Organization: stringPtr("org"),
+		BranchPermissions: BranchPermissions{
+			RequireCodeOwners: boolPtr(false),
+			ApproverCount:     intPtr(1),
+			AllowMergeCommit:  boolPtr(true),
+		},
+		TeamPermissions: []*Permissions{
+			{Team: stringPtr("developers"), Level: stringPtr("push")},
+		},
+		Defaults:      &RepositoryDefaults{Wiki: boolPtr(true), Issues: boolPtr(true)},
+		DefaultLabels: []RepoLabel{{Name: "bug", Color: "ff0000"}},
+		DefaultTopics: []string{"managed"},
+		Profiles: map[string]*Profile{
+			"critical": {
+				BranchPermissions: &BranchPermissions{ApproverCount: intPtr(2), EnforceAdmins: boolPtr(true)},
+				TeamPermissions: []*Permissions{
+					{Team: stringPtr("developers"), Level: stringPtr("pull")},
+					{Team: stringPtr("sre"), Level: stringPtr("admin")},
+				},
+				Defaults: &RepositoryDefaults{Wiki: boolPtr(false)},
+				Labels:   []RepoLabel{{Name: "BUG", Color: "00ff00"}, {Name: "incident"}},
+				Topics:   []string{"critical", "managed"},
+			},
+		},
+	}
+
+	if got := settings.EffectiveSettings(&Repository{Name: stringPtr("plain")}); got != settings {
+		t.Error("repositories without a profile must use the global settings")
+	}
+
+	got := settings.EffectiveSettings(&Repository{Name: stringPtr("payments"), Profile: stringPtr("critical")})
+	if *got.ApproverCount != 2 || !*got.EnforceAdmins || !*got.AllowMergeCommit || *got.RequireCodeOwners {
+		t.Errorf("unexpected branch permissions: %+v", got.BranchPermissions)
+	}
+	if len(got.TeamPermissions) != 2 || *got.TeamPermissions[0].Level != "pull" || *got.TeamPermissions[1].Team != "sre" {
+		t.Errorf("unexpected team permissions: %+v", got.TeamPermissions)
+	}
+	if *got.Defaults.Wiki || !*got.Defaults.Issues {
+		t.Errorf("unexpected defaults: %+v", got.Defaults)
+	}
+	if len(got.DefaultLabels) != 2 || got.DefaultLabels[0].Color != "00ff00" {
+		t.Errorf("unexpected labels: %+v", got.DefaultLabels)
+	}
+	if !reflect.DeepEqual(got.DefaultTopics, []string{"managed", "critical"}) {
+		t.Errorf("unexpected topics: %v", got.DefaultTopics)
+	}
+
+	// Global settings must not be modified by resolution.
+	if *settings.ApproverCount != 1 || len(settings.TeamPermissions) != 1 || !*settings.Defaults.Wiki {
+		t.Error("global settings were modified")
+	}
+}
+
+func TestValidateProfiles(t *testing.T) {
+	base := func() *PermissionsSettings {
+		return &PermissionsSettings{
+			Organization: stringPtr("org"),
+			Repositories: []*Repository{{Name: stringPtr("repo"), Profile: stringPtr("standard")}},
+			Profiles: map[string]*Profile{
+				"standard": {BranchPermissions: &BranchPermissions{ApproverCount: intPtr(1)}},
+			},
+		}
+	}
+
+	if err := ValidatePermissionsSettings(base()); err != nil {
+		t.Fatalf("ValidatePermissionsSettings() error = %v", err)
+	}
+
+	unknown := base()
+	unknown.Repositories[0].Profile = stringPtr("missing")
+	if err := ValidatePermissionsSettings(unknown); err == nil {
+		t.Error("expected error for undefined repository profile")
+	}
+
+	badSelector := base()
+	badSelector.Selectors = []*RepositorySelector{{Name: "svc-*", Profile: "missing"}}
+	if err := ValidatePermissionsSettings(badSelector); err == nil {
+		t.Error("expected error for undefined selector profile")
+	}
+
+	badBranches := base()
+	badBranches.Profiles["standard"].BranchPermissions.ApproverCount = intPtr(-1)
+	if err := ValidatePermissionsSettings(badBranches); err == nil {
+		t.Error("expected error for invalid profile branch permissions")
+	}
+
+	badTeam := base()
+	badTeam.Profiles["standard"].TeamPermissions = []*Permissions{{Team: stringPtr("sre")}}
+	if err := ValidatePermissionsSettings(badTeam); err == nil {
+		t.Error("expected error for team permission without level")
+	}
+}
@@ -0,0 +1,94 @@
+package ownershit
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// Remediation describes how to give the token what a failed operation needs. It is attached to
+// PermissionDeniedError and RepositoryNotFoundError, as GitHub also answers 404 for private
+// repositories the token cannot see.
+type Remediation struct {
+	// Operation is the failed operation, as listed by GetRequiredTokenPermissions.
+	Operation         string   `json:"operation,omitempty"`
+	Scopes            []string `json:"scopes,omitempty"`
+	FineGrained       []string `json:"fine_grained,omitempty"`
+	RepositoryAccess  string   `json:"repository_access,omitempty"`
+	OrganizationAdmin bool     `json:"organization_admin,omitempty"`
+	// SSOURL is where to authorize the token for the organization's SAML single sign-on. It is
+	// set when GitHub reports the authorization missing.
+	SSOURL string `json:"sso_url,omitempty"`
+}
+
+// Required summarizes the classic scopes and fine-grained permissions the operation needs.
+func (r *Remediation) Required() string {
+	if r == nil || r.Operation == "" {
+		return ""
+	}
+	scopes := "the " + strings.Join(r.Scopes, ", ") + " scope"
+	if len(r.Scopes) > 1 {
+		scopes += "s"
+	}
+	return fmt.Sprintf("%s, or fine-grained %s", scopes, strings.Join(r.FineGrained, ", "))
+}
+
+// String returns the remediation as one line, SSO authorization first as nothing else helps
+// until it is done.
+func (r *Remediation) String() string {
+	if r == nil {
+		return ""
+	}
+	var hints []string
+	if r.SSOURL != "" {
+		hints = append(hints, "authorize the token for the organization's SAML single sign-on at "+r.SSOURL)
+	}
+	if r.Operation != "" {
+		hint := fmt.Sprintf("%q needs %s", r.Operation, r.Required())
+		if r.RepositoryAccess != "" {
+			hint += fmt.Sprintf(", with %s access to the repository", r.RepositoryAccess)
+		}
+		if r.OrganizationAdmin {
+			hint += ", as an organization owner"
+		}
+		hints = append(hints, hint)
+	}
+	return strings.Join(hints, "; ")
+}
+
+// remediationFor returns the remediation for operation, an operation name carried by API errors,
+// given the failed response's headers. It is nil when there is nothing to suggest.
+func remediationFor(operation string, header http.Header) *Remediation {
+	r := &Remediation{SSOURL: ssoAuthorizationURL(header)}
+	for _, req := range tokenRequirements {
+		if slices.Contains(req.apiOperations, operation) {
+			r.Operation = req.Operation
+			r.Scopes = req.Scopes
+			r.FineGrained = req.FineGrained
+			r.RepositoryAccess = req.RepositoryAccess
+			r.OrganizationAdmin = req.OrganizationAdmin
+			break
+		}
+	}
+	if r.Operation == "" && r.SSOURL == "" {
+		return nil
+	}
+	return r
+}
+
+// ssoAuthorizationURL returns the authorization URL in an X-GitHub-SSO header of the form
+// "required; url=<url>", sent when the token is not authorized for the organization's SAML
+// single sign-on.
+func ssoAuthorizationURL(header http.Header) string {
+	parts := strings.Split(header.Get("X-GitHub-SSO"), ";")
+	if strings.TrimSpace(parts[0]) != "required" {
+		return ""
+	}
+	for _, part := range parts[1:] {
+		if url, ok := strings.CutPrefix(strings.TrimSpace(part), "url="); ok {
+			return url
+		}
+	}
+	return ""
+}
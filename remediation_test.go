@@ -0,0 +1,81 @@
+package ownershit
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestSSOAuthorizationURL(t *testing.T) {
+	tests := map[string]string{
+		"required; url=https://github.com/orgs/acme/sso?authorization_request=abc": "https://github.com/orgs/acme/sso?authorization_request=abc",
+		"partial-results; organizations=21955855,20582480":                         "",
+		"": "",
+	}
+	for value, want := range tests {
+		header := http.Header{}
+		header.Set("X-GitHub-SSO", value)
+		if got := ssoAuthorizationURL(header); got != want {
+			t.Errorf("ssoAuthorizationURL(%q) = %q, want %q", value, got, want)
+		}
+	}
+}
+
+func TestRemediationFor(t *testing.T) {
+	r := remediationFor("create label", nil)
+	if r == nil || r.Operation != "Sync labels" || r.RepositoryAccess != AccessWrite {
+		t.Fatalf("remediationFor(create label) = %+v", r)
+	}
+	if got := r.String(); got != `"Sync labels" needs the repo scope, or fine-grained Repository Issues: Write, with write access to the repository` {
+		t.Errorf("String() = %q", got)
+	}
+	if got := remediationFor("create team", nil).String(); !strings.Contains(got, "the admin:org scope") ||
+		!strings.HasSuffix(got, "as an organization owner") {
+		t.Errorf("create team remediation = %q", got)
+	}
+	if r := remediationFor("unknown", nil); r != nil {
+		t.Errorf("remediationFor(unknown) = %+v, want nil", r)
+	}
+
+	header := http.Header{}
+	header.Set("X-GitHub-SSO", "required; url=https://github.com/orgs/acme/sso")
+	if r := remediationFor("unknown", header); r == nil || r.String() !=
+		"authorize the token for the organization's SAML single sign-on at https://github.com/orgs/acme/sso" {
+		t.Errorf("SSO remediation = %+v", r)
+	}
+}
+
+func TestAPIOperationsAreUnique(t *testing.T) {
+	seen := map[string]string{}
+	for _, req := range tokenRequirements {
+		for _, op := range req.apiOperations {
+			if other, ok := seen[op]; ok {
+				t.Errorf("%q belongs to both %q and %q", op, other, req.Operation)
+			}
+			seen[op] = req.Operation
+		}
+	}
+}
+
+func TestClassifiedErrorsCarryRemediation(t *testing.T) {
+	forbidden := errorResponse(http.StatusForbidden, "/repos/acme/api/topics", "Resource protected by organization SAML enforcement.")
+	forbidden.Response.Header.Set("X-GitHub-SSO", "required; url=https://github.com/orgs/acme/sso")
+	err := classifyAPIError(0, "replace topics", "acme/api", "failed to update repository topics", forbidden)
+	var denied *PermissionDeniedError
+	if !errors.As(err, &denied) || denied.Remediation == nil || denied.Remediation.SSOURL == "" ||
+		denied.Remediation.Operation != "Sync topics" {
+		t.Fatalf("classifyAPIError() = %v, want a remediation", err)
+	}
+	if msg := err.Error(); !strings.Contains(msg, "to fix: authorize the token") ||
+		!strings.Contains(msg, `"Sync topics" needs the repo scope`) {
+		t.Errorf("Error() = %q", msg)
+	}
+
+	err = classifyAPIError(0, "get repository", "acme/api", "failed", errorResponse(http.StatusNotFound, "/repos/acme/api", "Not Found"))
+	var notFound *RepositoryNotFoundError
+	if !errors.As(err, &notFound) || !strings.HasPrefix(err.Error(),
+		"repository acme/api not found or not visible to the token; to fix: \"Import repository config\" needs") {
+		t.Errorf("classifyAPIError() = %v", err)
+	}
+}
@@ -0,0 +1,333 @@
+package ownershit
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+
+	"github.com/rs/zerolog/log"
+	"github.com/shurcooL/githubv4"
+)
+
+const (
+	// DefaultStateBatchSize is how many repositories the first batched state query reads.
+	DefaultStateBatchSize = 25
+	// MaxStateBatchSize bounds how many repositories one batched state query reads.
+	MaxStateBatchSize = 100
+	// stateBatchCostTarget is the GraphQL rate limit cost later batches are sized to.
+	stateBatchCostTarget = 10
+)
+
+// RepositoryState is the current state of a repository as read by GetRepositoryStates.
+type RepositoryState struct {
+	ID            githubv4.ID
+	Owner         string
+	Name          string
+	DefaultBranch string
+
+	HasWikiEnabled     bool
+	HasIssuesEnabled   bool
+	HasProjectsEnabled bool
+
+	MergeCommitAllowed  bool
+	SquashMergeAllowed  bool
+	RebaseMergeAllowed  bool
+	DeleteBranchOnMerge bool
+
+	BranchProtectionRules []BranchProtectionRuleState
+	// Truncated reports that a repository has more branch protection rules than a batched query
+	// reads.
+	Truncated bool
+}
+
+// RepositorySettingChange is a single repository setting whose current value differs from the configuration.
+type RepositorySettingChange struct {
+	Setting string
+	Current interface{}
+	Desired interface{}
+}
+
+// BranchProtectionRuleState is a branch protection rule as read by GetRepositoryStates.
+type BranchProtectionRuleState struct {
+	ID                           githubv4.ID
+	Pattern                      string
+	RequiresApprovingReviews     bool
+	RequiredApprovingReviewCount int
+	RequiresCodeOwnerReviews     bool
+	RequiresStatusChecks         bool
+	RequiresStrictStatusChecks   bool
+	RequiredStatusCheckContexts  []string
+	RequiresLinearHistory        bool
+	AllowsForcePushes            bool
+	AllowsDeletions              bool
+}
+
+// repositoryStateNode selects the fields of one repository in a batched state query.
+type repositoryStateNode struct {
+	ID               githubv4.ID
+	DefaultBranchRef *struct {
+		Name githubv4.String
+	}
+	HasWikiEnabled      githubv4.Boolean
+	HasIssuesEnabled    githubv4.Boolean
+	HasProjectsEnabled  githubv4.Boolean
+	MergeCommitAllowed  githubv4.Boolean
+	SquashMergeAllowed  githubv4.Boolean
+	RebaseMergeAllowed  githubv4.Boolean
+	DeleteBranchOnMerge githubv4.Boolean
+
+	BranchProtectionRules struct {
+		TotalCount githubv4.Int
+		Nodes      []struct {
+			ID                           githubv4.ID
+			Pattern                      githubv4.String
+			RequiresApprovingReviews     githubv4.Boolean
+			RequiredApprovingReviewCount githubv4.Int
+			RequiresCodeOwnerReviews     githubv4.Boolean
+			RequiresStatusChecks         githubv4.Boolean
+			RequiresStrictStatusChecks   githubv4.Boolean
+			RequiredStatusCheckContexts  []githubv4.String
+			RequiresLinearHistory        githubv4.Boolean
+			AllowsForcePushes            githubv4.Boolean
+			AllowsDeletions              githubv4.Boolean
+		}
+	} `graphql:"branchProtectionRules(first: 50)"`
+}
+
+// repositoryIDNode selects only the ID of a repository in a batched query.
+type repositoryIDNode struct {
+	ID githubv4.ID
+}
+
+var (
+	repositoryStateNodeType = reflect.TypeOf(&repositoryStateNode{})
+	repositoryIDNodeType    = reflect.TypeOf(&repositoryIDNode{})
+	rateLimitFieldsType     = reflect.TypeOf(&rateLimitFields{})
+)
+
+// repositoryBatchQueryType returns a query type reading n repositories as nodeType through the
+// aliases repo0 to repoN-1, and the rate limit status with the query's cost.
+func repositoryBatchQueryType(n int, nodeType reflect.Type) reflect.Type {
+	fields := make([]reflect.StructField, 0, n+1)
+	for i := 0; i < n; i++ {
+		fields = append(fields, reflect.StructField{
+			Name: fmt.Sprintf("Repo%d", i),
+			Type: nodeType,
+			Tag:  reflect.StructTag(fmt.Sprintf(`graphql:"repo%d: repository(owner: $owner, name: $name%d)"`, i, i)),
+		})
+	}
+	fields = append(fields, reflect.StructField{Name: "RateLimit", Type: rateLimitFieldsType})
+	return reflect.StructOf(fields)
+}
+
+// GetRepositoryStates reads the state of the named repositories in owner with batched GraphQL
+// queries, keyed by the names given. Repositories that cannot be read, for example because they do
+// not exist, are left out of the result. The first batch reads DefaultStateBatchSize repositories;
+// later batches are sized from the cost GitHub reports for the previous one, and shrunk to what the
+// remaining rate limit allows. When the rate limit cannot cover another repository, the states
+// read so far are returned with a RateLimitError.
+func (c *GitHubClient) GetRepositoryStates(owner string, names []string) (map[string]*RepositoryState, error) {
+	states := make(map[string]*RepositoryState, len(names))
+	err := c.queryRepositoryBatches(owner, names, repositoryStateNodeType, func(name string, node interface{}) {
+		states[name] = node.(*repositoryStateNode).state(owner, name)
+	})
+	return states, err
+}
+
+// GetRepositoryIDs reads the node IDs of the named repositories in owner like GetRepositoryStates,
+// selecting nothing else, which keeps each batch cheap when only the IDs are needed.
+func (c *GitHubClient) GetRepositoryIDs(owner string, names []string) (map[string]githubv4.ID, error) {
+	ids := make(map[string]githubv4.ID, len(names))
+	err := c.queryRepositoryBatches(owner, names, repositoryIDNodeType, func(name string, node interface{}) {
+		ids[name] = node.(*repositoryIDNode).ID
+	})
+	return ids, err
+}
+
+// queryRepositoryBatches reads the named repositories as nodeType in batches sized by
+// nextStateBatchSize, calling read for every repository found.
+func (c *GitHubClient) queryRepositoryBatches(owner string, names []string, nodeType reflect.Type, read func(string, interface{})) error {
+	size := DefaultStateBatchSize
+	for start := 0; start < len(names); {
+		end := min(start+size, len(names))
+		rate, err := c.queryRepositoryBatch(owner, names[start:end], nodeType, read)
+		if err != nil {
+			return err
+		}
+		size = nextStateBatchSize(end-start, rate)
+		start = end
+		if size == 0 && start < len(names) {
+			return NewRateLimitError(rate.ResetAt, rate.Remaining,
+				fmt.Sprintf("rate limit exhausted after reading %d of %d repositories", start, len(names)), nil)
+		}
+	}
+	return nil
+}
+
+// queryRepositoryBatch reads one batch of repositories. An error is returned only when no
+// repository in the batch could be read.
+func (c *GitHubClient) queryRepositoryBatch(
+	owner string, names []string, nodeType reflect.Type, read func(string, interface{}),
+) (RateLimitResult, error) {
+	variables := map[string]interface{}{"owner": githubv4.String(owner)}
+	for i, name := range names {
+		variables[fmt.Sprintf("name%d", i)] = githubv4.String(name)
+	}
+	query := reflect.New(repositoryBatchQueryType(len(names), nodeType))
+	err := c.Graph.Query(c.Context, query.Interface(), variables)
+
+	found := 0
+	for i, name := range names {
+		node := query.Elem().Field(i)
+		if node.IsNil() {
+			log.Debug().
+				Str("repository", fmt.Sprintf("%s/%s", owner, name)).
+				Msg("repository not read")
+			continue
+		}
+		read(name, node.Interface())
+		found++
+	}
+	if err != nil {
+		if found == 0 {
+			return RateLimitResult{}, classifyAPIError(0, "get repository states", owner,
+				fmt.Sprintf("failed to read %d repositories", len(names)), err)
+		}
+		log.Debug().Err(err).
+			Str("owner", owner).
+			Int("read", found).
+			Int("requested", len(names)).
+			Msg("batched repository query partially failed")
+	}
+	var rate RateLimitResult
+	// The rate limit is null when GitHub Enterprise Server has rate limiting disabled.
+	if fields, _ := query.Elem().FieldByName("RateLimit").Interface().(*rateLimitFields); fields != nil {
+		rate = fields.result("")
+	}
+	log.Debug().
+		Str("owner", owner).
+		Int("repositories", len(names)).
+		Int("cost", rate.Cost).
+		Int("remaining", rate.Remaining).
+		Msg("read repository batch")
+	return rate, nil
+}
+
+// nextStateBatchSize returns how many repositories the next batch should read, given the rate
+// limit status after reading batchSize repositories. Zero means the remaining rate limit cannot
+// cover another repository.
+func nextStateBatchSize(batchSize int, rate RateLimitResult) int {
+	if rate.Cost <= 0 || batchSize <= 0 {
+		// Without cost data the default size is kept.
+		return DefaultStateBatchSize
+	}
+	perRepository := float64(rate.Cost) / float64(batchSize)
+	size := max(min(int(stateBatchCostTarget/perRepository), MaxStateBatchSize), 1)
+	if affordable := int(float64(rate.Remaining) / perRepository); affordable < size {
+		return max(affordable, 0)
+	}
+	return size
+}
+
+// state converts the query result into a RepositoryState.
+func (n *repositoryStateNode) state(owner, name string) *RepositoryState {
+	state := &RepositoryState{
+		ID:                  n.ID,
+		Owner:               owner,
+		Name:                name,
+		HasWikiEnabled:      bool(n.HasWikiEnabled),
+		HasIssuesEnabled:    bool(n.HasIssuesEnabled),
+		HasProjectsEnabled:  bool(n.HasProjectsEnabled),
+		MergeCommitAllowed:  bool(n.MergeCommitAllowed),
+		SquashMergeAllowed:  bool(n.SquashMergeAllowed),
+		RebaseMergeAllowed:  bool(n.RebaseMergeAllowed),
+		DeleteBranchOnMerge: bool(n.DeleteBranchOnMerge),
+		Truncated:           int(n.BranchProtectionRules.TotalCount) > len(n.BranchProtectionRules.Nodes),
+	}
+	if n.DefaultBranchRef != nil {
+		state.DefaultBranch = string(n.DefaultBranchRef.Name)
+	}
+	for _, rule := range n.BranchProtectionRules.Nodes {
+		contexts := make([]string, 0, len(rule.RequiredStatusCheckContexts))
+		for _, check := range rule.RequiredStatusCheckContexts {
+			contexts = append(contexts, string(check))
+		}
+		state.BranchProtectionRules = append(state.BranchProtectionRules, BranchProtectionRuleState{
+			ID:                           rule.ID,
+			Pattern:                      string(rule.Pattern),
+			RequiresApprovingReviews:     bool(rule.RequiresApprovingReviews),
+			RequiredApprovingReviewCount: int(rule.RequiredApprovingReviewCount),
+			RequiresCodeOwnerReviews:     bool(rule.RequiresCodeOwnerReviews),
+			RequiresStatusChecks:         bool(rule.RequiresStatusChecks),
+			RequiresStrictStatusChecks:   bool(rule.RequiresStrictStatusChecks),
+			RequiredStatusCheckContexts:  contexts,
+			RequiresLinearHistory:        bool(rule.RequiresLinearHistory),
+			AllowsForcePushes:            bool(rule.AllowsForcePushes),
+			AllowsDeletions:              bool(rule.AllowsDeletions),
+		})
+	}
+	return state
+}
+
+// DiffRepositoryState returns the settings sync manages whose configured values differ from state:
+// repository features, merge settings and the branch protection rule for the protected branch.
+// settings are the repository's effective settings.
+func DiffRepositoryState(settings *PermissionsSettings, repo *Repository, state *RepositoryState) []RepositorySettingChange {
+	var changes []RepositorySettingChange
+	compare := func(setting string, desired *bool, current bool) {
+		if desired != nil && *desired != current {
+			changes = append(changes, RepositorySettingChange{Setting: setting, Current: current, Desired: *desired})
+		}
+	}
+	wiki, issues, projects := repositoryFeatures(repo, settings)
+	compare("wiki", wiki, state.HasWikiEnabled)
+	compare("issues", issues, state.HasIssuesEnabled)
+	compare("projects", projects, state.HasProjectsEnabled)
+	compare("delete_branch_on_merge", repositoryDeleteBranchOnMerge(settings, repo), state.DeleteBranchOnMerge)
+	perms := &settings.BranchPermissions
+	compare("allow_merge_commit", perms.AllowMergeCommit, state.MergeCommitAllowed)
+	compare("allow_squash_merge", perms.AllowSquashMerge, state.SquashMergeAllowed)
+	compare("allow_rebase_merge", perms.AllowRebaseMerge, state.RebaseMergeAllowed)
+
+	if !hasMeaningfulBranchProtection(perms) {
+		return changes
+	}
+	branch := getDefaultBranch(repo)
+	index := slices.IndexFunc(state.BranchProtectionRules, func(rule BranchProtectionRuleState) bool {
+		return rule.Pattern == branch
+	})
+	if index < 0 {
+		// The rule may be among those a truncated read left out.
+		if !state.Truncated {
+			changes = append(changes, RepositorySettingChange{Setting: "branch_protection", Current: nil, Desired: branch})
+		}
+		return changes
+	}
+	rule := state.BranchProtectionRules[index]
+	compare("branches.require_pull_request_reviews", perms.RequirePullRequestReviews, rule.RequiresApprovingReviews)
+	if perms.ApproverCount != nil && *perms.ApproverCount != rule.RequiredApprovingReviewCount {
+		changes = append(changes, RepositorySettingChange{
+			Setting: "branches.require_approving_count", Current: rule.RequiredApprovingReviewCount, Desired: *perms.ApproverCount,
+		})
+	}
+	compare("branches.require_code_owners", perms.RequireCodeOwners, rule.RequiresCodeOwnerReviews)
+	compare("branches.require_status_checks", perms.RequireStatusChecks, rule.RequiresStatusChecks)
+	compare("branches.require_up_to_date_branch", perms.RequireUpToDateBranch, rule.RequiresStrictStatusChecks)
+	if len(perms.StatusChecks) > 0 && !sameStringSet(perms.StatusChecks, rule.RequiredStatusCheckContexts) {
+		changes = append(changes, RepositorySettingChange{
+			Setting: "branches.status_checks", Current: rule.RequiredStatusCheckContexts, Desired: perms.StatusChecks,
+		})
+	}
+	compare("branches.require_linear_history", perms.RequireLinearHistory, rule.RequiresLinearHistory)
+	compare("branches.allow_force_pushes", perms.AllowForcePushes, rule.AllowsForcePushes)
+	compare("branches.allow_deletions", perms.AllowDeletions, rule.AllowsDeletions)
+	return changes
+}
+
+// sameStringSet reports whether a and b hold the same strings, in any order.
+func sameStringSet(a, b []string) bool {
+	a, b = slices.Clone(a), slices.Clone(b)
+	slices.Sort(a)
+	slices.Sort(b)
+	return slices.Equal(a, b)
+}
@@ -0,0 +1,152 @@
+package ownershit
+
+//go:generate go run ./cmd/schemagen -output config.schema.json
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ConfigSchemaID is the $id of the configuration JSON Schema.
+const ConfigSchemaID = "https://github.com/klauern/ownershit/config.schema.json"
+
+// configSchemaJSON is the generated schema shipped with the binary; regenerate it with go generate.
+//
+//go:embed config.schema.json
+var configSchemaJSON []byte
+
+// ConfigSchema returns the JSON Schema for repositories.yaml embedded in the binary.
+func ConfigSchema() []byte {
+	return configSchemaJSON
+}
+
+// JSONSchema is the subset of JSON Schema (draft 2020-12) used to describe the configuration.
+type JSONSchema struct {
+	Schema      string                 `json:"$schema,omitempty"`
+	ID          string                 `json:"$id,omitempty"`
+	Ref         string                 `json:"$ref,omitempty"`
+	Title       string                 `json:"title,omitempty"`
+	Description string                 `json:"description,omitempty"`
+	Type        string                 `json:"type,omitempty"`
+	Enum        []string               `json:"enum,omitempty"`
+	Properties  map[string]*JSONSchema `json:"properties,omitempty"`
+	Items       *JSONSchema            `json:"items,omitempty"`
+	AnyOf       []*JSONSchema          `json:"anyOf,omitempty"`
+	Defs        map[string]*JSONSchema `json:"$defs,omitempty"`
+	// AdditionalProperties is false for structs and the value schema for maps.
+	AdditionalProperties interface{} `json:"additionalProperties,omitempty"`
+}
+
+// schemaEnums lists the allowed values of string fields, keyed by Go type and YAML field name.
+var schemaEnums = map[string][]string{
+	"TeamDefinition.privacy": {TeamPrivacyClosed, TeamPrivacySecret},
+	"CustomPropertyDefinition.value_type": {
+		PropertyTypeString, PropertyTypeSingleSelect, PropertyTypeMultiSelect, PropertyTypeTrueFalse,
+	},
+	"OrganizationSettings.base_repository_permission": validBasePermissions,
+	"RepositorySelector.visibility":                   validVisibilities,
+	"FileSync.mode":                                   {string(FileSyncCreateOnly), string(FileSyncOverwrite), string(FileSyncPullRequest)},
+}
+
+// GenerateConfigSchema builds the JSON Schema for PermissionsSettings and its nested types.
+func GenerateConfigSchema() ([]byte, error) {
+	g := &schemaGenerator{defs: make(map[string]*JSONSchema)}
+	root := g.structSchema(reflect.TypeOf(PermissionsSettings{}))
+	root.Schema = "https://json-schema.org/draft/2020-12/schema"
+	root.ID = ConfigSchemaID
+	root.Title = "ownershit configuration"
+	root.Defs = g.defs
+	data, err := json.MarshalIndent(root, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("marshaling configuration schema: %w", err)
+	}
+	return append(data, '\n'), nil
+}
+
+type schemaGenerator struct {
+	defs map[string]*JSONSchema
+}
+
+// typeSchema returns the schema for t; named struct types are placed in $defs and referenced.
+func (g *schemaGenerator) typeSchema(t reflect.Type) *JSONSchema {
+	if t == reflect.TypeOf(PropertyValue{}) {
+		return &JSONSchema{AnyOf: []*JSONSchema{
+			{Type: "string"}, {Type: "boolean"}, {Type: "number"},
+			{Type: "array", Items: &JSONSchema{Type: "string"}},
+		}}
+	}
+	switch t.Kind() {
+	case reflect.Ptr:
+		return g.typeSchema(t.Elem())
+	case reflect.Bool:
+		return &JSONSchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &JSONSchema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &JSONSchema{Type: "number"}
+	case reflect.String:
+		return &JSONSchema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		return &JSONSchema{Type: "array", Items: g.typeSchema(t.Elem())}
+	case reflect.Map:
+		return &JSONSchema{Type: "object", AdditionalProperties: g.typeSchema(t.Elem())}
+	case reflect.Struct:
+		if _, ok := g.defs[t.Name()]; !ok {
+			g.defs[t.Name()] = nil // reserve the name so recursive types terminate
+			g.defs[t.Name()] = g.structSchema(t)
+		}
+		return &JSONSchema{Ref: "#/$defs/" + t.Name()}
+	default:
+		return &JSONSchema{}
+	}
+}
+
+// structSchema describes the YAML fields of a struct, rejecting unknown keys like the strict decoder.
+func (g *schemaGenerator) structSchema(t reflect.Type) *JSONSchema {
+	schema := &JSONSchema{Type: "object", Properties: make(map[string]*JSONSchema), AdditionalProperties: false}
+	for _, field := range yamlFields(t) {
+		property := g.typeSchema(field.Type)
+		if enum := schemaEnums[field.Owner.Name()+"."+field.Name]; enum != nil {
+			property.Enum = append([]string(nil), enum...)
+		}
+		schema.Properties[field.Name] = property
+	}
+	return schema
+}
+
+// yamlField is a struct field as the YAML decoder sees it.
+type yamlField struct {
+	Name  string
+	Type  reflect.Type
+	Owner reflect.Type
+}
+
+// yamlFields lists the YAML fields of a struct type in declaration order, flattening inline structs.
+// Untagged fields use the lowercased field name, as yaml.v3 does.
+func yamlFields(t reflect.Type) []yamlField {
+	var fields []yamlField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		tag := strings.Split(field.Tag.Get("yaml"), ",")
+		name := tag[0]
+		if name == "-" {
+			continue
+		}
+		if containsString(tag[1:], "inline") {
+			fields = append(fields, yamlFields(field.Type)...)
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		fields = append(fields, yamlField{Name: name, Type: field.Type, Owner: t})
+	}
+	return fields
+}
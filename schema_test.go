@@ -0,0 +1,42 @@
+package ownershit
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestEmbeddedConfigSchemaIsCurrent(t *testing.T) {
+	generated, err := GenerateConfigSchema()
+	if err != nil {
+		t.Fatalf("GenerateConfigSchema() error = %v", err)
+	}
+	if !bytes.Equal(generated, ConfigSchema()) {
+		t.Fatal("config.schema.json is out of date; run go generate")
+	}
+}
+
+func TestGenerateConfigSchema(t *testing.T) {
+	schema := &JSONSchema{}
+	if err := json.Unmarshal(ConfigSchema(), schema); err != nil {
+		t.Fatalf("schema is not valid JSON: %v", err)
+	}
+	if schema.AdditionalProperties != false {
+		t.Error("top-level schema should reject unknown fields")
+	}
+	if got := schema.Properties["branches"].Ref; got != "#/$defs/BranchPermissions" {
+		t.Errorf("branches ref = %q", got)
+	}
+	labels := schema.Defs["RepoLabel"]
+	if labels == nil || labels.Properties["color"] == nil {
+		t.Error("untagged RepoLabel fields should use lowercased names")
+	}
+	if visibility := schema.Defs["RepositorySelector"].Properties["visibility"]; len(visibility.Enum) != 3 {
+		t.Errorf("visibility enum = %v", visibility.Enum)
+	}
+	properties := schema.Defs["Repository"].Properties["properties"]
+	additional, ok := properties.AdditionalProperties.(*JSONSchema)
+	if !ok || len(additional.AnyOf) == 0 {
+		t.Errorf("repository properties should accept scalars or lists, got %+v", properties.AdditionalProperties)
+	}
+}
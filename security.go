@@ -0,0 +1,291 @@
+package ownershit
+
+import (
+	"fmt"
+
+	"github.com/google/go-github/v66/github"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	securityStatusEnabled  = "enabled"
+	securityStatusDisabled = "disabled"
+)
+
+// SecuritySettings defines repository security feature toggles. A nil field leaves
+// the current state on GitHub untouched.
+type SecuritySettings struct {
+	VulnerabilityAlerts           *bool `yaml:"vulnerability_alerts,omitempty"`
+	DependabotSecurityUpdates     *bool `yaml:"dependabot_security_updates,omitempty"`
+	SecretScanning                *bool `yaml:"secret_scanning,omitempty"`
+	SecretScanningPushProtection  *bool `yaml:"secret_scanning_push_protection,omitempty"`
+	PrivateVulnerabilityReporting *bool `yaml:"private_vulnerability_reporting,omitempty"`
+}
+
+// IsEmpty reports whether no security toggle is configured.
+func (s *SecuritySettings) IsEmpty() bool {
+	return s == nil ||
+		(s.VulnerabilityAlerts == nil &&
+			s.DependabotSecurityUpdates == nil &&
+			s.SecretScanning == nil &&
+			s.SecretScanningPushProtection == nil &&
+			s.PrivateVulnerabilityReporting == nil)
+}
+
+// ResolveSecuritySettings layers repository-level security toggles over the global defaults.
+// It returns nil when neither level configures anything.
+func ResolveSecuritySettings(global, repo *SecuritySettings) *SecuritySettings {
+	if global.IsEmpty() && repo.IsEmpty() {
+		return nil
+	}
+	if global == nil {
+		global = &SecuritySettings{}
+	}
+	if repo == nil {
+		repo = &SecuritySettings{}
+	}
+	return &SecuritySettings{
+		VulnerabilityAlerts:           coalesceBoolPtr(repo.VulnerabilityAlerts, global.VulnerabilityAlerts),
+		DependabotSecurityUpdates:     coalesceBoolPtr(repo.DependabotSecurityUpdates, global.DependabotSecurityUpdates),
+		SecretScanning:                coalesceBoolPtr(repo.SecretScanning, global.SecretScanning),
+		SecretScanningPushProtection:  coalesceBoolPtr(repo.SecretScanningPushProtection, global.SecretScanningPushProtection),
+		PrivateVulnerabilityReporting: coalesceBoolPtr(repo.PrivateVulnerabilityReporting, global.PrivateVulnerabilityReporting),
+	}
+}
+
+// ValidateSecuritySettings checks that the security toggles are logically consistent.
+func ValidateSecuritySettings(field string, s *SecuritySettings) error {
+	if s == nil {
+		return nil
+	}
+	if s.SecretScanningPushProtection != nil && *s.SecretScanningPushProtection &&
+		s.SecretScanning != nil && !*s.SecretScanning {
+		return NewConfigValidationError(field+".secret_scanning_push_protection", *s.SecretScanningPushProtection,
+			"push protection requires secret_scanning to be enabled", nil)
+	}
+	if s.DependabotSecurityUpdates != nil && *s.DependabotSecurityUpdates &&
+		s.VulnerabilityAlerts != nil && !*s.VulnerabilityAlerts {
+		return NewConfigValidationError(field+".dependabot_security_updates", *s.DependabotSecurityUpdates,
+			"Dependabot security updates require vulnerability_alerts to be enabled", nil)
+	}
+	return nil
+}
+
+func applySecuritySettings(settings *PermissionsSettings, repo *Repository, client *GitHubClient, dryRun bool) error {
+	security := ResolveSecuritySettings(settings.Security, repo.Security)
+	if security == nil {
+		return nil
+	}
+
+	if dryRun {
+		logEvent := log.Info().Str("repository", *repo.Name)
+		if security.VulnerabilityAlerts != nil {
+			logEvent = logEvent.Bool("vulnerability_alerts", *security.VulnerabilityAlerts)
+		}
+		if security.DependabotSecurityUpdates != nil {
+			logEvent = logEvent.Bool("dependabot_security_updates", *security.DependabotSecurityUpdates)
+		}
+		if security.SecretScanning != nil {
+			logEvent = logEvent.Bool("secret_scanning", *security.SecretScanning)
+		}
+		if security.SecretScanningPushProtection != nil {
+			logEvent = logEvent.Bool("secret_scanning_push_protection", *security.SecretScanningPushProtection)
+		}
+		if security.PrivateVulnerabilityReporting != nil {
+			logEvent = logEvent.Bool("private_vulnerability_reporting", *security.PrivateVulnerabilityReporting)
+		}
+		logEvent.Msg("Would update security features")
+		return nil
+	}
+
+	if err := client.SetSecuritySettings(*settings.Organization, *repo.Name, security); err != nil {
+		log.Err(err).
+			Str("repository", *repo.Name).
+			Str("organization", *settings.Organization).
+			Msg("setting security features")
+		return err
+	}
+	return nil
+}
+
+// SetSecuritySettings enables or disables the configured security features for a repository.
+// Vulnerability alerts are enabled before Dependabot security updates, and disabled after them,
+// since GitHub rejects the dependent feature while its prerequisite is off. Secret scanning is
+// applied in between, through the repository's security_and_analysis settings.
+func (c *GitHubClient) SetSecuritySettings(org, repo string, s *SecuritySettings) error {
+	if s == nil {
+		return nil
+	}
+	repoPath := fmt.Sprintf("%s/%s", org, repo)
+	s = c.supportedSecuritySettings(s, repoPath)
+
+	if s.DependabotSecurityUpdates != nil && !*s.DependabotSecurityUpdates {
+		if err := c.setDependabotSecurityUpdates(org, repo, false); err != nil {
+			return err
+		}
+	}
+	if s.VulnerabilityAlerts != nil && *s.VulnerabilityAlerts {
+		if err := c.setVulnerabilityAlerts(org, repo, true); err != nil {
+			return err
+		}
+	}
+
+	if sa := buildSecurityAndAnalysis(s); sa != nil {
+		_, resp, err := c.Repositories.Edit(c.Context, org, repo, &github.Repository{SecurityAndAnalysis: sa})
+		if err != nil {
+			return classifyAPIError(responseStatus(resp), "set security and analysis", repoPath,
+				"failed to update secret scanning settings", err)
+		}
+	}
+
+	if s.DependabotSecurityUpdates != nil && *s.DependabotSecurityUpdates {
+		if err := c.setDependabotSecurityUpdates(org, repo, true); err != nil {
+			return err
+		}
+	}
+	if s.VulnerabilityAlerts != nil && !*s.VulnerabilityAlerts {
+		if err := c.setVulnerabilityAlerts(org, repo, false); err != nil {
+			return err
+		}
+	}
+
+	if s.PrivateVulnerabilityReporting != nil {
+		var resp *github.Response
+		var err error
+		if *s.PrivateVulnerabilityReporting {
+			resp, err = c.Repositories.EnablePrivateReporting(c.Context, org, repo)
+		} else {
+			resp, err = c.Repositories.DisablePrivateReporting(c.Context, org, repo)
+		}
+		if err != nil {
+			return classifyAPIError(responseStatus(resp), "set private vulnerability reporting", repoPath,
+				"failed to update private vulnerability reporting", err)
+		}
+	}
+
+	log.Info().
+		Str("repository", repoPath).
+		Msg("Successfully updated security features")
+	return nil
+}
+
+// setVulnerabilityAlerts enables or disables vulnerability alerts for a repository.
+func (c *GitHubClient) setVulnerabilityAlerts(org, repo string, enabled bool) error {
+	var resp *github.Response
+	var err error
+	if enabled {
+		resp, err = c.Repositories.EnableVulnerabilityAlerts(c.Context, org, repo)
+	} else {
+		resp, err = c.Repositories.DisableVulnerabilityAlerts(c.Context, org, repo)
+	}
+	if err != nil {
+		return classifyAPIError(responseStatus(resp), "set vulnerability alerts", fmt.Sprintf("%s/%s", org, repo),
+			"failed to update vulnerability alerts", err)
+	}
+	return nil
+}
+
+// setDependabotSecurityUpdates enables or disables Dependabot security updates for a repository.
+func (c *GitHubClient) setDependabotSecurityUpdates(org, repo string, enabled bool) error {
+	var resp *github.Response
+	var err error
+	if enabled {
+		resp, err = c.Repositories.EnableAutomatedSecurityFixes(c.Context, org, repo)
+	} else {
+		resp, err = c.Repositories.DisableAutomatedSecurityFixes(c.Context, org, repo)
+	}
+	if err != nil {
+		return classifyAPIError(responseStatus(resp), "set dependabot security updates", fmt.Sprintf("%s/%s", org, repo),
+			"failed to update Dependabot security updates", err)
+	}
+	return nil
+}
+
+// supportedSecuritySettings returns s without the toggles the GitHub Enterprise Server version lacks.
+func (c *GitHubClient) supportedSecuritySettings(s *SecuritySettings, repoPath string) *SecuritySettings {
+	supported := *s
+	if supported.SecretScanningPushProtection != nil &&
+		!c.supportsSetting(FeatureSecretScanningPushProtection, "secret_scanning_push_protection", repoPath) {
+		supported.SecretScanningPushProtection = nil
+	}
+	if supported.PrivateVulnerabilityReporting != nil &&
+		!c.supportsSetting(FeaturePrivateVulnerabilityReporting, "private_vulnerability_reporting", repoPath) {
+		supported.PrivateVulnerabilityReporting = nil
+	}
+	return &supported
+}
+
+// buildSecurityAndAnalysis maps the secret scanning toggles onto the repository edit payload.
+// It returns nil when neither toggle is configured.
+func buildSecurityAndAnalysis(s *SecuritySettings) *github.SecurityAndAnalysis {
+	if s.SecretScanning == nil && s.SecretScanningPushProtection == nil {
+		return nil
+	}
+	sa := &github.SecurityAndAnalysis{}
+	if s.SecretScanning != nil {
+		sa.SecretScanning = &github.SecretScanning{Status: github.String(securityStatus(*s.SecretScanning))}
+	}
+	if s.SecretScanningPushProtection != nil {
+		sa.SecretScanningPushProtection = &github.SecretScanningPushProtection{
+			Status: github.String(securityStatus(*s.SecretScanningPushProtection)),
+		}
+	}
+	return sa
+}
+
+func securityStatus(enabled bool) string {
+	if enabled {
+		return securityStatusEnabled
+	}
+	return securityStatusDisabled
+}
+
+// getSecuritySettings retrieves the current security feature states for a repository.
+// Each feature is fetched independently; features the token cannot read are left nil
+// so that imports still succeed with partial information.
+func getSecuritySettings(client *GitHubClient, owner, repo string, sa *github.SecurityAndAnalysis) *SecuritySettings {
+	log.Debug().
+		Str("owner", owner).
+		Str("repo", repo).
+		Msg("fetching security settings")
+
+	security := &SecuritySettings{}
+
+	if enabled, _, err := client.Repositories.GetVulnerabilityAlerts(client.Context, owner, repo); err != nil {
+		log.Warn().Err(err).Str("repo", repo).Msg("unable to read vulnerability alerts state")
+	} else {
+		security.VulnerabilityAlerts = &enabled
+	}
+
+	if fixes, _, err := client.Repositories.GetAutomatedSecurityFixes(client.Context, owner, repo); err != nil {
+		log.Warn().Err(err).Str("repo", repo).Msg("unable to read Dependabot security updates state")
+	} else if fixes != nil {
+		security.DependabotSecurityUpdates = fixes.Enabled
+	}
+
+	if sa != nil {
+		if sa.SecretScanning != nil && sa.SecretScanning.Status != nil {
+			enabled := *sa.SecretScanning.Status == securityStatusEnabled
+			security.SecretScanning = &enabled
+		}
+		if sa.SecretScanningPushProtection != nil && sa.SecretScanningPushProtection.Status != nil {
+			enabled := *sa.SecretScanningPushProtection.Status == securityStatusEnabled
+			security.SecretScanningPushProtection = &enabled
+		}
+	}
+
+	if enabled, _, err := client.Repositories.IsPrivateReportingEnabled(client.Context, owner, repo); err != nil {
+		log.Warn().Err(err).Str("repo", repo).Msg("unable to read private vulnerability reporting state")
+	} else {
+		security.PrivateVulnerabilityReporting = &enabled
+	}
+
+	if security.IsEmpty() {
+		return nil
+	}
+
+	log.Debug().
+		Interface("security", security).
+		Msg("security settings retrieved")
+	return security
+}
@@ -0,0 +1,150 @@
+package ownershit
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-github/v66/github"
+	"go.uber.org/mock/gomock"
+)
+
+func TestResolveSecuritySettings(t *testing.T) {
+	if got := ResolveSecuritySettings(nil, &SecuritySettings{}); got != nil {
+		t.Errorf("expected nil for empty settings, got %+v", got)
+	}
+
+	global := &SecuritySettings{
+		VulnerabilityAlerts: boolPtr(true),
+		SecretScanning:      boolPtr(true),
+	}
+	repo := &SecuritySettings{
+		SecretScanning:                boolPtr(false),
+		PrivateVulnerabilityReporting: boolPtr(true),
+	}
+	got := ResolveSecuritySettings(global, repo)
+	if got == nil {
+		t.Fatal("expected resolved settings")
+	}
+	if !*got.VulnerabilityAlerts {
+		t.Error("expected global vulnerability_alerts to apply")
+	}
+	if *got.SecretScanning {
+		t.Error("expected repository secret_scanning override to win")
+	}
+	if !*got.PrivateVulnerabilityReporting {
+		t.Error("expected repository private_vulnerability_reporting to apply")
+	}
+	if got.DependabotSecurityUpdates != nil {
+		t.Error("expected unset dependabot_security_updates to stay nil")
+	}
+}
+
+func TestValidateSecuritySettings(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       *SecuritySettings
+		wantErr bool
+	}{
+		{name: "nil", s: nil},
+		{name: "all enabled", s: &SecuritySettings{
+			VulnerabilityAlerts: boolPtr(true), DependabotSecurityUpdates: boolPtr(true),
+			SecretScanning: boolPtr(true), SecretScanningPushProtection: boolPtr(true),
+		}},
+		{name: "push protection without secret scanning", s: &SecuritySettings{
+			SecretScanning: boolPtr(false), SecretScanningPushProtection: boolPtr(true),
+		}, wantErr: true},
+		{name: "dependabot without alerts", s: &SecuritySettings{
+			VulnerabilityAlerts: boolPtr(false), DependabotSecurityUpdates: boolPtr(true),
+		}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateSecuritySettings("security", tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateSecuritySettings() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrValidation) {
+				t.Errorf("expected validation error, got %T", err)
+			}
+		})
+	}
+}
+
+func TestSetSecuritySettings(t *testing.T) {
+	m := setupMocks(t)
+
+	gomock.InOrder(
+		m.repoMock.EXPECT().EnableVulnerabilityAlerts(gomock.Any(), "org", "repo").Return(defaultGoodResponse, nil),
+		m.repoMock.EXPECT().Edit(gomock.Any(), "org", "repo", &github.Repository{
+			SecurityAndAnalysis: &github.SecurityAndAnalysis{
+				SecretScanning:               &github.SecretScanning{Status: github.String("enabled")},
+				SecretScanningPushProtection: &github.SecretScanningPushProtection{Status: github.String("disabled")},
+			},
+		}).Return(nil, defaultGoodResponse, nil),
+		m.repoMock.EXPECT().EnableAutomatedSecurityFixes(gomock.Any(), "org", "repo").Return(defaultGoodResponse, nil),
+		m.repoMock.EXPECT().DisablePrivateReporting(gomock.Any(), "org", "repo").Return(defaultGoodResponse, nil),
+	)
+
+	err := m.client.SetSecuritySettings("org", "repo", &SecuritySettings{
+		VulnerabilityAlerts:           boolPtr(true),
+		DependabotSecurityUpdates:     boolPtr(true),
+		SecretScanning:                boolPtr(true),
+		SecretScanningPushProtection:  boolPtr(false),
+		PrivateVulnerabilityReporting: boolPtr(false),
+	})
+	if err != nil {
+		t.Fatalf("SetSecuritySettings() error = %v", err)
+	}
+}
+
+func TestSetSecuritySettingsDisablesInReverse(t *testing.T) {
+	m := setupMocks(t)
+
+	gomock.InOrder(
+		m.repoMock.EXPECT().DisableAutomatedSecurityFixes(gomock.Any(), "org", "repo").Return(defaultGoodResponse, nil),
+		m.repoMock.EXPECT().Edit(gomock.Any(), "org", "repo", gomock.Any()).Return(nil, defaultGoodResponse, nil),
+		m.repoMock.EXPECT().DisableVulnerabilityAlerts(gomock.Any(), "org", "repo").Return(defaultGoodResponse, nil),
+	)
+
+	err := m.client.SetSecuritySettings("org", "repo", &SecuritySettings{
+		VulnerabilityAlerts:       boolPtr(false),
+		DependabotSecurityUpdates: boolPtr(false),
+		SecretScanning:            boolPtr(false),
+	})
+	if err != nil {
+		t.Fatalf("SetSecuritySettings() error = %v", err)
+	}
+}
+
+func TestSetSecuritySettingsError(t *testing.T) {
+	m := setupMocks(t)
+	m.repoMock.EXPECT().DisableVulnerabilityAlerts(gomock.Any(), "org", "repo").
+		Return(nil, ErrDummyV3Error)
+
+	err := m.client.SetSecuritySettings("org", "repo", &SecuritySettings{VulnerabilityAlerts: boolPtr(false)})
+	var apiErr *GitHubAPIError
+	if !errors.As(err, &apiErr) || apiErr.Operation != "set vulnerability alerts" {
+		t.Fatalf("expected GitHubAPIError for vulnerability alerts, got %v", err)
+	}
+}
+
+func TestGetSecuritySettings(t *testing.T) {
+	m := setupMocks(t)
+	m.repoMock.EXPECT().GetVulnerabilityAlerts(gomock.Any(), "org", "repo").Return(false, nil, nil)
+	m.repoMock.EXPECT().GetAutomatedSecurityFixes(gomock.Any(), "org", "repo").
+		Return(&github.AutomatedSecurityFixes{Enabled: github.Bool(true)}, nil, nil)
+	m.repoMock.EXPECT().IsPrivateReportingEnabled(gomock.Any(), "org", "repo").Return(false, nil, ErrDummyV3Error)
+
+	got := getSecuritySettings(m.client, "org", "repo", &github.SecurityAndAnalysis{
+		SecretScanning: &github.SecretScanning{Status: github.String("enabled")},
+	})
+	if got == nil {
+		t.Fatal("expected security settings")
+	}
+	if *got.VulnerabilityAlerts || !*got.DependabotSecurityUpdates || !*got.SecretScanning {
+		t.Errorf("unexpected security settings: %+v", got)
+	}
+	if got.SecretScanningPushProtection != nil || got.PrivateVulnerabilityReporting != nil {
+		t.Errorf("expected unknown states to be nil: %+v", got)
+	}
+}
@@ -0,0 +1,224 @@
+package ownershit
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/google/go-github/v66/github"
+	"github.com/rs/zerolog/log"
+)
+
+// Repository visibilities accepted by selectors.
+var validVisibilities = []string{"public", "private", "internal"}
+
+// RepositorySelector selects organization repositories at runtime instead of listing them by name.
+// All criteria in a selector must match for a repository to be selected.
+type RepositorySelector struct {
+	// Name is a glob matched against the repository name, e.g. "svc-*".
+	Name string `yaml:"name,omitempty"`
+	// Regex is a regular expression matched against the repository name.
+	Regex string `yaml:"regex,omitempty"`
+	// HasTopic matches repositories tagged with the given topic.
+	HasTopic string `yaml:"has_topic,omitempty"`
+	// Language matches the repository's primary language, case-insensitively.
+	Language string `yaml:"language,omitempty"`
+	// Visibility matches public, private or internal repositories.
+	Visibility string `yaml:"visibility,omitempty"`
+	// Archived matches on archive state; unset matches both.
+	Archived *bool `yaml:"archived,omitempty"`
+	// Exclude lists name globs that are never selected, even when every other criterion matches.
+	Exclude []string `yaml:"exclude,omitempty"`
+	// Properties matches repositories whose custom property values equal the given values.
+	// For multi_select properties, the repository must have the value among its selections.
+	Properties map[string]string `yaml:"properties,omitempty"`
+	// Profile is applied to the repositories this selector picks.
+	Profile string `yaml:"profile,omitempty"`
+
+	regex *regexp.Regexp
+}
+
+// hasCriteria reports whether the selector narrows the repository list at all.
+func (s *RepositorySelector) hasCriteria() bool {
+	return s.Name != "" || s.Regex != "" || s.HasTopic != "" || s.Language != "" ||
+		s.Visibility != "" || s.Archived != nil || len(s.Properties) > 0
+}
+
+// ValidateRepositorySelectors checks that each selector has at least one criterion and that its
+// globs and regular expressions compile.
+func ValidateRepositorySelectors(selectors []*RepositorySelector) error {
+	for i, sel := range selectors {
+		field := fmt.Sprintf("selectors[%d]", i)
+		if sel == nil || !sel.hasCriteria() {
+			return NewConfigValidationError(field, sel, "selector must specify at least one criterion", nil)
+		}
+		for _, pattern := range append([]string{sel.Name}, sel.Exclude...) {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return NewConfigValidationError(field, pattern, "invalid name glob", err)
+			}
+		}
+		if sel.Regex != "" {
+			re, err := regexp.Compile(sel.Regex)
+			if err != nil {
+				return NewConfigValidationError(field+".regex", sel.Regex, "invalid regular expression", err)
+			}
+			sel.regex = re
+		}
+		if sel.Visibility != "" && !containsString(validVisibilities, sel.Visibility) {
+			return NewConfigValidationError(field+".visibility", sel.Visibility,
+				fmt.Sprintf("visibility must be one of %s", strings.Join(validVisibilities, ", ")), nil)
+		}
+		for name := range sel.Properties {
+			if strings.TrimSpace(name) == "" {
+				return NewConfigValidationError(field+".properties", sel.Properties, "property name cannot be empty", nil)
+			}
+		}
+	}
+	return nil
+}
+
+// matches reports whether repo and its custom property values satisfy every selector criterion.
+// The selector must have been validated so that Regex is compiled.
+func (s *RepositorySelector) matches(repo *github.Repository, values map[string]PropertyValue) bool {
+	name := repo.GetName()
+	for _, pattern := range s.Exclude {
+		if ok, _ := path.Match(pattern, name); ok {
+			return false
+		}
+	}
+	if s.Name != "" {
+		if ok, _ := path.Match(s.Name, name); !ok {
+			return false
+		}
+	}
+	if s.regex != nil && !s.regex.MatchString(name) {
+		return false
+	}
+	if s.HasTopic != "" && !containsString(repo.Topics, s.HasTopic) {
+		return false
+	}
+	if s.Language != "" && !strings.EqualFold(s.Language, repo.GetLanguage()) {
+		return false
+	}
+	if s.Visibility != "" && s.Visibility != repositoryVisibility(repo) {
+		return false
+	}
+	if s.Archived != nil && *s.Archived != repo.GetArchived() {
+		return false
+	}
+	return s.matchesProperties(values)
+}
+
+// matchesProperties reports whether the repository's property values satisfy every selector property.
+func (s *RepositorySelector) matchesProperties(values map[string]PropertyValue) bool {
+	for name, want := range s.Properties {
+		if !containsString(values[name], want) {
+			return false
+		}
+	}
+	return true
+}
+
+// repositoryVisibility returns the repository visibility, falling back to the private flag
+// for responses that omit it.
+func repositoryVisibility(repo *github.Repository) string {
+	if v := repo.GetVisibility(); v != "" {
+		return v
+	}
+	if repo.GetPrivate() {
+		return "private"
+	}
+	return "public"
+}
+
+// ExpandRepositorySelectors resolves settings.Selectors against the organization's repositories and
+// appends every match that is not already listed explicitly. Explicit entries keep their per-repository
+// overrides. Selected repositories carry their archive state so sync skips archived ones.
+// It is a no-op when no selectors are configured.
+func ExpandRepositorySelectors(settings *PermissionsSettings, client *GitHubClient) error {
+	if len(settings.Selectors) == 0 {
+		return nil
+	}
+	if settings.Organization == nil || *settings.Organization == "" {
+		return NewConfigValidationError("organization", settings.Organization,
+			"organization is required to expand repository selectors", nil)
+	}
+	if err := ValidateRepositorySelectors(settings.Selectors); err != nil {
+		return err
+	}
+
+	repos, err := client.ListOrganizationRepositories(*settings.Organization)
+	if err != nil {
+		return fmt.Errorf("expanding repository selectors: %w", err)
+	}
+
+	var propertyValues map[string]map[string]PropertyValue
+	for _, sel := range settings.Selectors {
+		if len(sel.Properties) > 0 {
+			propertyValues, err = client.ListRepositoryPropertyValues(*settings.Organization)
+			if err != nil {
+				return fmt.Errorf("expanding repository selectors: %w", err)
+			}
+			break
+		}
+	}
+
+	explicit := make(map[string]bool, len(settings.Repositories))
+	for _, repo := range settings.Repositories {
+		if repo != nil && repo.Name != nil {
+			explicit[strings.TrimSpace(*repo.Name)] = true
+		}
+	}
+
+	sort.Slice(repos, func(i, j int) bool { return repos[i].GetName() < repos[j].GetName() })
+
+	added := 0
+	for _, repo := range repos {
+		name := repo.GetName()
+		if explicit[name] {
+			continue
+		}
+		for _, sel := range settings.Selectors {
+			if sel.matches(repo, propertyValues[name]) {
+				archived := repo.GetArchived()
+				selected := &Repository{Name: &name, Archived: &archived}
+				if sel.Profile != "" {
+					profile := sel.Profile
+					selected.Profile = &profile
+				}
+				settings.Repositories = append(settings.Repositories, selected)
+				explicit[name] = true
+				added++
+				break
+			}
+		}
+	}
+
+	log.Info().
+		Str("organization", *settings.Organization).
+		Int("selected", added).
+		Int("total", len(settings.Repositories)).
+		Msg("expanded repository selectors")
+	return nil
+}
+
+// ListOrganizationRepositories returns every repository in the organization.
+func (c *GitHubClient) ListOrganizationRepositories(org string) ([]*github.Repository, error) {
+	var result []*github.Repository
+	opts := &github.RepositoryListByOrgOptions{Type: "all", ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		repos, resp, err := c.Repositories.ListByOrg(c.Context, org, opts)
+		if err != nil {
+			return nil, classifyAPIError(responseStatus(resp), "list repositories", org,
+				"failed to list organization repositories", err)
+		}
+		result = append(result, repos...)
+		if resp == nil || resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return result, nil
+}
@@ -0,0 +1,151 @@
+package ownershit
+
+import (
+	"testing"
+
+	"github.com/google/go-github/v66/github"
+	"go.uber.org/mock/gomock"
+)
+
+func TestExpandRepositorySelectorsByProperty(t *testing.T) {
+	m := setupMocks(t)
+	m.repoMock.EXPECT().ListByOrg(gomock.Any(), "org", gomock.Any()).
+		Return([]*github.Repository{
+			{Name: github.String("sandbox")},
+			{Name: github.String("payments")},
+			{Name: github.String("explicit")},
+			{Name: github.String("multi")},
+		}, &github.Response{}, nil)
+	m.orgsMock.EXPECT().ListCustomPropertyValues(gomock.Any(), "org", gomock.Any()).
+		Return([]*github.RepoCustomPropertyValue{
+			{RepositoryName: "payments", Properties: []*github.CustomPropertyValue{{PropertyName: "tier", Value: "critical"}}},
+			{RepositoryName: "sandbox", Properties: []*github.CustomPropertyValue{{PropertyName: "tier", Value: "sandbox"}}},
+			{RepositoryName: "explicit", Properties: []*github.CustomPropertyValue{{PropertyName: "tier", Value: "critical"}}},
+			{RepositoryName: "multi", Properties: []*github.CustomPropertyValue{{PropertyName: "tier", Value: []string{"critical", "standard"}}}},
+		}, &github.Response{}, nil)
+
+	settings := &PermissionsSettings{
+		Organization: stringPtr("org"),
+		Repositories: []*Repository{{Name: stringPtr("explicit"), Wiki: boolPtr(true)}},
+		Selectors:    []*RepositorySelector{{Properties: map[string]string{"tier": "critical"}}},
+	}
+	if err := ExpandRepositorySelectors(settings, m.client); err != nil {
+		t.Fatalf("ExpandRepositorySelectors() error = %v", err)
+	}
+
+	var names []string
+	for _, r := range settings.Repositories {
+		names = append(names, *r.Name)
+	}
+	want := []string{"explicit", "multi", "payments"}
+	if len(names) != len(want) {
+		t.Fatalf("repositories = %v, want %v", names, want)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Errorf("repositories = %v, want %v", names, want)
+			break
+		}
+	}
+	if settings.Repositories[0].Wiki == nil || !*settings.Repositories[0].Wiki {
+		t.Error("explicit repository overrides must be preserved")
+	}
+}
+
+func TestExpandRepositorySelectorsNoop(t *testing.T) {
+	m := setupMocks(t)
+	settings := &PermissionsSettings{Organization: stringPtr("org")}
+	if err := ExpandRepositorySelectors(settings, m.client); err != nil {
+		t.Fatalf("ExpandRepositorySelectors() error = %v", err)
+	}
+}
+
+func TestValidatePermissionsSettingsAllowsSelectorsWithoutRepositories(t *testing.T) {
+	settings := &PermissionsSettings{
+		Organization: stringPtr("org"),
+		Selectors:    []*RepositorySelector{{Properties: map[string]string{"tier": "critical"}}},
+	}
+	if err := ValidatePermissionsSettings(settings); err != nil {
+		t.Fatalf("ValidatePermissionsSettings() error = %v", err)
+	}
+
+	settings.Selectors = []*RepositorySelector{{}}
+	if err := ValidatePermissionsSettings(settings); err == nil {
+		t.Fatal("expected error for empty selector")
+	}
+}
+
+func TestRepositorySelectorMatches(t *testing.T) {
+	repo := &github.Repository{
+		Name:       github.String("svc-billing"),
+		Topics:     []string{"go", "payments"},
+		Language:   github.String("Go"),
+		Visibility: github.String("private"),
+		Archived:   github.Bool(false),
+	}
+	tests := []struct {
+		name string
+		sel  *RepositorySelector
+		want bool
+	}{
+		{name: "glob", sel: &RepositorySelector{Name: "svc-*"}, want: true},
+		{name: "glob mismatch", sel: &RepositorySelector{Name: "lib-*"}, want: false},
+		{name: "regex", sel: &RepositorySelector{Regex: "^svc-(billing|ledger)$"}, want: true},
+		{name: "topic", sel: &RepositorySelector{HasTopic: "payments"}, want: true},
+		{name: "missing topic", sel: &RepositorySelector{HasTopic: "frontend"}, want: false},
+		{name: "language", sel: &RepositorySelector{Language: "go"}, want: true},
+		{name: "visibility", sel: &RepositorySelector{Visibility: "public"}, want: false},
+		{name: "not archived", sel: &RepositorySelector{Archived: boolPtr(false)}, want: true},
+		{name: "excluded", sel: &RepositorySelector{Name: "svc-*", Exclude: []string{"*-billing"}}, want: false},
+		{name: "all criteria", sel: &RepositorySelector{
+			Name: "svc-*", HasTopic: "go", Language: "Go", Visibility: "private", Archived: boolPtr(false),
+		}, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateRepositorySelectors([]*RepositorySelector{tt.sel}); err != nil {
+				t.Fatalf("ValidateRepositorySelectors() error = %v", err)
+			}
+			if got := tt.sel.matches(repo, nil); got != tt.want {
+				t.Errorf("matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateRepositorySelectorsRejectsBadPatterns(t *testing.T) {
+	for _, sel := range []*RepositorySelector{
+		{Name: "svc-["},
+		{Regex: "svc-("},
+		{Name: "x", Exclude: []string{"["}},
+		{Visibility: "secret"},
+	} {
+		if err := ValidateRepositorySelectors([]*RepositorySelector{sel}); err == nil {
+			t.Errorf("expected error for %+v", sel)
+		}
+	}
+}
+
+func TestExpandRepositorySelectorsByName(t *testing.T) {
+	m := setupMocks(t)
+	m.repoMock.EXPECT().ListByOrg(gomock.Any(), "org", gomock.Any()).
+		Return([]*github.Repository{
+			{Name: github.String("svc-a"), Archived: github.Bool(true)},
+			{Name: github.String("svc-b")},
+			{Name: github.String("lib-c")},
+		}, &github.Response{}, nil)
+
+	settings := &PermissionsSettings{
+		Organization: stringPtr("org"),
+		Selectors:    []*RepositorySelector{{Name: "svc-*"}},
+	}
+	if err := ExpandRepositorySelectors(settings, m.client); err != nil {
+		t.Fatalf("ExpandRepositorySelectors() error = %v", err)
+	}
+	if len(settings.Repositories) != 2 {
+		t.Fatalf("expected 2 repositories, got %d", len(settings.Repositories))
+	}
+	if !*settings.Repositories[0].Archived || *settings.Repositories[1].Archived {
+		t.Error("expected archive state to be carried over so sync skips archived repositories")
+	}
+}
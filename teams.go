@@ -0,0 +1,444 @@
+package ownershit
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/google/go-github/v66/github"
+	"github.com/rs/zerolog/log"
+)
+
+// Team privacy levels. Nested teams must be closed.
+const (
+	TeamPrivacyClosed = "closed"
+	TeamPrivacySecret = "secret"
+)
+
+// Team membership roles.
+const (
+	TeamRoleMember     = "member"
+	TeamRoleMaintainer = "maintainer"
+)
+
+var teamSlugSanitizer = regexp.MustCompile(`[^a-z0-9_]+`)
+
+// TeamDefinition declares an organization team that ownershit creates and keeps up to date
+// before repository permissions are applied. Nil fields leave the current value untouched.
+type TeamDefinition struct {
+	Name        string  `yaml:"name"`
+	Description *string `yaml:"description,omitempty"`
+	Privacy     *string `yaml:"privacy,omitempty"`
+	// Parent is the name or slug of the parent team. An empty string removes the parent.
+	Parent      *string  `yaml:"parent,omitempty"`
+	Maintainers []string `yaml:"maintainers,omitempty"`
+	Members     []string `yaml:"members,omitempty"`
+	// PruneMembers removes users that are neither maintainers nor members of this team
+	// or of any declared child team.
+	PruneMembers *bool `yaml:"prune_members,omitempty"`
+}
+
+// TeamSlug derives the GitHub slug for a team name, e.g. "Platform Eng" becomes "platform-eng".
+func TeamSlug(name string) string {
+	return strings.Trim(teamSlugSanitizer.ReplaceAllString(strings.ToLower(strings.TrimSpace(name)), "-"), "-")
+}
+
+// Slug returns the GitHub slug of the team.
+func (t *TeamDefinition) Slug() string {
+	return TeamSlug(t.Name)
+}
+
+// parentSlug returns the slug of the configured parent, or "" when unset or removed.
+func (t *TeamDefinition) parentSlug() string {
+	if t.Parent == nil {
+		return ""
+	}
+	return TeamSlug(*t.Parent)
+}
+
+// TeamMembershipChange adds a user to a team or changes their role.
+type TeamMembershipChange struct {
+	User string
+	Role string
+}
+
+// TeamMembershipPlan describes the membership changes needed for a team to match its definition.
+type TeamMembershipPlan struct {
+	Add    []TeamMembershipChange
+	Remove []string
+}
+
+// HasChanges reports whether the plan would modify the team.
+func (p *TeamMembershipPlan) HasChanges() bool {
+	return p != nil && (len(p.Add) > 0 || len(p.Remove) > 0)
+}
+
+// ValidateTeamDefinitions checks team names, privacy, membership and the parent hierarchy.
+func ValidateTeamDefinitions(teams []*TeamDefinition) error {
+	declared := make(map[string]*TeamDefinition, len(teams))
+	for i, team := range teams {
+		field := fmt.Sprintf("teams[%d]", i)
+		if team == nil {
+			return NewConfigValidationError(field, nil, "team cannot be nil", nil)
+		}
+		if team.Slug() == "" {
+			return NewConfigValidationError(field+".name", team.Name, "team name cannot be empty", nil)
+		}
+		if declared[team.Slug()] != nil {
+			return NewConfigValidationError(field+".name", team.Name, "duplicate team name", nil)
+		}
+		declared[team.Slug()] = team
+
+		if team.Privacy != nil && *team.Privacy != TeamPrivacyClosed && *team.Privacy != TeamPrivacySecret {
+			return NewConfigValidationError(field+".privacy", *team.Privacy,
+				fmt.Sprintf("privacy must be %s or %s", TeamPrivacyClosed, TeamPrivacySecret), nil)
+		}
+		if team.parentSlug() != "" && team.Privacy != nil && *team.Privacy == TeamPrivacySecret {
+			return NewConfigValidationError(field+".privacy", *team.Privacy, "nested teams must be closed", nil)
+		}
+		if team.parentSlug() == team.Slug() {
+			return NewConfigValidationError(field+".parent", *team.Parent, "team cannot be its own parent", nil)
+		}
+
+		roles := make(map[string]string)
+		for _, role := range []struct {
+			name  string
+			users []string
+		}{{TeamRoleMaintainer, team.Maintainers}, {TeamRoleMember, team.Members}} {
+			for _, user := range role.users {
+				login := strings.ToLower(strings.TrimSpace(user))
+				if login == "" {
+					return NewConfigValidationError(field+"."+role.name+"s", team.Name, "username cannot be empty", nil)
+				}
+				if roles[login] != "" {
+					return NewConfigValidationError(field+"."+role.name+"s", user,
+						"user is listed more than once in the team", nil)
+				}
+				roles[login] = role.name
+			}
+		}
+	}
+
+	for i, team := range teams {
+		parent := declared[team.parentSlug()]
+		if parent != nil && parent.Privacy != nil && *parent.Privacy == TeamPrivacySecret {
+			return NewConfigValidationError(fmt.Sprintf("teams[%d].parent", i), *team.Parent,
+				"secret teams cannot have child teams", nil)
+		}
+	}
+	if _, err := orderTeams(teams); err != nil {
+		return err
+	}
+	return nil
+}
+
+// orderTeams returns the teams with every declared parent ahead of its children,
+// otherwise preserving configuration order.
+func orderTeams(teams []*TeamDefinition) ([]*TeamDefinition, error) {
+	declared := make(map[string]*TeamDefinition, len(teams))
+	for _, team := range teams {
+		declared[team.Slug()] = team
+	}
+
+	ordered := make([]*TeamDefinition, 0, len(teams))
+	state := make(map[string]int) // 1 = visiting, 2 = done
+	var visit func(team *TeamDefinition) error
+	visit = func(team *TeamDefinition) error {
+		switch state[team.Slug()] {
+		case 1:
+			return NewConfigValidationError("teams", team.Name, "team parent hierarchy contains a cycle", nil)
+		case 2:
+			return nil
+		}
+		state[team.Slug()] = 1
+		if parent := declared[team.parentSlug()]; parent != nil {
+			if err := visit(parent); err != nil {
+				return err
+			}
+		}
+		state[team.Slug()] = 2
+		ordered = append(ordered, team)
+		return nil
+	}
+	for _, team := range teams {
+		if err := visit(team); err != nil {
+			return nil, err
+		}
+	}
+	return ordered, nil
+}
+
+// descendantMembers returns, for each declared team slug, the logins declared in any of its
+// declared descendant teams. GitHub lists those users as members of the parent, so pruning skips them.
+func descendantMembers(teams []*TeamDefinition) map[string]map[string]bool {
+	declared := make(map[string]*TeamDefinition, len(teams))
+	for _, team := range teams {
+		declared[team.Slug()] = team
+	}
+
+	result := make(map[string]map[string]bool)
+	for _, team := range teams {
+		seen := map[string]bool{team.Slug(): true}
+		for parent := declared[team.parentSlug()]; parent != nil && !seen[parent.Slug()]; parent = declared[parent.parentSlug()] {
+			seen[parent.Slug()] = true
+			if result[parent.Slug()] == nil {
+				result[parent.Slug()] = make(map[string]bool)
+			}
+			for _, user := range append(append([]string{}, team.Maintainers...), team.Members...) {
+				result[parent.Slug()][strings.ToLower(strings.TrimSpace(user))] = true
+			}
+		}
+	}
+	return result
+}
+
+// PlanTeamMembership compares the declared membership with current (login to role) and returns the
+// changes required. Users in keep are never removed. Logins are compared case-insensitively.
+func PlanTeamMembership(team *TeamDefinition, current map[string]string, keep map[string]bool) *TeamMembershipPlan {
+	plan := &TeamMembershipPlan{}
+	currentRoles := make(map[string]string, len(current))
+	for login, role := range current {
+		currentRoles[strings.ToLower(login)] = role
+	}
+
+	declared := make(map[string]bool)
+	for _, role := range []struct {
+		name  string
+		users []string
+	}{{TeamRoleMaintainer, team.Maintainers}, {TeamRoleMember, team.Members}} {
+		for _, user := range role.users {
+			user = strings.TrimSpace(user)
+			login := strings.ToLower(user)
+			declared[login] = true
+			if currentRoles[login] != role.name {
+				plan.Add = append(plan.Add, TeamMembershipChange{User: user, Role: role.name})
+			}
+		}
+	}
+
+	if team.PruneMembers != nil && *team.PruneMembers {
+		for login := range current {
+			lower := strings.ToLower(login)
+			if !declared[lower] && !keep[lower] {
+				plan.Remove = append(plan.Remove, login)
+			}
+		}
+		sort.Strings(plan.Remove)
+	}
+	return plan
+}
+
+// teamNeedsUpdate reports whether the declared description, privacy or parent differ from current.
+func teamNeedsUpdate(team *TeamDefinition, current *github.Team) bool {
+	if team.Description != nil && *team.Description != current.GetDescription() {
+		return true
+	}
+	if team.Privacy != nil && *team.Privacy != current.GetPrivacy() {
+		return true
+	}
+	if team.Parent != nil && team.parentSlug() != current.GetParent().GetSlug() {
+		return true
+	}
+	return false
+}
+
+// ApplyTeams creates or updates the declared teams, parents first, and reconciles their membership.
+// If dryRun is true, it logs the team and membership changes that would be made. A failed team is
+// logged and skipped; the returned error joins every failure.
+func ApplyTeams(settings *PermissionsSettings, client *GitHubClient, dryRun bool) error {
+	if len(settings.Teams) == 0 {
+		return nil
+	}
+	org := *settings.Organization
+	ordered, err := orderTeams(settings.Teams)
+	if err != nil {
+		log.Err(err).Msg("ordering teams")
+		return err
+	}
+	inherited := descendantMembers(settings.Teams)
+	var errs []error
+
+	for _, team := range ordered {
+		current, err := client.GetTeam(org, team.Slug())
+		if err != nil {
+			log.Err(err).Str("team", team.Name).Msg("getting team")
+			errs = append(errs, err)
+			continue
+		}
+
+		switch {
+		case current == nil && dryRun:
+			log.Info().
+				Str("organization", org).
+				Str("team", team.Name).
+				Str("parent", team.parentSlug()).
+				Msg("Would create team")
+		case current == nil:
+			if err := client.CreateTeam(org, team); err != nil {
+				log.Err(err).Str("team", team.Name).Msg("creating team")
+				errs = append(errs, err)
+				continue
+			}
+		case teamNeedsUpdate(team, current) && dryRun:
+			log.Info().
+				Str("organization", org).
+				Str("team", team.Name).
+				Str("parent", team.parentSlug()).
+				Msg("Would update team")
+		case teamNeedsUpdate(team, current):
+			if err := client.UpdateTeam(org, team); err != nil {
+				log.Err(err).Str("team", team.Name).Msg("updating team")
+				errs = append(errs, err)
+			}
+		}
+
+		members := map[string]string{}
+		if current != nil || !dryRun {
+			members, err = client.ListTeamMemberships(org, team.Slug())
+			if err != nil {
+				log.Err(err).Str("team", team.Name).Msg("listing team members")
+				errs = append(errs, err)
+				continue
+			}
+		}
+		plan := PlanTeamMembership(team, members, inherited[team.Slug()])
+		if !plan.HasChanges() {
+			continue
+		}
+		if dryRun {
+			for _, add := range plan.Add {
+				log.Info().
+					Str("team", team.Name).
+					Str("user", add.User).
+					Str("role", add.Role).
+					Msg("Would add team member")
+			}
+			for _, user := range plan.Remove {
+				log.Info().
+					Str("team", team.Name).
+					Str("user", user).
+					Msg("Would remove team member")
+			}
+			continue
+		}
+		if err := client.ApplyTeamMembershipPlan(org, team.Slug(), plan); err != nil {
+			log.Err(err).Str("team", team.Name).Msg("updating team membership")
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// GetTeam returns the team with the given slug, or nil when it does not exist.
+func (c *GitHubClient) GetTeam(org, slug string) (*github.Team, error) {
+	team, resp, err := c.Teams.GetTeamBySlug(c.Context, org, slug)
+	if err != nil {
+		if responseStatus(resp) == http.StatusNotFound {
+			return nil, nil
+		}
+		return nil, classifyAPIError(responseStatus(resp), "get team", fmt.Sprintf("%s/%s", org, slug),
+			"failed to get team", err)
+	}
+	return team, nil
+}
+
+// newTeamRequest builds the create/edit payload for team, resolving the parent team ID.
+func (c *GitHubClient) newTeamRequest(org string, team *TeamDefinition) (github.NewTeam, error) {
+	req := github.NewTeam{
+		Name:        team.Name,
+		Description: team.Description,
+		Privacy:     team.Privacy,
+	}
+	if parent := team.parentSlug(); parent != "" {
+		parentTeam, err := c.GetTeam(org, parent)
+		if err != nil {
+			return req, err
+		}
+		if parentTeam == nil {
+			return req, NewGitHubAPIError(http.StatusNotFound, "get team", fmt.Sprintf("%s/%s", org, parent),
+				fmt.Sprintf("parent team of %s does not exist", team.Name), nil)
+		}
+		req.ParentTeamID = parentTeam.ID
+	}
+	return req, nil
+}
+
+// CreateTeam creates the team in the organization.
+func (c *GitHubClient) CreateTeam(org string, team *TeamDefinition) error {
+	req, err := c.newTeamRequest(org, team)
+	if err != nil {
+		return err
+	}
+	_, resp, err := c.Teams.CreateTeam(c.Context, org, req)
+	if err != nil {
+		return classifyAPIError(responseStatus(resp), "create team", fmt.Sprintf("%s/%s", org, team.Slug()),
+			"failed to create team", err)
+	}
+	log.Info().Str("organization", org).Str("team", team.Name).Msg("Successfully created team")
+	return nil
+}
+
+// UpdateTeam updates the team's description, privacy and parent. An empty parent removes it.
+func (c *GitHubClient) UpdateTeam(org string, team *TeamDefinition) error {
+	req, err := c.newTeamRequest(org, team)
+	if err != nil {
+		return err
+	}
+	removeParent := team.Parent != nil && team.parentSlug() == ""
+	_, resp, err := c.Teams.EditTeamBySlug(c.Context, org, team.Slug(), req, removeParent)
+	if err != nil {
+		return classifyAPIError(responseStatus(resp), "edit team", fmt.Sprintf("%s/%s", org, team.Slug()),
+			"failed to update team", err)
+	}
+	log.Info().Str("organization", org).Str("team", team.Name).Msg("Successfully updated team")
+	return nil
+}
+
+// ListTeamMemberships returns the team's members keyed by login, with their team role.
+func (c *GitHubClient) ListTeamMemberships(org, slug string) (map[string]string, error) {
+	result := make(map[string]string)
+	for _, role := range []string{TeamRoleMember, TeamRoleMaintainer} {
+		opts := &github.TeamListTeamMembersOptions{Role: role, ListOptions: github.ListOptions{PerPage: 100}}
+		for {
+			users, resp, err := c.Teams.ListTeamMembersBySlug(c.Context, org, slug, opts)
+			if err != nil {
+				return nil, classifyAPIError(responseStatus(resp), "list team members", fmt.Sprintf("%s/%s", org, slug),
+					"failed to list team members", err)
+			}
+			for _, user := range users {
+				result[user.GetLogin()] = role
+			}
+			if resp == nil || resp.NextPage == 0 {
+				break
+			}
+			opts.Page = resp.NextPage
+		}
+	}
+	return result, nil
+}
+
+// ApplyTeamMembershipPlan adds or re-roles members, then removes pruned ones.
+func (c *GitHubClient) ApplyTeamMembershipPlan(org, slug string, plan *TeamMembershipPlan) error {
+	teamPath := fmt.Sprintf("%s/%s", org, slug)
+	for _, add := range plan.Add {
+		_, resp, err := c.Teams.AddTeamMembershipBySlug(c.Context, org, slug, add.User,
+			&github.TeamAddTeamMembershipOptions{Role: add.Role})
+		if err != nil {
+			return classifyAPIError(responseStatus(resp), "add team membership", teamPath,
+				fmt.Sprintf("failed to add %s as %s", add.User, add.Role), err)
+		}
+		log.Info().Str("team", teamPath).Str("user", add.User).Str("role", add.Role).Msg("Successfully added team member")
+	}
+	for _, user := range plan.Remove {
+		resp, err := c.Teams.RemoveTeamMembershipBySlug(c.Context, org, slug, user)
+		if err != nil {
+			return classifyAPIError(responseStatus(resp), "remove team membership", teamPath,
+				fmt.Sprintf("failed to remove %s", user), err)
+		}
+		log.Info().Str("team", teamPath).Str("user", user).Msg("Successfully removed team member")
+	}
+	return nil
+}
@@ -0,0 +1,187 @@
+package ownershit
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/google/go-github/v66/github"
+	"go.uber.org/mock/gomock"
+)
+
+func TestTeamSlug(t *testing.T) {
+	tests := map[string]string{
+		"Platform Eng":  "platform-eng",
+		"  sre  ":       "sre",
+		"Data.Science!": "data-science",
+		"api_owners":    "api_owners",
+	}
+	for name, want := range tests {
+		if got := TeamSlug(name); got != want {
+			t.Errorf("TeamSlug(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestValidateTeamDefinitions(t *testing.T) {
+	tests := []struct {
+		name    string
+		teams   []*TeamDefinition
+		wantErr bool
+	}{
+		{name: "valid", teams: []*TeamDefinition{
+			{Name: "Platform", Privacy: stringPtr(TeamPrivacyClosed), Maintainers: []string{"alice"}},
+			{Name: "SRE", Parent: stringPtr("platform"), Members: []string{"bob"}},
+		}},
+		{name: "empty name", teams: []*TeamDefinition{{Name: " "}}, wantErr: true},
+		{name: "duplicate", teams: []*TeamDefinition{{Name: "SRE"}, {Name: "sre"}}, wantErr: true},
+		{name: "bad privacy", teams: []*TeamDefinition{{Name: "a", Privacy: stringPtr("public")}}, wantErr: true},
+		{name: "secret child", teams: []*TeamDefinition{
+			{Name: "a", Parent: stringPtr("b"), Privacy: stringPtr(TeamPrivacySecret)},
+		}, wantErr: true},
+		{name: "secret parent", teams: []*TeamDefinition{
+			{Name: "a", Privacy: stringPtr(TeamPrivacySecret)},
+			{Name: "b", Parent: stringPtr("a")},
+		}, wantErr: true},
+		{name: "user in both roles", teams: []*TeamDefinition{
+			{Name: "a", Maintainers: []string{"alice"}, Members: []string{"Alice"}},
+		}, wantErr: true},
+		{name: "cycle", teams: []*TeamDefinition{
+			{Name: "a", Parent: stringPtr("b")},
+			{Name: "b", Parent: stringPtr("a")},
+		}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateTeamDefinitions(tt.teams); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateTeamDefinitions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestOrderTeams(t *testing.T) {
+	teams := []*TeamDefinition{
+		{Name: "leaf", Parent: stringPtr("middle")},
+		{Name: "middle", Parent: stringPtr("root")},
+		{Name: "root"},
+		{Name: "external-child", Parent: stringPtr("not-declared")},
+	}
+	ordered, err := orderTeams(teams)
+	if err != nil {
+		t.Fatalf("orderTeams() error = %v", err)
+	}
+	var names []string
+	for _, team := range ordered {
+		names = append(names, team.Name)
+	}
+	want := []string{"root", "middle", "leaf", "external-child"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("orderTeams() = %v, want %v", names, want)
+	}
+}
+
+func TestPlanTeamMembership(t *testing.T) {
+	team := &TeamDefinition{
+		Name:         "platform",
+		Maintainers:  []string{"alice"},
+		Members:      []string{"Bob", "carol"},
+		PruneMembers: boolPtr(true),
+	}
+	current := map[string]string{
+		"alice": TeamRoleMember,
+		"bob":   TeamRoleMember,
+		"dave":  TeamRoleMember,
+		"erin":  TeamRoleMaintainer,
+	}
+	plan := PlanTeamMembership(team, current, map[string]bool{"erin": true})
+
+	wantAdd := []TeamMembershipChange{
+		{User: "alice", Role: TeamRoleMaintainer},
+		{User: "carol", Role: TeamRoleMember},
+	}
+	if !reflect.DeepEqual(plan.Add, wantAdd) {
+		t.Errorf("Add = %v, want %v", plan.Add, wantAdd)
+	}
+	if !reflect.DeepEqual(plan.Remove, []string{"dave"}) {
+		t.Errorf("Remove = %v, want [dave]", plan.Remove)
+	}
+
+	team.PruneMembers = nil
+	if plan := PlanTeamMembership(team, current, nil); len(plan.Remove) != 0 {
+		t.Errorf("expected no removals without prune_members, got %v", plan.Remove)
+	}
+}
+
+func TestDescendantMembers(t *testing.T) {
+	got := descendantMembers([]*TeamDefinition{
+		{Name: "root"},
+		{Name: "middle", Parent: stringPtr("root"), Members: []string{"bob"}},
+		{Name: "leaf", Parent: stringPtr("middle"), Maintainers: []string{"Carol"}},
+	})
+	if !got["root"]["bob"] || !got["root"]["carol"] || !got["middle"]["carol"] || got["middle"]["bob"] {
+		t.Errorf("descendantMembers() = %v", got)
+	}
+}
+
+func TestApplyTeamsCreatesMissingTeam(t *testing.T) {
+	m := setupMocks(t)
+	notFound := &github.Response{Response: &http.Response{StatusCode: http.StatusNotFound}}
+	settings := &PermissionsSettings{
+		Organization: stringPtr("org"),
+		Teams: []*TeamDefinition{{
+			Name:        "Platform Eng",
+			Description: stringPtr("Platform engineering"),
+			Parent:      stringPtr("engineering"),
+			Members:     []string{"bob"},
+		}},
+	}
+
+	gomock.InOrder(
+		m.teamMock.EXPECT().GetTeamBySlug(gomock.Any(), "org", "platform-eng").Return(nil, notFound, ErrDummyV3Error),
+		m.teamMock.EXPECT().GetTeamBySlug(gomock.Any(), "org", "engineering").
+			Return(&github.Team{ID: github.Int64(42)}, defaultGoodResponse, nil),
+		m.teamMock.EXPECT().CreateTeam(gomock.Any(), "org", github.NewTeam{
+			Name:         "Platform Eng",
+			Description:  stringPtr("Platform engineering"),
+			ParentTeamID: github.Int64(42),
+		}).Return(&github.Team{}, defaultGoodResponse, nil),
+		m.teamMock.EXPECT().ListTeamMembersBySlug(gomock.Any(), "org", "platform-eng", gomock.Any()).
+			Return([]*github.User{}, defaultGoodResponse, nil),
+		m.teamMock.EXPECT().ListTeamMembersBySlug(gomock.Any(), "org", "platform-eng", gomock.Any()).
+			Return([]*github.User{{Login: github.String("creator")}}, defaultGoodResponse, nil),
+		m.teamMock.EXPECT().AddTeamMembershipBySlug(gomock.Any(), "org", "platform-eng", "bob",
+			&github.TeamAddTeamMembershipOptions{Role: TeamRoleMember}).
+			Return(&github.Membership{}, defaultGoodResponse, nil),
+	)
+	ApplyTeams(settings, m.client, false)
+}
+
+func TestApplyTeamsDryRun(t *testing.T) {
+	m := setupMocks(t)
+	settings := &PermissionsSettings{
+		Organization: stringPtr("org"),
+		Teams: []*TeamDefinition{{
+			Name:         "sre",
+			Privacy:      stringPtr(TeamPrivacySecret),
+			Members:      []string{"bob"},
+			PruneMembers: boolPtr(true),
+		}},
+	}
+	m.teamMock.EXPECT().GetTeamBySlug(gomock.Any(), "org", "sre").
+		Return(&github.Team{Privacy: github.String(TeamPrivacyClosed)}, defaultGoodResponse, nil)
+	m.teamMock.EXPECT().ListTeamMembersBySlug(gomock.Any(), "org", "sre", gomock.Any()).
+		Return([]*github.User{{Login: github.String("dave")}}, defaultGoodResponse, nil).Times(2)
+	// Dry run must not call EditTeamBySlug, AddTeamMembershipBySlug or RemoveTeamMembershipBySlug.
+	ApplyTeams(settings, m.client, true)
+}
+
+func TestApplyTeamMembershipPlanRemoveError(t *testing.T) {
+	m := setupMocks(t)
+	m.teamMock.EXPECT().RemoveTeamMembershipBySlug(gomock.Any(), "org", "sre", "dave").
+		Return(defaultGoodResponse, ErrDummyV3Error)
+	err := m.client.ApplyTeamMembershipPlan("org", "sre", &TeamMembershipPlan{Remove: []string{"dave"}})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+}
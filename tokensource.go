@@ -0,0 +1,257 @@
+package ownershit
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Token source kinds accepted by ParseTokenSource.
+const (
+	TokenSourceEnv           = "env"
+	TokenSourceFile          = "file"
+	TokenSourceGH            = "gh"
+	TokenSourceGitCredential = "git-credential"
+	TokenSourceCommand       = "command"
+)
+
+// tokenCommandTimeout bounds how long a token command may run.
+const tokenCommandTimeout = 30 * time.Second
+
+// Sentinel errors for token sources.
+var (
+	ErrTokenSourceUnknown   = errors.New("unknown token source")
+	ErrTokenFilePermissions = errors.New("token file is accessible by other users")
+	ErrTokenCommandFailed   = errors.New("token command failed")
+)
+
+// TokenSource supplies the token used to authenticate with GitHub.
+type TokenSource interface {
+	// Token returns the raw token, without validating its format.
+	Token(ctx context.Context) (string, error)
+	// String describes the source for logs and errors; it never includes the token.
+	String() string
+}
+
+// ParseTokenSource returns the token source described by spec:
+//
+//	env            the variable tokenEnv (GITHUB_TOKEN when empty)
+//	env:NAME       the variable NAME
+//	file:PATH      the first line of a file readable only by its owner
+//	gh             the output of `gh auth token` for host
+//	git-credential the password git's credential helpers store for host
+//	command:CMD    the output of CMD, split into arguments on spaces and run without a shell
+//
+// An empty spec is the same as env. host is the GitHub host name, such as github.com.
+func ParseTokenSource(spec, tokenEnv, host string) (TokenSource, error) {
+	if tokenEnv == "" {
+		tokenEnv = DefaultTokenEnv
+	}
+	if host == "" {
+		host = "github.com"
+	}
+	kind, argument, hasArgument := strings.Cut(strings.TrimSpace(spec), ":")
+	switch kind {
+	case "", TokenSourceEnv:
+		if !hasArgument {
+			return EnvTokenSource{Name: tokenEnv}, nil
+		}
+		if !envVarName.MatchString(argument) {
+			return nil, fmt.Errorf("%w: %q is not an environment variable name", ErrTokenSourceUnknown, argument)
+		}
+		return EnvTokenSource{Name: argument}, nil
+	case TokenSourceFile:
+		if argument == "" {
+			return nil, fmt.Errorf("%w: file requires a path, as in file:~/.config/ownershit/token", ErrTokenSourceUnknown)
+		}
+		return FileTokenSource{Path: expandHome(argument)}, nil
+	case TokenSourceGH:
+		if hasArgument {
+			return nil, fmt.Errorf("%w: gh takes no argument", ErrTokenSourceUnknown)
+		}
+		return GHTokenSource{Host: host}, nil
+	case TokenSourceGitCredential:
+		if hasArgument {
+			return nil, fmt.Errorf("%w: git-credential takes no argument", ErrTokenSourceUnknown)
+		}
+		return GitCredentialTokenSource{Host: host}, nil
+	case TokenSourceCommand:
+		args := strings.Fields(argument)
+		if len(args) == 0 {
+			return nil, fmt.Errorf("%w: command requires a command line, as in command:pass show github", ErrTokenSourceUnknown)
+		}
+		return CommandTokenSource{Args: args}, nil
+	default:
+		return nil, fmt.Errorf("%w %q; expected env, file, gh, git-credential or command", ErrTokenSourceUnknown, kind)
+	}
+}
+
+// ValidatedToken returns the token from source after checking its format with ValidateGitHubToken.
+func ValidatedToken(ctx context.Context, source TokenSource) (string, error) {
+	token, err := source.Token(ctx)
+	if err != nil {
+		return "", err
+	}
+	token = strings.TrimSpace(token)
+	if err := ValidateGitHubToken(token); err != nil {
+		return "", fmt.Errorf("invalid GitHub token from %s: %w", source, err)
+	}
+	return token, nil
+}
+
+// EnvTokenSource reads the token from an environment variable.
+type EnvTokenSource struct {
+	Name string
+}
+
+// Token returns the value of the variable.
+func (s EnvTokenSource) Token(context.Context) (string, error) {
+	token := os.Getenv(s.Name)
+	if token == "" {
+		if s.Name == DefaultTokenEnv {
+			return "", ErrTokenNotFound
+		}
+		return "", fmt.Errorf("%w: %s", ErrTokenEnvNotSet, s.Name)
+	}
+	return token, nil
+}
+
+func (s EnvTokenSource) String() string { return "environment variable " + s.Name }
+
+// FileTokenSource reads the token from the first line of a file. The file must not be readable or
+// writable by group or other users.
+type FileTokenSource struct {
+	Path string
+}
+
+// Token returns the first line of the file.
+func (s FileTokenSource) Token(context.Context) (string, error) {
+	info, err := os.Stat(s.Path)
+	if err != nil {
+		return "", fmt.Errorf("reading token file: %w", err)
+	}
+	if !info.Mode().IsRegular() {
+		return "", fmt.Errorf("reading token file %s: not a regular file", s.Path)
+	}
+	// Windows does not report Unix permission bits.
+	if perm := info.Mode().Perm(); runtime.GOOS != "windows" && perm&0o077 != 0 {
+		return "", fmt.Errorf("%w: %s has mode %04o; run chmod 600 %s", ErrTokenFilePermissions, s.Path, perm, s.Path)
+	}
+	data, err := os.ReadFile(s.Path)
+	if err != nil {
+		return "", fmt.Errorf("reading token file: %w", err)
+	}
+	line, _, _ := strings.Cut(string(data), "\n")
+	if strings.TrimSpace(line) == "" {
+		return "", fmt.Errorf("%w: %s", ErrTokenEmpty, s.Path)
+	}
+	return strings.TrimSpace(line), nil
+}
+
+func (s FileTokenSource) String() string { return "token file " + s.Path }
+
+// GHTokenSource reads the token the GitHub CLI is logged in with.
+type GHTokenSource struct {
+	Host string
+}
+
+// Token runs `gh auth token`.
+func (s GHTokenSource) Token(ctx context.Context) (string, error) {
+	output, err := runTokenCommand(ctx, nil, "gh", "auth", "token", "--hostname", s.Host)
+	if err != nil {
+		return "", fmt.Errorf("%w; run gh auth login --hostname %s", err, s.Host)
+	}
+	return firstLine(output, s)
+}
+
+func (s GHTokenSource) String() string { return "gh auth token for " + s.Host }
+
+// GitCredentialTokenSource asks git's configured credential helpers for the password stored for
+// the host, using the git credential protocol.
+type GitCredentialTokenSource struct {
+	Host string
+}
+
+// Token runs `git credential fill` without allowing it to prompt.
+func (s GitCredentialTokenSource) Token(ctx context.Context) (string, error) {
+	request := fmt.Sprintf("protocol=https\nhost=%s\n\n", s.Host)
+	output, err := runTokenCommand(ctx, strings.NewReader(request), "git", "credential", "fill")
+	if err != nil {
+		return "", err
+	}
+	for _, line := range strings.Split(string(output), "\n") {
+		if password, ok := strings.CutPrefix(line, "password="); ok && strings.TrimSpace(password) != "" {
+			return strings.TrimSpace(password), nil
+		}
+	}
+	return "", fmt.Errorf("%w: no password stored for %s", ErrTokenEmpty, s.Host)
+}
+
+func (s GitCredentialTokenSource) String() string { return "git credential helper for " + s.Host }
+
+// CommandTokenSource runs an external command and reads the token from the first line of its
+// output.
+type CommandTokenSource struct {
+	Args []string
+}
+
+// Token runs the command.
+func (s CommandTokenSource) Token(ctx context.Context) (string, error) {
+	output, err := runTokenCommand(ctx, nil, s.Args[0], s.Args[1:]...)
+	if err != nil {
+		return "", err
+	}
+	return firstLine(output, s)
+}
+
+// String names only the program, since arguments may carry secrets.
+func (s CommandTokenSource) String() string { return "command " + s.Args[0] }
+
+// runTokenCommand runs name with args, feeding it stdin, and returns its standard output. The
+// command's standard error is included in the returned error.
+func runTokenCommand(ctx context.Context, stdin *strings.Reader, name string, args ...string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, tokenCommandTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, name, args...) // #nosec G204 - the command is chosen by the user
+	if stdin != nil {
+		cmd.Stdin = stdin
+	}
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		if detail := strings.TrimSpace(stderr.String()); detail != "" {
+			return nil, fmt.Errorf("%w: %s: %w: %s", ErrTokenCommandFailed, name, err, detail)
+		}
+		return nil, fmt.Errorf("%w: %s: %w", ErrTokenCommandFailed, name, err)
+	}
+	return stdout.Bytes(), nil
+}
+
+func firstLine(output []byte, source TokenSource) (string, error) {
+	line, _, _ := strings.Cut(string(output), "\n")
+	if strings.TrimSpace(line) == "" {
+		return "", fmt.Errorf("%w: %s printed nothing", ErrTokenEmpty, source)
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// expandHome replaces a leading ~/ with the user's home directory.
+func expandHome(path string) string {
+	rest, ok := strings.CutPrefix(path, "~/")
+	if !ok {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, rest)
+}
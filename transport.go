@@ -0,0 +1,348 @@
+package ownershit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-retryablehttp"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/oauth2"
+)
+
+// Environment variables configuring retries, shared by the REST and GraphQL clients.
+const (
+	EnvTimeoutSeconds         = "OWNERSHIT_TIMEOUT_SECONDS"
+	EnvMaxRetries             = "OWNERSHIT_MAX_RETRIES"
+	EnvWaitIntervalSeconds    = "OWNERSHIT_WAIT_INTERVAL_SECONDS"
+	EnvBackoffMultiplier      = "OWNERSHIT_BACKOFF_MULTIPLIER"
+	EnvRateLimitBudgetSeconds = "OWNERSHIT_RATE_LIMIT_BUDGET_SECONDS"
+)
+
+const (
+	// DefaultRateLimitBudget is the longest a request waits for a rate limit to reset by default.
+	DefaultRateLimitBudget = 2 * time.Minute
+	// secondaryRateLimitWait is how long to wait after a secondary rate limit without a
+	// Retry-After header, as GitHub recommends.
+	secondaryRateLimitWait = time.Minute
+	// rateLimitBodyPeek bounds how much of a 403 body is read to recognize a rate limit.
+	rateLimitBodyPeek = 4096
+)
+
+// RetryConfig configures the retrying transport.
+type RetryConfig struct {
+	// Timeout bounds each attempt.
+	Timeout time.Duration
+	// MaxRetries is the number of retries after the first attempt.
+	MaxRetries int
+	// WaitInterval is the first backoff; later backoffs double up to WaitInterval*Multiplier.
+	WaitInterval time.Duration
+	Multiplier   float64
+	// RateLimitBudget is the longest a request waits for a rate limit to reset. A longer wait
+	// fails with a RateLimitError. Zero selects DefaultRateLimitBudget.
+	RateLimitBudget time.Duration
+}
+
+// DefaultRetryConfig returns the retry configuration used when no environment variables are set.
+func DefaultRetryConfig() RetryConfig {
+	return RetryConfig{
+		Timeout:      10 * time.Second,
+		MaxRetries:   3,
+		WaitInterval: 10 * time.Second,
+		Multiplier:   2.0,
+	}
+}
+
+// RetryConfigFromEnv returns DefaultRetryConfig overridden by the OWNERSHIT_* retry environment
+// variables. Invalid values are reported as errors.
+func RetryConfigFromEnv() (RetryConfig, error) {
+	cfg := DefaultRetryConfig()
+	for name, target := range map[string]*time.Duration{
+		EnvTimeoutSeconds:         &cfg.Timeout,
+		EnvWaitIntervalSeconds:    &cfg.WaitInterval,
+		EnvRateLimitBudgetSeconds: &cfg.RateLimitBudget,
+	} {
+		if val := os.Getenv(name); val != "" {
+			seconds, err := strconv.Atoi(val)
+			if err != nil {
+				return RetryConfig{}, fmt.Errorf("parsing %s: %w", name, err)
+			}
+			*target = time.Duration(seconds) * time.Second
+		}
+	}
+	if val := os.Getenv(EnvMaxRetries); val != "" {
+		retries, err := strconv.Atoi(val)
+		if err != nil {
+			return RetryConfig{}, fmt.Errorf("parsing %s: %w", EnvMaxRetries, err)
+		}
+		cfg.MaxRetries = retries
+	}
+	if val := os.Getenv(EnvBackoffMultiplier); val != "" {
+		multiplier, err := strconv.ParseFloat(val, 64)
+		if err != nil {
+			return RetryConfig{}, fmt.Errorf("parsing %s: %w", EnvBackoffMultiplier, err)
+		}
+		cfg.Multiplier = multiplier
+	}
+	return cfg, nil
+}
+
+// NewRetryClient returns a client that sends requests through base, retrying network errors
+// and 5xx responses with exponential backoff, except for requests marked non-idempotent by
+// StandardRetryClient. Rate limited responses (429, or 403 with an
+// exhausted X-RateLimit-Remaining or a secondary rate limit message) are retried after the wait
+// given by Retry-After or X-RateLimit-Reset, unless that wait exceeds the rate limit budget, in
+// which case the request fails with a RateLimitError. When retries run out, the last response
+// is returned so callers see GitHub's error.
+func NewRetryClient(cfg RetryConfig, base http.RoundTripper) *retryablehttp.Client {
+	if cfg.RateLimitBudget <= 0 {
+		cfg.RateLimitBudget = DefaultRateLimitBudget
+	}
+	policy := &retryPolicy{budget: cfg.RateLimitBudget, now: time.Now}
+	client := retryablehttp.NewClient()
+	client.HTTPClient.Timeout = cfg.Timeout
+	client.HTTPClient.Transport = base
+	client.Logger = retryLogger{}
+	client.RetryMax = cfg.MaxRetries
+	client.RetryWaitMin = cfg.WaitInterval
+	// The longest backoff is kept to whole seconds, like the interval it is derived from.
+	client.RetryWaitMax = time.Duration(float64(cfg.WaitInterval/time.Second)*cfg.Multiplier) * time.Second
+	client.CheckRetry = policy.checkRetry
+	client.Backoff = policy.backoff
+	client.ErrorHandler = retryErrorHandler
+	return client
+}
+
+// newTokenHTTPClient returns an HTTP client that authenticates with source, retries through
+// NewRetryClient configured from the environment, and revalidates GET responses held by the
+// active HTTPCache under cacheScope, the identity source authenticates as.
+func newTokenHTTPClient(source oauth2.TokenSource, cacheScope string) (*http.Client, error) {
+	cfg, err := RetryConfigFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	return newTokenHTTPClientWithConfig(source, cacheScope, cfg), nil
+}
+
+func newTokenHTTPClientWithConfig(source oauth2.TokenSource, cacheScope string, cfg RetryConfig) *http.Client {
+	auth := &oauth2.Transport{
+		Source: oauth2.ReuseTokenSource(nil, source),
+		Base:   ActiveHTTPCache().Transport(cacheScope, http.DefaultTransport),
+	}
+	return StandardRetryClient(NewRetryClient(cfg, auth))
+}
+
+// retryPolicy decides whether and how long to wait before retrying a response.
+type retryPolicy struct {
+	budget time.Duration
+	now    func() time.Time
+}
+
+func (p *retryPolicy) checkRetry(ctx context.Context, resp *http.Response, err error) (bool, error) {
+	if ctx.Err() != nil {
+		return false, ctx.Err()
+	}
+	if err != nil || resp == nil {
+		// An unknown host is a misconfigured URL, not a transient failure.
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) && dnsErr.IsNotFound || ctx.Value(nonIdempotentKey{}) != nil {
+			return false, nil
+		}
+		return retryablehttp.DefaultRetryPolicy(ctx, resp, err)
+	}
+	if wait, limited := p.rateLimitWait(resp); limited {
+		if wait > p.budget {
+			return false, p.rateLimitError(resp, wait)
+		}
+		log.Debug().
+			Str("url", resp.Request.URL.Redacted()).
+			Dur("wait", wait).
+			Msg("rate limited; waiting before retrying")
+		return true, nil
+	}
+	if resp.StatusCode >= http.StatusInternalServerError && resp.StatusCode != http.StatusNotImplemented {
+		// A rate limited request was never processed, but a failed one may have been.
+		return ctx.Value(nonIdempotentKey{}) == nil, nil
+	}
+	return false, nil
+}
+
+// nonIdempotentKey marks, in a request's context, that the request must not be retried after a
+// server or network error.
+type nonIdempotentKey struct{}
+
+// StandardRetryClient returns client as an *http.Client. Requests that are not idempotent, REST
+// requests other than GET, HEAD, OPTIONS, PUT and DELETE and GraphQL mutations, are not retried
+// after a server or network error, as GitHub may have processed them; rate limited requests,
+// which GitHub did not process, are still retried.
+func StandardRetryClient(client *retryablehttp.Client) *http.Client {
+	return &http.Client{Transport: &retrySafetyTransport{base: &retryablehttp.RoundTripper{Client: client}}}
+}
+
+// retrySafetyTransport marks non-idempotent requests for retryPolicy, which only sees a request's
+// context.
+type retrySafetyTransport struct {
+	base http.RoundTripper
+}
+
+func (t *retrySafetyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !isIdempotentRequest(req) {
+		req = req.WithContext(context.WithValue(req.Context(), nonIdempotentKey{}, true))
+	}
+	return t.base.RoundTrip(req)
+}
+
+// isIdempotentRequest reports whether repeating req has the same effect as sending it once: REST
+// requests with an idempotent method, and GraphQL queries.
+func isIdempotentRequest(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	case http.MethodPost:
+		return strings.HasSuffix(req.URL.Path, "/graphql") && isGraphQLQuery(req)
+	}
+	return false
+}
+
+// isGraphQLQuery reports whether the body of req is a GraphQL query rather than a mutation. The
+// body is restored so it can still be sent.
+func isGraphQLQuery(req *http.Request) bool {
+	if req.Body == nil || req.Body == http.NoBody {
+		return false
+	}
+	body, err := io.ReadAll(req.Body)
+	_ = req.Body.Close()
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return false
+	}
+	var payload struct {
+		Query string `json:"query"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return false
+	}
+	query := strings.TrimSpace(payload.Query)
+	return !strings.HasPrefix(query, "mutation") && !strings.HasPrefix(query, "subscription")
+}
+
+func (p *retryPolicy) backoff(minWait, maxWait time.Duration, attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if wait, limited := p.rateLimitWait(resp); limited {
+			return wait
+		}
+	}
+	wait := float64(minWait) * math.Pow(2, float64(attempt))
+	if wait > float64(maxWait) {
+		return maxWait
+	}
+	return time.Duration(wait)
+}
+
+// rateLimitWait reports whether resp is a rate limited response and how long to wait before
+// retrying it.
+func (p *retryPolicy) rateLimitWait(resp *http.Response) (time.Duration, bool) {
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+	case http.StatusForbidden:
+		if resp.Header.Get("Retry-After") == "" && resp.Header.Get("X-RateLimit-Remaining") != "0" &&
+			!isSecondaryRateLimit(resp) {
+			return 0, false
+		}
+	default:
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		if reset, ok := rateLimitReset(resp); ok {
+			// Wait a second past the reset to absorb clock skew.
+			if wait := reset.Sub(p.now()) + time.Second; wait > 0 {
+				return wait, true
+			}
+			return time.Second, true
+		}
+	}
+	return secondaryRateLimitWait, true
+}
+
+func (p *retryPolicy) rateLimitError(resp *http.Response, wait time.Duration) *RateLimitError {
+	reset, ok := rateLimitReset(resp)
+	if !ok {
+		reset = p.now().Add(wait)
+	}
+	remaining, _ := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining"))
+	message := fmt.Sprintf("waiting %s for %s exceeds the rate limit budget of %s",
+		wait.Round(time.Second), resp.Request.URL.Redacted(), p.budget)
+	return NewRateLimitError(reset, remaining, message, nil)
+}
+
+// rateLimitReset returns the time in the X-RateLimit-Reset header.
+func rateLimitReset(resp *http.Response) (time.Time, bool) {
+	epoch, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(epoch, 0), true
+}
+
+// isSecondaryRateLimit reports whether a 403 body describes a secondary rate limit. The body is
+// restored so it can still be decoded by the caller.
+func isSecondaryRateLimit(resp *http.Response) bool {
+	if resp.Body == nil {
+		return false
+	}
+	peek, err := io.ReadAll(io.LimitReader(resp.Body, rateLimitBodyPeek))
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(peek), resp.Body), resp.Body}
+	if err != nil {
+		return false
+	}
+	body := strings.ToLower(string(peek))
+	return strings.Contains(body, "secondary rate limit") || strings.Contains(body, "abuse detection")
+}
+
+// retryErrorHandler returns the last response when retries run out, and only the error when
+// the policy gave up with one, such as a RateLimitError.
+func retryErrorHandler(resp *http.Response, err error, _ int) (*http.Response, error) {
+	var rateLimitErr *RateLimitError
+	if errors.As(err, &rateLimitErr) {
+		if resp != nil {
+			_ = resp.Body.Close()
+		}
+		return nil, err
+	}
+	return resp, err
+}
+
+// retryLogger sends retry logging to zerolog at debug level.
+type retryLogger struct{}
+
+func (retryLogger) Error(msg string, keysAndValues ...interface{}) {
+	log.Debug().Fields(keysAndValues).Msg(msg)
+}
+
+func (retryLogger) Info(msg string, keysAndValues ...interface{}) {
+	log.Debug().Fields(keysAndValues).Msg(msg)
+}
+
+func (retryLogger) Debug(msg string, keysAndValues ...interface{}) {
+	log.Debug().Fields(keysAndValues).Msg(msg)
+}
+
+func (retryLogger) Warn(msg string, keysAndValues ...interface{}) {
+	log.Warn().Fields(keysAndValues).Msg(msg)
+}
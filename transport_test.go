@@ -0,0 +1,183 @@
+package ownershit
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newRetryTestClient serves responses from handler through NewRetryClient without backoff waits.
+func newRetryTestClient(t *testing.T, handler func(attempt int32, w http.ResponseWriter)) (*http.Client, string, *int32) {
+	t.Helper()
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler(atomic.AddInt32(&attempts, 1), w)
+	}))
+	t.Cleanup(server.Close)
+	client := NewRetryClient(RetryConfig{Timeout: 5 * time.Second, MaxRetries: 2, Multiplier: 2}, http.DefaultTransport)
+	return StandardRetryClient(client), server.URL, &attempts
+}
+
+func TestRetryClientRetriesServerErrors(t *testing.T) {
+	client, url, attempts := newRetryTestClient(t, func(attempt int32, w http.ResponseWriter) {
+		if attempt == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	})
+	resp, err := client.Get(url)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || *attempts != 2 {
+		t.Errorf("status = %d after %d attempts, want 200 after 2", resp.StatusCode, *attempts)
+	}
+}
+
+func TestRetryClientReturnsLastResponse(t *testing.T) {
+	client, url, attempts := newRetryTestClient(t, func(_ int32, w http.ResponseWriter) {
+		w.Header().Set("Retry-After", "0")
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+	resp, err := client.Get(url)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusTooManyRequests || *attempts != 3 {
+		t.Errorf("status = %d after %d attempts, want 429 after 3", resp.StatusCode, *attempts)
+	}
+}
+
+func TestRetryClientSecondaryRateLimit(t *testing.T) {
+	client, url, attempts := newRetryTestClient(t, func(attempt int32, w http.ResponseWriter) {
+		if attempt == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"message":"You have exceeded a secondary rate limit."}`))
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	})
+	resp, err := client.Get(url)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || *attempts != 2 {
+		t.Errorf("status = %d after %d attempts, want 200 after 2", resp.StatusCode, *attempts)
+	}
+}
+
+func TestRetryClientDoesNotRetryPermissionErrors(t *testing.T) {
+	body := `{"message":"Resource not accessible by integration"}`
+	client, url, attempts := newRetryTestClient(t, func(_ int32, w http.ResponseWriter) {
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(body))
+	})
+	resp, err := client.Get(url)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	defer resp.Body.Close()
+	got, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusForbidden || *attempts != 1 || string(got) != body {
+		t.Errorf("status = %d after %d attempts with body %q", resp.StatusCode, *attempts, got)
+	}
+}
+
+func TestRetryClientOnlyRetriesIdempotentRequests(t *testing.T) {
+	tests := []struct {
+		name, method, path, body string
+		status                   int
+		wantAttempts             int32
+	}{
+		{"REST POST after a server error", http.MethodPost, "/repos/acme/api/labels", `{"name":"bug"}`, http.StatusBadGateway, 1},
+		{"REST PATCH after a server error", http.MethodPatch, "/repos/acme/api", `{}`, http.StatusBadGateway, 1},
+		{"REST PUT after a server error", http.MethodPut, "/repos/acme/api/topics", `{}`, http.StatusBadGateway, 2},
+		{"GraphQL mutation after a server error", http.MethodPost, "/graphql",
+			`{"query":"mutation($input:CreateBranchProtectionRuleInput!){x}"}`, http.StatusBadGateway, 1},
+		{"GraphQL query after a server error", http.MethodPost, "/graphql", `{"query":"query{viewer{login}}"}`, http.StatusBadGateway, 2},
+		{"REST POST after a rate limit", http.MethodPost, "/repos/acme/api/labels", `{"name":"bug"}`, http.StatusTooManyRequests, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, url, attempts := newRetryTestClient(t, func(attempt int32, w http.ResponseWriter) {
+				if attempt == 1 {
+					w.Header().Set("Retry-After", "0")
+					w.WriteHeader(tt.status)
+					return
+				}
+				_, _ = w.Write([]byte("ok"))
+			})
+			req, err := http.NewRequest(tt.method, url+tt.path, strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatalf("Do() error = %v", err)
+			}
+			defer resp.Body.Close()
+			if *attempts != tt.wantAttempts {
+				t.Errorf("%d attempts, want %d", *attempts, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestRetryClientRateLimitBudget(t *testing.T) {
+	reset := time.Now().Add(time.Hour).Unix()
+	client, url, attempts := newRetryTestClient(t, func(_ int32, w http.ResponseWriter) {
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset, 10))
+		w.WriteHeader(http.StatusForbidden)
+	})
+	_, err := client.Get(url)
+	var rateLimitErr *RateLimitError
+	if !errors.As(err, &rateLimitErr) || !errors.Is(err, ErrRateLimit) {
+		t.Fatalf("Get() error = %v, want a RateLimitError", err)
+	}
+	if rateLimitErr.ResetTime.Unix() != reset || *attempts != 1 {
+		t.Errorf("ResetTime = %v after %d attempts", rateLimitErr.ResetTime, *attempts)
+	}
+}
+
+func TestRetryPolicyRateLimitWait(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	policy := &retryPolicy{budget: time.Minute, now: func() time.Time { return now }}
+	resp := &http.Response{StatusCode: http.StatusForbidden, Header: http.Header{}}
+	resp.Header.Set("X-RateLimit-Remaining", "0")
+	resp.Header.Set("X-RateLimit-Reset", strconv.FormatInt(now.Add(30*time.Second).Unix(), 10))
+	if wait, limited := policy.rateLimitWait(resp); !limited || wait != 31*time.Second {
+		t.Errorf("rateLimitWait() = %v, %v; want 31s, true", wait, limited)
+	}
+	if got := policy.backoff(time.Second, 4*time.Second, 3, nil); got != 4*time.Second {
+		t.Errorf("backoff() = %v, want the 4s maximum", got)
+	}
+}
+
+func TestRetryConfigFromEnv(t *testing.T) {
+	t.Setenv(EnvMaxRetries, "7")
+	t.Setenv(EnvRateLimitBudgetSeconds, "600")
+	cfg, err := RetryConfigFromEnv()
+	if err != nil {
+		t.Fatalf("RetryConfigFromEnv() error = %v", err)
+	}
+	if cfg.MaxRetries != 7 || cfg.RateLimitBudget != 10*time.Minute || cfg.Timeout != 10*time.Second {
+		t.Errorf("RetryConfigFromEnv() = %+v", cfg)
+	}
+
+	t.Setenv(EnvBackoffMultiplier, "fast")
+	if _, err := RetryConfigFromEnv(); err == nil {
+		t.Error("RetryConfigFromEnv() should reject an invalid multiplier")
+	}
+}
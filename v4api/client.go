@@ -4,15 +4,14 @@ package v4api
 import (
 	"context"
 	"fmt"
-	globalLog "log"
 	"net/http"
-	"os"
 	"strconv"
 	"time"
 
 	"github.com/Khan/genqlient/graphql"
 	"github.com/hashicorp/go-retryablehttp"
 	"github.com/rs/zerolog/log"
+	"golang.org/x/oauth2"
 
 	"github.com/klauern/ownershit"
 )
@@ -25,6 +24,8 @@ type GitHubV4Client struct {
 	retryClient *retryablehttp.Client
 	client      graphql.Client
 	Context     context.Context
+	// cacheScope identifies the instance and credentials in cache keys.
+	cacheScope string
 }
 
 // EnvVarPrefix is the prefix used for environment variable names.
@@ -51,6 +52,8 @@ const (
 	EnvWaitIntervalSeconds = "WAIT_INTERVAL_SECONDS"
 	// EnvBackoffMultiplier is the environment variable for backoff multiplier configuration.
 	EnvBackoffMultiplier = "BACKOFF_MULTIPLIER"
+	// EnvRateLimitBudgetSeconds is the environment variable for the longest wait for a rate limit reset.
+	EnvRateLimitBudgetSeconds = "RATE_LIMIT_BUDGET_SECONDS"
 )
 
 type retryParams struct {
@@ -58,10 +61,14 @@ type retryParams struct {
 	MaxRetries          int
 	Multiplier          float64
 	WaitIntervalSeconds int
+	// RateLimitBudgetSeconds is the longest wait for a rate limit reset; zero selects the default.
+	RateLimitBudgetSeconds int
 }
 
 type authedTransport struct {
-	key     string
+	key string
+	// source supplies refreshing tokens, such as GitHub App installation tokens, in place of key.
+	source  oauth2.TokenSource
 	wrapped http.RoundTripper
 }
 
@@ -81,92 +88,108 @@ type (
 )
 
 func (t *authedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
-	if t.key != "" {
+	if t.source != nil {
+		token, err := t.source.Token()
+		if err != nil {
+			return nil, fmt.Errorf("obtaining GitHub token: %w", err)
+		}
+		req = req.Clone(req.Context())
+		req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+	} else if t.key != "" {
 		req.Header.Set("Authorization", "Bearer "+t.key)
 	}
 	return t.wrapped.RoundTrip(req)
 }
 
+// parseEnv reads the retry configuration shared with the REST client from the OWNERSHIT_*
+// environment variables.
 func parseEnv() (*retryParams, error) {
-	params := &retryParams{
-		TimeoutSeconds:      defaultConfig.timeoutSeconds,
-		MaxRetries:          defaultConfig.maxRetries,
-		Multiplier:          defaultConfig.multiplier,
-		WaitIntervalSeconds: defaultConfig.waitIntervalSeconds,
-	}
-
-	parseEnvInt := func(env string, target *int) error {
-		if val := os.Getenv(EnvVarPrefix + env); val != "" {
-			if parsed, err := strconv.Atoi(val); err == nil {
-				*target = parsed
-			} else {
-				log.Warn().Err(err).Str("env", env).Msg("invalid env; using default")
-				return err
-			}
-		}
-		return nil
-	}
-
-	if err := parseEnvInt(EnvTimeoutSeconds, &params.TimeoutSeconds); err != nil {
-		return nil, err
-	}
-	if err := parseEnvInt(EnvMaxRetries, &params.MaxRetries); err != nil {
-		return nil, err
-	}
-	if err := parseEnvInt(EnvWaitIntervalSeconds, &params.WaitIntervalSeconds); err != nil {
+	cfg, err := ownershit.RetryConfigFromEnv()
+	if err != nil {
+		log.Warn().Err(err).Msg("invalid retry environment")
 		return nil, err
 	}
-
-	if val := os.Getenv(EnvVarPrefix + EnvBackoffMultiplier); val != "" {
-		if parsed, err := strconv.ParseFloat(val, 64); err == nil {
-			params.Multiplier = parsed
-		} else {
-			log.Warn().Err(err).Str("env", EnvBackoffMultiplier).Msg("invalid env; using default")
-			return nil, err
-		}
-	}
-
-	return params, nil
+	return &retryParams{
+		TimeoutSeconds:         int(cfg.Timeout / time.Second),
+		MaxRetries:             cfg.MaxRetries,
+		Multiplier:             cfg.Multiplier,
+		WaitIntervalSeconds:    int(cfg.WaitInterval / time.Second),
+		RateLimitBudgetSeconds: int(cfg.RateLimitBudget / time.Second),
+	}, nil
 }
 
 // NewGHv4Client constructs an authenticated GitHub GraphQL v4 client with
 // retry/backoff configuration derived from environment variables prefixed with
-// OWNERSHIT_. The GitHub token is validated before creating the client.
+// OWNERSHIT_. The GitHub token is validated before creating the client, and the
+// GitHub instance is taken from GITHUB_API_URL.
 func NewGHv4Client() (*GitHubV4Client, error) {
+	return NewGHv4ClientForURL(ownershit.GitHubURLFromEnv())
+}
+
+// NewGHv4ClientForURL constructs a client like NewGHv4Client for the GitHub instance
+// at baseURL, which may be a GitHub Enterprise Server host or its /api/v3 or
+// /api/graphql endpoint. An empty baseURL selects github.com.
+func NewGHv4ClientForURL(baseURL string) (*GitHubV4Client, error) {
+	return NewGHv4ClientFromSource(context.Background(), ownershit.EnvTokenSource{Name: ownershit.DefaultTokenEnv}, baseURL)
+}
+
+// NewGHv4ClientFromSource constructs a client like NewGHv4ClientForURL that takes
+// its token from source, such as a token file or the GitHub CLI.
+func NewGHv4ClientFromSource(ctx context.Context, source ownershit.TokenSource, baseURL string) (*GitHubV4Client, error) {
+	endpoints, err := ownershit.ResolveGitHubEndpoints(baseURL)
+	if err != nil {
+		return nil, err
+	}
 	params, err := parseEnv()
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse environment configuration: %w", err)
 	}
-	// Note: stdlib log expects an io.Writer; keep default output. RetryableHTTP logging
-	// is wired to the stdlib logger in buildClient().
-	key, err := ownershit.GetValidatedGitHubToken()
+	key, err := ownershit.ValidatedToken(ctx, source)
 	if err != nil {
 		return nil, fmt.Errorf("GitHub token validation failed: %w", err)
 	}
 
-	client := buildClient(params, key)
+	return newGHv4Client(buildClient(params, key), endpoints, ownershit.CacheScope(endpoints.GraphQL, key)), nil
+}
 
-	graphqlClient := graphql.NewClient("https://api.github.com/graphql", client.StandardClient())
+// NewGHv4ClientForApp constructs a client like NewGHv4Client that authenticates as the GitHub
+// App's installation in org, refreshing the installation token before it expires.
+func NewGHv4ClientForApp(ctx context.Context, app *ownershit.GitHubApp, org string) (*GitHubV4Client, error) {
+	params, err := parseEnv()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse environment configuration: %w", err)
+	}
+	source, err := app.InstallationTokenSource(ctx, org)
+	if err != nil {
+		return nil, err
+	}
+	client := buildClient(params, "")
+	client.HTTPClient.Transport = &authedTransport{source: source, wrapped: http.DefaultTransport}
+	scope := ownershit.CacheScope(app.Endpoints().GraphQL, "app", strconv.FormatInt(app.ID, 10), org)
+	return newGHv4Client(client, app.Endpoints(), scope), nil
+}
 
+func newGHv4Client(client *retryablehttp.Client, endpoints ownershit.GitHubEndpoints, cacheScope string) *GitHubV4Client {
 	return &GitHubV4Client{
-		baseClient:  client.StandardClient(),
+		baseClient:  ownershit.StandardRetryClient(client),
 		retryClient: client,
-		client:      graphqlClient,
+		client:      graphql.NewClient(endpoints.GraphQL, ownershit.StandardRetryClient(client)),
 		Context:     context.Background(),
-	}, nil
+		cacheScope:  cacheScope,
+	}
 }
 
-// buildClient sets up the retry functionality and attaches authentication to the client.
+// buildClient sets up the retry functionality shared with the REST client and attaches
+// authentication to the client.
 func buildClient(params *retryParams, key string) *retryablehttp.Client {
-	client := retryablehttp.NewClient()
-	client.HTTPClient.Timeout = time.Duration(params.TimeoutSeconds) * time.Second
-	client.Logger = globalLog.Default()
-	client.RetryMax = params.MaxRetries
-	client.RetryWaitMax = time.Duration(float64(params.WaitIntervalSeconds)*params.Multiplier) * time.Second
-	client.RetryWaitMin = time.Duration(params.WaitIntervalSeconds) * time.Second
-	client.HTTPClient.Transport = &authedTransport{
+	return ownershit.NewRetryClient(ownershit.RetryConfig{
+		Timeout:         time.Duration(params.TimeoutSeconds) * time.Second,
+		MaxRetries:      params.MaxRetries,
+		WaitInterval:    time.Duration(params.WaitIntervalSeconds) * time.Second,
+		Multiplier:      params.Multiplier,
+		RateLimitBudget: time.Duration(params.RateLimitBudgetSeconds) * time.Second,
+	}, &authedTransport{
 		key:     key,
 		wrapped: http.DefaultTransport,
-	}
-	return client
+	})
 }
@@ -10,6 +10,7 @@ import (
 	"github.com/Khan/genqlient/graphql"
 	"go.uber.org/mock/gomock"
 
+	"github.com/klauern/ownershit"
 	mock_graphql "github.com/klauern/ownershit/v4api/mocks"
 )
 
@@ -681,3 +682,34 @@ func containsString(s, substr string) bool {
 			return false
 		}()))
 }
+
+func TestGitHubV4Client_GetTeams_Cached(t *testing.T) {
+	cache, err := ownershit.NewHTTPCache(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewHTTPCache() error = %v", err)
+	}
+	ownershit.SetHTTPCache(cache)
+	defer ownershit.SetHTTPCache(nil)
+
+	ctrl := gomock.NewController(t)
+	mockClient := mock_graphql.NewMockClient(ctrl)
+	mockClient.EXPECT().MakeRequest(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, req *graphql.Request, resp *graphql.Response) error {
+			data := resp.Data.(*GetTeamsResponse)
+			data.Organization.Teams.Edges = []GetTeamsOrganizationTeamsTeamConnectionEdgesTeamEdge{
+				{Node: GetTeamsOrganizationTeamsTeamConnectionEdgesTeamEdgeNodeTeam{Name: "platform"}},
+			}
+			return nil
+		}).Times(1)
+
+	client := &GitHubV4Client{client: mockClient, Context: context.Background(), cacheScope: "test"}
+	for i := 0; i < 2; i++ {
+		teams, err := client.GetTeams("acme")
+		if err != nil {
+			t.Fatalf("GetTeams() error = %v", err)
+		}
+		if len(teams) != 1 || teams[0].Node.Name != "platform" {
+			t.Errorf("GetTeams() = %+v", teams)
+		}
+	}
+}
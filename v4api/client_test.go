@@ -4,12 +4,14 @@ import (
 	"context"
 	"errors"
 	"net/http"
+	"net/http/httptest"
 	"reflect"
 	"strings"
 	"testing"
 	"time"
 
 	"go.uber.org/mock/gomock"
+	"golang.org/x/oauth2"
 
 	mock_graphql "github.com/klauern/ownershit/v4api/mocks"
 )
@@ -136,6 +138,39 @@ func Test_authedTransport_RoundTrip(t *testing.T) {
 	}
 }
 
+func Test_authedTransport_TokenSource(t *testing.T) {
+	var got []string
+	transport := &authedTransport{
+		key:    "ignored",
+		source: oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "ghs_installation"}),
+		wrapped: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			got = append(got, req.Header.Get("Authorization"))
+			return &http.Response{StatusCode: 200, Body: http.NoBody, Header: make(http.Header)}, nil
+		}),
+	}
+	req, err := http.NewRequest(http.MethodGet, "http://example.com", http.NoBody)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if len(got) != 1 || got[0] != "Bearer ghs_installation" {
+		t.Errorf("Authorization headers = %v, want the token source's token", got)
+	}
+
+	transport.source = oauth2.ReuseTokenSource(nil, errorTokenSource{})
+	if _, err := transport.RoundTrip(req); err == nil {
+		t.Error("expected the token source error")
+	}
+}
+
+type errorTokenSource struct{}
+
+func (errorTokenSource) Token() (*oauth2.Token, error) { return nil, errors.New("token unavailable") }
+
 func Test_buildClient(t *testing.T) {
 	type args struct {
 		params *retryParams
@@ -663,6 +698,36 @@ func TestNewGHv4Client_TokenValidationError(t *testing.T) {
 	}
 }
 
+func TestNewGHv4ClientForURL(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", validTestToken())
+	t.Setenv(EnvVarPrefix+EnvMaxRetries, "0")
+	var gotPath string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data":{"rateLimit":{"limit":5000,"remaining":4999}}}`))
+	}))
+	defer server.Close()
+
+	for _, baseURL := range []string{server.URL, server.URL + "/api/v3", server.URL + "/api/graphql"} {
+		gotPath = ""
+		client, err := NewGHv4ClientForURL(baseURL)
+		if err != nil {
+			t.Fatalf("NewGHv4ClientForURL(%q) error = %v", baseURL, err)
+		}
+		if _, err := client.GetRateLimit(); err != nil {
+			t.Fatalf("GetRateLimit() error = %v", err)
+		}
+		if gotPath != "/api/graphql" {
+			t.Errorf("NewGHv4ClientForURL(%q) queried %q, want /api/graphql", baseURL, gotPath)
+		}
+	}
+
+	if _, err := NewGHv4ClientForURL("ftp://github.example.com"); err == nil {
+		t.Error("expected an error for an unsupported scheme")
+	}
+}
+
 // Helper type for testing error transport.
 type errorTransport struct{}
 
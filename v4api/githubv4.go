@@ -7,6 +7,8 @@ import (
 	"strings"
 
 	"github.com/rs/zerolog/log"
+
+	"github.com/klauern/ownershit"
 )
 
 var (
@@ -72,15 +74,22 @@ func (e *MultiLabelError) Unwrap() error {
 const V4ClientDefaultPageSize = 100
 
 // GetTeams returns all teams for the specified organization, handling pagination
-// under the hood and returning a flattened list of team edges.
+// under the hood and returning a flattened list of team edges. Results are kept in the
+// active HTTP cache for its TTL, since teams change rarely.
 func (c *GitHubV4Client) GetTeams(organization string) (OrganizationTeams, error) {
+	cache := ownershit.ActiveHTTPCache()
+	cacheKey := "teams " + c.cacheScope + " " + organization
+	var cached OrganizationTeams
+	if cache.Get(cacheKey, &cached) {
+		return cached, nil
+	}
 	orgTeams := []GetTeamsOrganizationTeamsTeamConnectionEdgesTeamEdge{}
 	initResp, err := GetTeams(c.Context, c.client, TeamOrder{
 		Direction: OrderDirectionDesc,
 		Field:     TeamOrderFieldName,
 	}, V4ClientDefaultPageSize, "", organization)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get teams (initial request): %w", err)
+		return nil, ownershit.ClassifyAPIError("list teams", organization, "failed to get teams (initial request)", err)
 	}
 	log.Debug().
 		Int("edges", len(initResp.Organization.Teams.Edges)).
@@ -96,7 +105,7 @@ func (c *GitHubV4Client) GetTeams(organization string) (OrganizationTeams, error
 			Field:     TeamOrderFieldName,
 		}, V4ClientDefaultPageSize, cursor, organization)
 		if err != nil {
-			return nil, fmt.Errorf("failed to get teams (pagination request): %w", err)
+			return nil, ownershit.ClassifyAPIError("list teams", organization, "failed to get teams (pagination request)", err)
 		}
 		hasNextPage = resp.Organization.Teams.PageInfo.HasNextPage
 		cursor = resp.Organization.Teams.PageInfo.EndCursor
@@ -104,6 +113,7 @@ func (c *GitHubV4Client) GetTeams(organization string) (OrganizationTeams, error
 	}
 
 	log.Debug().Int("count", len(orgTeams)).Msg("teams fetched")
+	cache.Put(cacheKey, OrganizationTeams(orgTeams))
 
 	return orgTeams, nil
 }
@@ -113,7 +123,7 @@ func (c *GitHubV4Client) GetTeams(organization string) (OrganizationTeams, error
 func (c *GitHubV4Client) GetRateLimit() (RateLimit, error) {
 	resp, err := GetRateLimit(c.Context, c.client)
 	if err != nil {
-		return RateLimit{}, fmt.Errorf("can't get ratelimit: %w", err)
+		return RateLimit{}, ownershit.ClassifyAPIError("get rate limit", "", "can't get ratelimit", err)
 	}
 	log.Debug().Interface("rate-limit", resp).Msg("rate limit")
 	return RateLimit(*resp), nil
@@ -127,7 +137,7 @@ func (c *GitHubV4Client) fetchExistingLabels(repo, owner string) (existingLabels
 	for {
 		labelResp, err := GetRepositoryIssueLabels(c.Context, c.client, repo, owner, cursor)
 		if err != nil {
-			return nil, "", fmt.Errorf("can't get labels for %s/%s: %w", owner, repo, err)
+			return nil, "", ownershit.ClassifyAPIError("list repository labels", fmt.Sprintf("%s/%s", owner, repo), "can't get labels", err)
 		}
 		if repoID == "" {
 			if labelResp.Repository.Id == "" {